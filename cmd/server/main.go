@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	// Quiet hours are in a named timezone, the final image has no zoneinfo
+	_ "time/tzdata"
 
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/database"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/routes"
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
 	"github.com/gin-gonic/gin"
@@ -36,6 +39,11 @@ func main() {
 	}
 	defer database.Close(dbpool)
 
+	// Create the tables owned by this service
+	if err := database.Migrate(context.Background(), dbpool); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
 	// Initialize WhatsApp service
 	ctx := context.Background()
 	whatsappService, err := services.NewWhatsAppService(ctx, cfg)
@@ -44,18 +52,94 @@ func main() {
 	}
 	defer whatsappService.Close()
 
-	// Connect to WhatsApp (this may show QR code for first-time setup)
+	repos := repository.NewPostgresRepositories(dbpool, cfg.DefaultPhoneCountry)
+
+	// Track delivery and read receipts of everything we send
+	tracker := services.NewMessageTracker(repos.Messages, whatsappService)
+	go tracker.Run(ctx)
+
+	// Match inbound messages to users and hand them to the conversation handlers
+	inboundRouter := services.NewInboundRouter(repos.Users, repos.Conversations)
+	inboundRouter.Attach(whatsappService)
+
+	// Internal alerts go to the groups routed for their category
+	notificationRoutes, err := services.NewNotificationRouter(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load notification routes: %v", err)
+	}
+	go notificationRoutes.Run(ctx)
+
+	// Message texts, every template is rendered once so a broken one fails startup
+	templates, err := services.NewMessageTemplates(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load message templates: %v", err)
+	}
+	go templates.Run(ctx)
+
+	// Deliver queued messages in the background, skipping numbers that aren't on WhatsApp
+	registrations := services.NewRegistrationCache(repos.Registrations, whatsappService, cfg)
+
+	// Messages to users wait for quiet hours to end
+	deliveryPolicy, err := services.NewDeliveryPolicy(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load delivery policy: %v", err)
+	}
+	outbox := services.NewOutbox(dbpool, whatsappService, tracker, registrations, deliveryPolicy, templates, notificationRoutes, cfg)
+	go outbox.Run(ctx)
+
+	// Conversation flows driven by customer replies. Account deletion goes first so
+	// a CANCEL reply always reaches it.
+	accountDeletion := services.NewAccountDeletionWorkflow(repos.Deletions, services.NewUserAccountDeleter(repos.Users), outbox, templates, notificationRoutes, cfg)
+	accountDeletion.Register(inboundRouter)
+	go accountDeletion.Run(ctx)
+
+	rentalIntake := services.NewRentalIntakeFlow(repos.RentalIntakes, outbox, templates, whatsappService, notificationRoutes, cfg)
+	rentalIntake.Register(inboundRouter)
+	go rentalIntake.Run(ctx)
+
+	sellChecklist := services.NewSellChecklistTracker(repos.Checklists, outbox, templates, whatsappService, notificationRoutes, cfg)
+	sellChecklist.Register(inboundRouter)
+	go sellChecklist.Run(ctx)
+
+	workflows := services.Workflows{
+		RentalIntake:    rentalIntake,
+		SellChecklist:   sellChecklist,
+		AccountDeletion: accountDeletion,
+	}
+
+	// Connect to WhatsApp (this may show QR code for first-time setup) and keep the
+	// session alive. This happens once every event handler is registered so no early
+	// message is missed.
+	supervisor := services.NewConnectionSupervisor(whatsappService, cfg)
+	go supervisor.Run(ctx)
+
+	// Liveness and readiness probes
+	health := services.NewHealthMonitor(dbpool, supervisor, whatsappService, outbox, cfg)
+	// Exit once liveness fails so the restart policy brings the service back, a
+	// HEALTHCHECK on its own doesn't restart anything under docker or compose
 	go func() {
-		if err := whatsappService.Connect(ctx); err != nil {
-			log.Printf("Failed to connect to WhatsApp: %v", err)
+		if err := health.Watch(ctx); err != nil {
+			log.Fatalf("Health Error: %v, exiting to be restarted", err)
 		}
 	}()
 
 	// Initialize Gin router
 	router := gin.Default()
 
-	// Setup routes with WhatsApp service
-	routes.SetupRoutes(router, dbpool, whatsappService, cfg)
+	// Setup routes with WhatsApp outbox
+	routes.SetupRoutes(router, routes.Dependencies{
+		DB:            dbpool,
+		Repos:         repos,
+		Messenger:     outbox,
+		Workflows:     workflows,
+		Notifications: notificationRoutes,
+		Connection:    supervisor,
+		Pairing:       whatsappService,
+		Health:        health,
+		Templates:     templates,
+		Scheduled:     outbox,
+		Config:        cfg,
+	})
 
 	// Start server
 	serverAddr := ":" + cfg.Port
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/phone"
 	"github.com/joho/godotenv"
 )
 
@@ -13,8 +18,57 @@ type Config struct {
 	GinMode             string
 	WhatsAppPairingMode string // "phone" or "qr"
 	WhatsAppPhoneNumber string // Phone number for pairing
-	WhatsAppGroupJID    string // Group JID for sending messages to groups
+	WhatsAppGroupJID    string // Group JID alerts go to when no notification route matches
+	DefaultPhoneCountry string // Country of phone numbers stored without a country code
 
+	// Routing of internal alerts to groups by category, reloaded when the file changes
+	NotificationRoutesFile           string
+	NotificationRoutesReloadInterval time.Duration
+
+	// Message texts, one text/template file per message, reloaded when a file changes
+	TemplatesDir            string
+	TemplatesReloadInterval time.Duration
+	DefaultLocale           string // Locale of users without a translation in their preferred language
+	TeamLocale              string // Locale of internal alerts, whatever the user's language
+
+	// Quiet hours in DeliveryTimezone ("HH:MM"), messages to users due in between are
+	// held until they end. Disabled unless both are set.
+	QuietHoursStart  string
+	QuietHoursEnd    string
+	DeliveryTimezone string
+
+	WhatsAppRegistrationTTL time.Duration // How long a check whether a number is on WhatsApp is trusted
+
+	// Reconnects use exponential backoff with jitter between these bounds
+	WhatsAppReconnectBaseDelay time.Duration
+	WhatsAppReconnectMaxDelay  time.Duration
+	// The backoff is only reset once a session has stayed up this long
+	WhatsAppStableConnection time.Duration
+
+	HealthMaxDisconnected time.Duration // Liveness fails once the session has been down this long
+
+	// Webhook authentication, the previous secret stays valid while a new one is rolled out
+	WebhookSecret         string
+	WebhookSecretPrevious string
+	WebhookDedupWindow    time.Duration // Retries of a webhook event within this window are answered from the first response
+
+	AdminToken string // Bearer token for the operator endpoints under /admin
+
+	// Outbox delivery settings
+	OutboxMaxAttempts  int           // Attempts before a message is dead-lettered
+	OutboxBaseBackoff  time.Duration // Delay before the first retry, doubled on each attempt
+	OutboxMaxBackoff   time.Duration // Upper bound for the retry delay
+	OutboxPollInterval time.Duration // How often the worker looks for due messages
+
+	MediaDir            string        // Directory where media received over WhatsApp is stored
+	BrochurePath        string        // Construction brochure PDF sent on download, empty disables it
+	RentalIntakeTimeout time.Duration // Inactivity after which a rental intake conversation expires
+
+	// Sell request checklist reminders
+	SellChecklistReminderInterval time.Duration // Inactivity before a seller is reminded about missing items
+	SellChecklistMaxReminders     int           // Reminders sent per checklist before we stop asking
+
+	AccountDeletionGracePeriod time.Duration // Time a user has to cancel an account deletion
 }
 
 // Load loads configuration from environment variables
@@ -32,6 +86,47 @@ func Load() (*Config, error) {
 		WhatsAppPairingMode: getEnv("WHATSAPP_PAIRING_MODE", "phone"), // Default to phone pairing
 		WhatsAppPhoneNumber: getEnv("WHATSAPP_PHONE_NUMBER", ""),
 		WhatsAppGroupJID:    getEnv("WHATSAPP_GROUP_JID", ""),
+		DefaultPhoneCountry: strings.ToUpper(getEnv("DEFAULT_PHONE_COUNTRY", phone.DefaultCountry)),
+
+		NotificationRoutesFile:           getEnv("NOTIFICATION_ROUTES_FILE", ""),
+		NotificationRoutesReloadInterval: getEnvDuration("NOTIFICATION_ROUTES_RELOAD_INTERVAL", 30*time.Second),
+
+		TemplatesDir:            getEnv("TEMPLATES_DIR", "templates"),
+		TemplatesReloadInterval: getEnvDuration("TEMPLATES_RELOAD_INTERVAL", 30*time.Second),
+		DefaultLocale:           getEnv("DEFAULT_LOCALE", "en"),
+		TeamLocale:              getEnv("TEAM_LOCALE", "en"),
+
+		QuietHoursStart:  getEnv("QUIET_HOURS_START", ""),
+		QuietHoursEnd:    getEnv("QUIET_HOURS_END", ""),
+		DeliveryTimezone: getEnv("DELIVERY_TIMEZONE", "Asia/Kolkata"),
+
+		WhatsAppRegistrationTTL: getEnvDuration("WHATSAPP_REGISTRATION_TTL", 7*24*time.Hour),
+
+		WhatsAppReconnectBaseDelay: getEnvDuration("WHATSAPP_RECONNECT_BASE_DELAY", 2*time.Second),
+		WhatsAppReconnectMaxDelay:  getEnvDuration("WHATSAPP_RECONNECT_MAX_DELAY", 5*time.Minute),
+		WhatsAppStableConnection:   getEnvDuration("WHATSAPP_STABLE_CONNECTION", time.Minute),
+
+		HealthMaxDisconnected: getEnvDuration("HEALTH_MAX_DISCONNECTED", 15*time.Minute),
+
+		WebhookSecret:         getEnv("WEBHOOK_SECRET", ""),
+		WebhookSecretPrevious: getEnv("WEBHOOK_SECRET_PREVIOUS", ""),
+		WebhookDedupWindow:    getEnvDuration("WEBHOOK_DEDUP_WINDOW", 24*time.Hour),
+
+		AdminToken: getEnv("ADMIN_TOKEN", ""),
+
+		OutboxMaxAttempts:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 8),
+		OutboxBaseBackoff:  getEnvDuration("OUTBOX_BASE_BACKOFF", 30*time.Second),
+		OutboxMaxBackoff:   getEnvDuration("OUTBOX_MAX_BACKOFF", time.Hour),
+		OutboxPollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", 5*time.Second),
+
+		MediaDir:            getEnv("MEDIA_DIR", "media"),
+		BrochurePath:        getEnv("BROCHURE_PATH", ""),
+		RentalIntakeTimeout: getEnvDuration("RENTAL_INTAKE_TIMEOUT", 24*time.Hour),
+
+		SellChecklistReminderInterval: getEnvDuration("SELL_CHECKLIST_REMINDER_INTERVAL", 24*time.Hour),
+		SellChecklistMaxReminders:     getEnvInt("SELL_CHECKLIST_MAX_REMINDERS", 3),
+
+		AccountDeletionGracePeriod: getEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD", 24*time.Hour),
 	}
 
 	return config, nil
@@ -53,10 +148,42 @@ func getEnv(key, fallback string) string {
 // 	return fallback
 // }
 
+// getEnvInt gets an environment variable as an integer with a fallback value
+func getEnvInt(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("Warning: invalid integer for %s (%q), using %d", key, value, fallback)
+			return fallback
+		}
+		return parsed
+	}
+	return fallback
+}
+
+// getEnvDuration gets an environment variable as a duration (e.g. "30s", "5m") with a fallback value
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			log.Printf("Warning: invalid duration for %s (%q), using %s", key, value, fallback)
+			return fallback
+		}
+		return parsed
+	}
+	return fallback
+}
+
 // Validate checks if required configuration values are present
 func (c *Config) Validate() error {
 	if c.DatabaseURL == "" {
 		log.Fatal("DATABASE_URL environment variable is required")
 	}
+	if c.WhatsAppGroupJID == "" {
+		return fmt.Errorf("WHATSAPP_GROUP_JID environment variable is required")
+	}
+	if !phone.SupportedCountry(c.DefaultPhoneCountry) {
+		return fmt.Errorf("unsupported DEFAULT_PHONE_COUNTRY %q, expected one of %s", c.DefaultPhoneCountry, strings.Join(phone.SupportedCountries(), ", "))
+	}
 	return nil
 }
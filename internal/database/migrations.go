@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// schema holds the statements creating the tables owned by this service.
+// Every statement must be idempotent since they all run on each startup.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS whatsapp_outbox (
+		id              BIGSERIAL PRIMARY KEY,
+		recipient_type  TEXT NOT NULL,
+		recipient       TEXT NOT NULL,
+		payload         JSONB NOT NULL,
+		status          TEXT NOT NULL DEFAULT 'pending',
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		last_error      TEXT,
+		wa_message_id   TEXT,
+		created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+		sent_at         TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS whatsapp_outbox_due_idx
+		ON whatsapp_outbox (next_attempt_at) WHERE status = 'pending'`,
+	`CREATE TABLE IF NOT EXISTS whatsapp_messages (
+		message_id     TEXT PRIMARY KEY,
+		outbox_id      BIGINT REFERENCES whatsapp_outbox (id) ON DELETE SET NULL,
+		recipient_type TEXT NOT NULL,
+		recipient      TEXT NOT NULL,
+		status         TEXT NOT NULL DEFAULT 'sent',
+		sent_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+		delivered_at   TIMESTAMPTZ,
+		read_at        TIMESTAMPTZ,
+		failed_at      TIMESTAMPTZ,
+		updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS whatsapp_messages_recipient_idx
+		ON whatsapp_messages (recipient, sent_at DESC)`,
+	`CREATE TABLE IF NOT EXISTS conversation_log (
+		id           BIGSERIAL PRIMARY KEY,
+		message_id   TEXT NOT NULL UNIQUE,
+		chat_jid     TEXT NOT NULL,
+		sender_phone TEXT NOT NULL,
+		user_id      TEXT,
+		chat_type    TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		body         TEXT NOT NULL DEFAULT '',
+		received_at  TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS conversation_log_sender_idx
+		ON conversation_log (sender_phone, received_at DESC)`,
+	`CREATE TABLE IF NOT EXISTS rental_property_drafts (
+		id            BIGSERIAL PRIMARY KEY,
+		user_id       TEXT NOT NULL,
+		photos        TEXT[] NOT NULL DEFAULT '{}',
+		maps_link     TEXT,
+		latitude      DOUBLE PRECISION,
+		longitude     DOUBLE PRECISION,
+		description   TEXT NOT NULL,
+		rent          BIGINT NOT NULL,
+		contact_phone TEXT NOT NULL,
+		status        TEXT NOT NULL DEFAULT 'pending_review',
+		created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE TABLE IF NOT EXISTS rental_intake_sessions (
+		id            BIGSERIAL PRIMARY KEY,
+		user_id       TEXT NOT NULL,
+		phone         TEXT NOT NULL,
+		step          TEXT NOT NULL,
+		status        TEXT NOT NULL DEFAULT 'active',
+		photos        TEXT[] NOT NULL DEFAULT '{}',
+		maps_link     TEXT,
+		latitude      DOUBLE PRECISION,
+		longitude     DOUBLE PRECISION,
+		description   TEXT,
+		rent          BIGINT,
+		contact_phone TEXT,
+		draft_id      BIGINT REFERENCES rental_property_drafts (id),
+		created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS rental_intake_sessions_active_idx
+		ON rental_intake_sessions (phone) WHERE status = 'active'`,
+	`CREATE TABLE IF NOT EXISTS sell_request_checklists (
+		id               BIGSERIAL PRIMARY KEY,
+		sell_request_id  INTEGER NOT NULL UNIQUE,
+		user_id          TEXT NOT NULL,
+		phone            TEXT NOT NULL,
+		size             TEXT,
+		property_type    TEXT,
+		facing           TEXT,
+		map_location     TEXT,
+		images           TEXT[] NOT NULL DEFAULT '{}',
+		utaara_copy      TEXT,
+		reminders_sent   INTEGER NOT NULL DEFAULT 0,
+		last_reminder_at TIMESTAMPTZ,
+		completed_at     TIMESTAMPTZ,
+		created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS sell_request_checklists_open_idx
+		ON sell_request_checklists (phone) WHERE completed_at IS NULL`,
+	`CREATE TABLE IF NOT EXISTS webhook_events (
+		id                BIGSERIAL PRIMARY KEY,
+		dedup_key         TEXT NOT NULL UNIQUE,
+		event_table       TEXT NOT NULL,
+		event_type        TEXT NOT NULL,
+		record_id         BIGINT NOT NULL,
+		status_code       INTEGER,
+		response          JSONB,
+		duplicates        INTEGER NOT NULL DEFAULT 0,
+		received_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+		last_duplicate_at TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS webhook_events_duplicates_idx
+		ON webhook_events (last_duplicate_at) WHERE duplicates > 0`,
+	`CREATE TABLE IF NOT EXISTS account_deletions (
+		id            BIGSERIAL PRIMARY KEY,
+		user_id       TEXT NOT NULL,
+		phone         TEXT NOT NULL,
+		status        TEXT NOT NULL DEFAULT 'pending',
+		requested_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+		scheduled_for TIMESTAMPTZ NOT NULL,
+		cancelled_at  TIMESTAMPTZ,
+		completed_at  TIMESTAMPTZ,
+		last_error    TEXT
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS account_deletions_pending_idx
+		ON account_deletions (user_id) WHERE status = 'pending'`,
+	`CREATE TABLE IF NOT EXISTS construction_leads (
+		id         BIGSERIAL PRIMARY KEY,
+		user_id    TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		phone      TEXT NOT NULL,
+		source     TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE TABLE IF NOT EXISTS whatsapp_registrations (
+		phone      TEXT PRIMARY KEY,
+		registered BOOLEAN NOT NULL,
+		jid        TEXT,
+		checked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	// Direct messages queued during quiet hours are held until scheduled_for
+	`ALTER TABLE whatsapp_outbox ADD COLUMN IF NOT EXISTS scheduled_for TIMESTAMPTZ`,
+	`CREATE INDEX IF NOT EXISTS whatsapp_outbox_scheduled_idx
+		ON whatsapp_outbox (scheduled_for) WHERE status = 'pending' AND scheduled_for IS NOT NULL`,
+	// Outcome of the handlers of an inbound message, messages logged before it was
+	// recorded count as handled
+	`ALTER TABLE conversation_log ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'handled'`,
+	`ALTER TABLE conversation_log ADD COLUMN IF NOT EXISTS last_error TEXT`,
+	// Language of the user, for the messages workflows send without the user at hand
+	`ALTER TABLE rental_intake_sessions ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE sell_request_checklists ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE account_deletions ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT ''`,
+	// Replies to a user who is writing to us go out even during quiet hours, retries included
+	`ALTER TABLE whatsapp_outbox ADD COLUMN IF NOT EXISTS immediate BOOLEAN NOT NULL DEFAULT false`,
+	// Let the health check count dead messages and find the last send without
+	// scanning the whole outbox
+	`CREATE INDEX IF NOT EXISTS whatsapp_outbox_dead_idx ON whatsapp_outbox (id) WHERE status = 'dead'`,
+	`CREATE INDEX IF NOT EXISTS whatsapp_outbox_sent_idx ON whatsapp_outbox (sent_at) WHERE status = 'sent'`,
+	// Deletions claimed by a process that died before finishing are retried once the claim is stale
+	`ALTER TABLE account_deletions ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ`,
+	// Let inbound messages find their sender by phone digits without scanning every
+	// user, the expression must stay the same as in PostgresUserRepository.GetByPhone
+	`CREATE INDEX IF NOT EXISTS users_phone_digits_idx ON users ((regexp_replace(phone, '[^0-9]', '', 'g')))`,
+}
+
+// Migrate creates the tables this service needs if they don't exist yet
+func Migrate(ctx context.Context, dbpool *pgxpool.Pool) error {
+	for _, statement := range schema {
+		if _, err := dbpool.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("failed to apply schema: %v", err)
+		}
+	}
+	return nil
+}
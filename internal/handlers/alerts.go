@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/middleware"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// alertGroups returns the internal groups notified about a category
+func alertGroups(c *gin.Context, category string) []string {
+	routes, exists := middleware.GetGroupRouter(c)
+	if !exists {
+		log.Println("Could not get group router from context")
+		return nil
+	}
+	return routes.Groups(category)
+}
+
+// sendGroupAlert queues an internal alert to every group routed for the category
+func sendGroupAlert(c *gin.Context, messenger services.Messenger, category, message string) error {
+	routes, exists := middleware.GetGroupRouter(c)
+	if !exists {
+		return errors.New("group router not available")
+	}
+	err := services.SendToGroups(c.Request.Context(), messenger, routes, category, message)
+	if err != nil {
+		log.Printf("WhatsApp Error: Failed to queue %s alert: %v", category, err)
+	}
+	return err
+}
+
+// renderAlert renders the template of an internal alert in the team's language, a
+// failure is logged and the alert is skipped
+func renderAlert(c *gin.Context, name string, data any) (string, bool) {
+	templates, exists := middleware.GetMessageTemplates(c)
+	if !exists {
+		log.Println("Could not get message templates from context")
+		return "", false
+	}
+	alert, err := templates.RenderAlert(name, data)
+	if err != nil {
+		log.Printf("Template Error: Failed to render %s: %v", name, err)
+		return "", false
+	}
+	return alert, true
+}
+
+// NewNotificationRoutesHandler shows the groups every alert category goes to
+func NewNotificationRoutesHandler(notifications services.NotificationRoutesProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"notification_routes": notifications.Routes()})
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// NewConnectionStatusHandler reports the state of the WhatsApp session and its recent changes
+func NewConnectionStatusHandler(connection services.ConnectionStatusProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := connection.Status()
+		response := gin.H{
+			"state":       status.State,
+			"since":       status.Since,
+			"attempts":    status.Attempts,
+			"transitions": status.Transitions,
+		}
+		if status.LastError != "" {
+			response["last_error"] = status.LastError
+		}
+		if status.NextAttemptAt != nil {
+			response["next_attempt_at"] = status.NextAttemptAt
+		}
+		if status.State == models.ConnectionPairing {
+			response["action_required"] = "Pair the WhatsApp device again, messages are queued until then"
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
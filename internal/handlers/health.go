@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// NewLivenessHandler answers the orchestrator's liveness probe, 503 asks for a restart
+func NewLivenessHandler(health services.HealthReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, ok := health.Liveness()
+		c.JSON(healthStatusCode(ok), report)
+	}
+}
+
+// NewReadinessHandler answers readiness probes and uptime monitors, 503 means
+// messages can't be delivered right now
+func NewReadinessHandler(health services.HealthReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, ok := health.Readiness(c.Request.Context())
+		c.JSON(healthStatusCode(ok), report)
+	}
+}
+
+func healthStatusCode(ok bool) int {
+	if ok {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/middleware"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/phone"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultMessageLimit = 20
+	maxMessageLimit     = 100
+)
+
+// GetMessageStatus returns the delivery status of a single message by its WhatsApp ID
+func GetMessageStatus(c *gin.Context) {
+	messages, exists := middleware.GetMessageRepository(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Message repository not available"})
+		return
+	}
+
+	msg, err := messages.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrMessageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch message",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": msg})
+}
+
+// GetUserMessages lists the latest messages sent to a user with their delivery status,
+// and whether the user has read any of them
+func GetUserMessages(c *gin.Context) {
+	users, usersExist := middleware.GetUserRepository(c)
+	messages, messagesExist := middleware.GetMessageRepository(c)
+	if !usersExist || !messagesExist {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Repositories not available"})
+		return
+	}
+
+	limit := defaultMessageLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(parsed, maxMessageLimit)
+	}
+
+	user, err := users.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondUserLookupError(c, err)
+		return
+	}
+
+	// Messages are recorded to the normalized number, whatever form the user's phone is stored in
+	var country string
+	if cfg, exists := middleware.GetConfig(c); exists {
+		country = cfg.DefaultPhoneCountry
+	}
+	recipient := user.Phone
+	if number, err := phone.Normalize(user.Phone, country); err == nil {
+		recipient = number.Digits()
+	}
+
+	sent, err := messages.ListByRecipient(c.Request.Context(), recipient, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch messages",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	read := false
+	for _, msg := range sent {
+		if msg.Status == models.MessageRead {
+			read = true
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":  user.ID,
+		"phone":    user.Phone,
+		"messages": sent,
+		"read":     read,
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+)
+
+func TestGetUserMessagesReportsReadStatus(t *testing.T) {
+	// The user's phone is stored formatted, messages are recorded to the normalized number
+	user := testUser
+	user.Phone = "+91 90000 00001"
+	repos := newTestRepositories()
+	repos.Users = repository.NewMemoryUserRepository(user)
+	ctx := context.Background()
+	now := time.Now()
+	repos.Messages.Record(ctx, models.TrackedMessage{MessageID: "A", RecipientType: models.RecipientUser, Recipient: "919000000001", SentAt: now.Add(-time.Hour)})
+	repos.Messages.Record(ctx, models.TrackedMessage{MessageID: "B", RecipientType: models.RecipientUser, Recipient: "919000000001", SentAt: now})
+	repos.Messages.UpdateStatus(ctx, []string{"A"}, models.MessageDelivered, now)
+
+	router := newTestRouterWith(services.NewFakeMessenger(), repos)
+	router.GET("/users/:id/messages", GetUserMessages)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/user-1/messages", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Messages []models.TrackedMessage `json:"messages"`
+		Read     bool                    `json:"read"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Messages) != 2 || body.Messages[0].MessageID != "B" || body.Messages[1].Status != models.MessageDelivered {
+		t.Errorf("unexpected messages: %+v", body.Messages)
+	}
+	if body.Read {
+		t.Error("no message was read yet")
+	}
+
+	repos.Messages.UpdateStatus(ctx, []string{"B"}, models.MessageRead, now)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/user-1/messages?limit=1", nil))
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if !body.Read || len(body.Messages) != 1 {
+		t.Errorf("expected one read message, got %+v", body)
+	}
+}
+
+func TestGetMessageStatusNotFound(t *testing.T) {
+	router := newTestRouter(services.NewFakeMessenger())
+	router.GET("/messages/:id", GetMessageStatus)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/messages/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
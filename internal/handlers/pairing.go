@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	// pairingQRSize is the width and height of the QR code image in pixels
+	pairingQRSize = 320
+	// pairingKeepAlive is how often the event stream sends a ping, so proxies keep it open
+	pairingKeepAlive = 15 * time.Second
+)
+
+// NewStartPairingHandler starts linking the WhatsApp device. The body picks the method,
+// {"method": "qr"} or {"method": "phone", "phone": "+91..."}, QR is used without one.
+func NewStartPairingHandler(pairing services.PairingController) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.PairingRequest
+		if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload", "details": err.Error()})
+			return
+		}
+
+		status, err := pairing.StartPairing(request)
+		switch {
+		case errors.Is(err, services.ErrAlreadyPaired), errors.Is(err, services.ErrPairingInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "pairing": pairing.PairingStatus()})
+			return
+		case errors.Is(err, services.ErrInvalidRecipient), errors.Is(err, services.ErrInvalidPairingMethod):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start pairing", "details": err.Error()})
+			return
+		}
+
+		response := gin.H{
+			"message":    "Pairing started",
+			"pairing":    status,
+			"status_url": "/admin/pairing",
+			"events_url": "/admin/pairing/events",
+		}
+		if status.Method == models.PairingQR {
+			response["qr_url"] = "/admin/pairing/qr.png"
+		}
+		c.JSON(http.StatusAccepted, response)
+	}
+}
+
+// NewPairingStatusHandler returns the progress of the last pairing, including the
+// pairing code once it is ready
+func NewPairingStatusHandler(pairing services.PairingController) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"pairing": pairing.PairingStatus()})
+	}
+}
+
+// NewPairingQRHandler renders the current pairing QR code as a PNG image
+func NewPairingQRHandler(pairing services.PairingController) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := pairing.PairingStatus()
+		if status.State != models.PairingQRReady || status.QRCode == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No QR code to scan", "pairing": status})
+			return
+		}
+
+		png, err := qrcode.Encode(status.QRCode, qrcode.Medium, pairingQRSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render QR code", "details": err.Error()})
+			return
+		}
+		// QR codes are replaced every 20 seconds
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "image/png", png)
+	}
+}
+
+// NewPairingEventsHandler streams pairing status changes as server-sent events. The
+// stream ends once the device is paired or the pairing failed.
+func NewPairingEventsHandler(pairing services.PairingController) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		updates, cancel := pairing.SubscribePairing()
+		defer cancel()
+
+		keepAlive := time.NewTicker(pairingKeepAlive)
+		defer keepAlive.Stop()
+
+		c.Header("Cache-Control", "no-store")
+		c.SSEvent("pairing", pairing.PairingStatus())
+		c.Writer.Flush()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-keepAlive.C:
+				c.SSEvent("ping", time.Now())
+			case status := <-updates:
+				c.SSEvent("pairing", status)
+				if status.State == models.PairingSuccess || status.State == models.PairingFailed {
+					c.Writer.Flush()
+					return
+				}
+			}
+			c.Writer.Flush()
+		}
+	}
+}
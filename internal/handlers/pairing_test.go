@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// fakePairing records pairing requests and reports a fixed status
+type fakePairing struct {
+	status   models.PairingStatus
+	err      error
+	requests []models.PairingRequest
+}
+
+func (f *fakePairing) StartPairing(request models.PairingRequest) (models.PairingStatus, error) {
+	f.requests = append(f.requests, request)
+	if f.err != nil {
+		return models.PairingStatus{}, f.err
+	}
+	f.status = models.PairingStatus{State: models.PairingStarting, Method: request.Method, Phone: request.Phone}
+	if f.status.Method == "" {
+		f.status.Method = models.PairingQR
+	}
+	return f.status, nil
+}
+
+func (f *fakePairing) PairingStatus() models.PairingStatus {
+	return f.status
+}
+
+func (f *fakePairing) SubscribePairing() (<-chan models.PairingStatus, func()) {
+	updates := make(chan models.PairingStatus, 1)
+	updates <- models.PairingStatus{State: models.PairingSuccess}
+	return updates, func() {}
+}
+
+func newPairingRouter(pairing services.PairingController) *gin.Engine {
+	router := gin.New()
+	router.POST("/admin/pairing", NewStartPairingHandler(pairing))
+	router.GET("/admin/pairing", NewPairingStatusHandler(pairing))
+	router.GET("/admin/pairing/qr.png", NewPairingQRHandler(pairing))
+	router.GET("/admin/pairing/events", NewPairingEventsHandler(pairing))
+	return router
+}
+
+func TestStartPairing(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		want   int
+		qrLink bool
+	}{
+		{"qr without body", "", nil, http.StatusAccepted, true},
+		{"phone", `{"method":"phone","phone":"+919035577330"}`, nil, http.StatusAccepted, false},
+		{"already paired", `{"method":"qr"}`, services.ErrAlreadyPaired, http.StatusConflict, false},
+		{"invalid phone", `{"method":"phone","phone":"12"}`, services.ErrInvalidRecipient, http.StatusBadRequest, false},
+		{"invalid json", `{"method":`, nil, http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairing := &fakePairing{err: tt.err}
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/admin/pairing", strings.NewReader(tt.body))
+			newPairingRouter(pairing).ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			var response map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if _, ok := response["qr_url"]; ok != tt.qrLink {
+				t.Errorf("qr_url present = %v, want %v", ok, tt.qrLink)
+			}
+		})
+	}
+}
+
+func TestPairingQRImage(t *testing.T) {
+	pairing := &fakePairing{status: models.PairingStatus{State: models.PairingStarting}}
+	router := newPairingRouter(pairing)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/pairing/qr.png", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status without QR code = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	pairing.status = models.PairingStatus{State: models.PairingQRReady, QRCode: "2@abc,def,ghi,jkl"}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/pairing/qr.png", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("status = %d, content type %q, want a PNG", w.Code, w.Header().Get("Content-Type"))
+	}
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("invalid PNG: %v", err)
+	}
+	if size := img.Bounds().Dx(); size != pairingQRSize {
+		t.Errorf("QR code width = %d, want %d", size, pairingQRSize)
+	}
+}
+
+func TestPairingEventsEndOnSuccess(t *testing.T) {
+	pairing := &fakePairing{status: models.PairingStatus{State: models.PairingCodeReady, PairingCode: "ABCD-EFGH"}}
+
+	w := httptest.NewRecorder()
+	newPairingRouter(pairing).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/pairing/events", nil))
+
+	body := w.Body.String()
+	if strings.Count(body, "event:pairing") != 2 {
+		t.Fatalf("stream = %q, want the current status and the success", body)
+	}
+	if !strings.Contains(body, "ABCD-EFGH") || !strings.Contains(body, `"state":"success"`) {
+		t.Errorf("stream = %q, want the pairing code and the success", body)
+	}
+}
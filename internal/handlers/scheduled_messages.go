@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// NewScheduledMessagesHandler lists the messages to users held until quiet hours end
+func NewScheduledMessagesHandler(scheduled services.ScheduledMessages) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		messages, err := scheduled.ListScheduled(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list scheduled messages", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"messages": messages, "count": len(messages)})
+	}
+}
+
+// NewCancelScheduledMessageHandler cancels a message held until quiet hours end, so it
+// is never sent
+func NewCancelScheduledMessageHandler(scheduled services.ScheduledMessages) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+			return
+		}
+
+		msg, err := scheduled.CancelScheduled(c.Request.Context(), id)
+		if errors.Is(err, services.ErrNotScheduled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No scheduled message with this id, it may have been sent already"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel message", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Scheduled message cancelled", "data": msg})
+	}
+}
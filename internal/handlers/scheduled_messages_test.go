@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeScheduledMessages holds messages by id until they are cancelled
+type fakeScheduledMessages struct {
+	messages map[int64]models.OutboxMessage
+	err      error
+}
+
+func (f *fakeScheduledMessages) ListScheduled(ctx context.Context) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	for _, msg := range f.messages {
+		messages = append(messages, msg)
+	}
+	return messages, f.err
+}
+
+func (f *fakeScheduledMessages) CancelScheduled(ctx context.Context, id int64) (models.OutboxMessage, error) {
+	if f.err != nil {
+		return models.OutboxMessage{}, f.err
+	}
+	msg, ok := f.messages[id]
+	if !ok {
+		return models.OutboxMessage{}, fmt.Errorf("%w: %d", services.ErrNotScheduled, id)
+	}
+	delete(f.messages, id)
+	msg.Status = models.OutboxCancelled
+	return msg, nil
+}
+
+func newScheduledMessagesRouter(scheduled services.ScheduledMessages) *gin.Engine {
+	router := gin.New()
+	router.GET("/admin/scheduled-messages", NewScheduledMessagesHandler(scheduled))
+	router.DELETE("/admin/scheduled-messages/:id", NewCancelScheduledMessageHandler(scheduled))
+	return router
+}
+
+func TestScheduledMessagesListAndCancel(t *testing.T) {
+	scheduledFor := time.Now().Add(6 * time.Hour)
+	scheduled := &fakeScheduledMessages{messages: map[int64]models.OutboxMessage{
+		7: {ID: 7, RecipientType: models.RecipientUser, Recipient: "919000000001", Status: models.OutboxPending, ScheduledFor: &scheduledFor},
+	}}
+	router := newScheduledMessagesRouter(scheduled)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/scheduled-messages", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"count":1`) || !strings.Contains(w.Body.String(), `"scheduled_for"`) {
+		t.Fatalf("unexpected list response %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{"7", http.StatusOK},
+		{"7", http.StatusNotFound},
+		{"abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/scheduled-messages/"+tt.id, nil))
+		if w.Code != tt.want {
+			t.Errorf("DELETE %s = %d, want %d: %s", tt.id, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
+
+func TestScheduledMessagesFailures(t *testing.T) {
+	router := newScheduledMessagesRouter(&fakeScheduledMessages{err: errors.New("database down")})
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		path := "/admin/scheduled-messages"
+		if method == http.MethodDelete {
+			path += "/1"
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s = %d, want 500", method, w.Code)
+		}
+	}
+}
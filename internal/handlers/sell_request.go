@@ -1,29 +1,34 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/middleware"
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
-	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// NewSellRequestHandler returns a handler function that uses the WhatsApp service from the context
+// NewSellRequestHandler returns a handler function that sends WhatsApp messages through the messenger from the context.
+// A new sell request alerts the team and asks the seller for details, an update only
+// acts on the columns that changed, and a deletion lets the team know.
 func NewSellRequestHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get services from context
-		db, exists := middleware.GetDB(c)
+		users, exists := middleware.GetUserRepository(c)
 		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not available"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "User repository not available"})
 			return
 		}
-		whatsappService, exists := middleware.GetWhatsApp(c)
+		messenger, exists := middleware.GetMessenger(c)
 		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "WhatsApp service not available"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "WhatsApp messenger not available"})
 			return
 		}
 
@@ -36,79 +41,159 @@ func NewSellRequestHandler() gin.HandlerFunc {
 			return
 		}
 
-		sellRequestData := webhookPayload.Record
-		if sellRequestData.UserID == "" {
+		switch webhookPayload.Type {
+		case models.WebhookInsert, "":
+			handleSellRequestInsert(c, users, messenger, webhookPayload.Record)
+		case models.WebhookUpdate:
+			handleSellRequestUpdate(c, users, messenger, webhookPayload)
+		case models.WebhookDelete:
+			handleSellRequestDelete(c, messenger, webhookPayload)
+		default:
 			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "user_id is empty",
+				"error": fmt.Sprintf("Unsupported webhook type %q", webhookPayload.Type),
 			})
-			return
 		}
+	}
+}
 
-		// Fetch user data to get phone number and name for WhatsApp
-		var userData, err = utils.GetUserDataById(sellRequestData.UserID, db)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to fetch userData",
-				"details": err.Error(),
-			})
-			return
+// handleSellRequestInsert alerts the team about a new sell request and asks the seller for the property details
+func handleSellRequestInsert(c *gin.Context, users repository.UserRepository, messenger services.Messenger, sellRequestData models.SellRequest) {
+	if sellRequestData.UserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "user_id is empty",
+		})
+		return
+	}
+
+	// Fetch user data to get phone number and name for WhatsApp
+	userData, err := users.GetByID(c.Request.Context(), sellRequestData.UserID)
+	if err != nil {
+		respondUserLookupError(c, err)
+		return
+	}
+
+	// Message the seller first so the team alert can say whether they were reached
+	data := models.SellRequestMessageData{User: userData, SellRequest: sellRequestData}
+	onWhatsApp := recordUserSend(c, sendUserMessage(c, messenger, userData, services.TemplateSellRequest, data))
+	whatsappStatus, _ := userSendStatus(c)
+
+	if alert, ok := renderAlert(c, services.TemplateSellRequestAlert, data); ok {
+		if !onWhatsApp {
+			alert += notOnWhatsAppNotice
 		}
+		sendGroupAlert(c, messenger, models.CategorySellRequest, alert)
+	}
 
-		// Extract name safely (handle potential nil pointer)
-		userName := userData.Name
-		if userName == "" {
-			userName = "Valued Customer"
+	// Track which of the requested details the seller sends back
+	if workflows, exists := middleware.GetWorkflows(c); onWhatsApp && exists && workflows.SellChecklist != nil {
+		if err := workflows.SellChecklist.Start(c.Request.Context(), sellRequestData, userData); err != nil {
+			log.Printf("Failed to start checklist for sell request %d: %v", sellRequestData.Id, err)
 		}
+	}
 
-		log.Printf("WhatsApp Debug: User data - Name: %s, Phone: %s", userName, userData.Phone)
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Sell request received successfully",
+		"data":      sellRequestData,
+		"user_data": userData,
+		"whatsapp":  whatsappStatus,
+	})
+}
 
-		// For now, using hardcoded number for testing - you can switch to userData.Phone later
+// handleSellRequestUpdate tells the seller when their request was attended to or assigned to someone
+func handleSellRequestUpdate(c *gin.Context, users repository.UserRepository, messenger services.Messenger, webhookPayload models.WebhookPayload) {
+	sellRequestData := webhookPayload.Record
+	if webhookPayload.OldRecord == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "old_record is required for UPDATE"})
+		return
+	}
+	if sellRequestData.UserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "user_id is empty",
+		})
+		return
+	}
 
-		log.Printf("WhatsApp Debug: Attempting to send message to: %s", userData.Phone)
+	changes := sellRequestData.Diff(*webhookPayload.OldRecord)
+	log.Printf("Sell request %d updated, changed: %v", sellRequestData.Id, changes.Fields())
 
-		// Check if we should send to group instead of individual
-		log.Printf("WhatsApp Debug: Sending to group: %s", services.InternalGroupWhatsAppId)
-		err = whatsappService.SendSellRequestToGroup(
-			c.Request.Context(),
-			services.InternalGroupWhatsAppId,
-			userName,
-			sellRequestData.PropertyType,
-			sellRequestData.Address,
-			sellRequestData.Price,
-			userData.Phone,
-		)
+	_, attendedChanged := changes.Get("attended")
+	notifyAttended := attendedChanged && sellRequestData.Attended
+	_, assigneeChanged := changes.Get("assign_to")
+	notifyAssignee := assigneeChanged && sellRequestData.AssignTo != ""
 
+	actions := []string{}
+	if notifyAttended || notifyAssignee {
+		userData, err := users.GetByID(c.Request.Context(), sellRequestData.UserID)
 		if err != nil {
-			//TODO: Need to add logging system
+			respondUserLookupError(c, err)
+			return
 		}
 
-		err = whatsappService.SendMessage(c.Request.Context(), userData.Phone, services.SellRequestWhatAppMessage(userName))
+		data := models.SellRequestMessageData{User: userData, SellRequest: sellRequestData}
+		onWhatsApp := true
+		if notifyAttended {
+			err := sendUserMessage(c, messenger, userData, services.TemplateSellRequestAttended, data)
+			onWhatsApp = recordUserSend(c, err)
+			if err == nil {
+				actions = append(actions, "customer_notified_attended")
+			}
+		}
 
-		var whatsappStatus string
-		if err != nil {
-			log.Printf("WhatsApp Error: Failed to send message: %v", err)
-			whatsappStatus = "Failed to send WhatsApp message: " + err.Error()
-		} else {
-			log.Printf("WhatsApp Success: Message sent successfully to %s", userData.Phone)
-			whatsappStatus = "WhatsApp message sent successfully"
+		if notifyAssignee {
+			if onWhatsApp {
+				err := sendUserMessage(c, messenger, userData, services.TemplateSellRequestAssigned, data)
+				onWhatsApp = recordUserSend(c, err)
+				if err == nil {
+					actions = append(actions, "customer_notified_assigned")
+				}
+			}
+
+			if internalWAMessage, ok := renderAlert(c, services.TemplateSellRequestAssignedAlert, data); ok {
+				if !onWhatsApp {
+					internalWAMessage += notOnWhatsAppNotice
+				}
+				sendGroupAlert(c, messenger, models.CategorySellRequest, internalWAMessage)
+			}
 		}
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message":   "Sell request received successfully",
-			"data":      sellRequestData,
-			"user_data": userData,
-			"whatsapp":  whatsappStatus,
-		})
+	response := gin.H{
+		"message": "Sell request update processed",
+		"data":    sellRequestData,
+		"changes": changes,
+		"actions": actions,
 	}
+	if status, messaged := userSendStatus(c); messaged {
+		response["whatsapp"] = status
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// handleSellRequestDelete lets the team know a sell request was removed
+func handleSellRequestDelete(c *gin.Context, messenger services.Messenger, webhookPayload models.WebhookPayload) {
+	deleted := webhookPayload.OldRecord
+	if deleted == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "old_record is required for DELETE"})
+		return
+	}
+
+	if internalWAMessage, ok := renderAlert(c, services.TemplateSellRequestDeletedAlert, models.SellRequestMessageData{SellRequest: *deleted}); ok {
+		sendGroupAlert(c, messenger, models.CategorySellRequest, internalWAMessage)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sell request deletion processed",
+		"data":    deleted,
+	})
 }
 
 // SellRequestHandler is the original handler (kept for backward compatibility)
 func SellRequestHandler(c *gin.Context) {
-	// Get database connection from context
-	db, exists := middleware.GetDB(c)
+	// Get user repository from context
+	users, exists := middleware.GetUserRepository(c)
 	if !exists {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database connection not available",
+			"error": "User repository not available",
 		})
 		return
 	}
@@ -130,12 +215,9 @@ func SellRequestHandler(c *gin.Context) {
 		return
 	}
 
-	var userData, err = utils.GetUserDataById(sellRequestData.UserID, db)
+	userData, err := users.GetByID(c.Request.Context(), sellRequestData.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch userData",
-			"details": err.Error(),
-		})
+		respondUserLookupError(c, err)
 		return
 	}
 
@@ -146,11 +228,34 @@ func SellRequestHandler(c *gin.Context) {
 	})
 }
 
-func HandleUserLogs(c *gin.Context) {
-	db, exists := middleware.GetDB(c)
+// respondUserLookupError maps a failed user lookup to 404 or 500
+func respondUserLookupError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "User not found",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Failed to fetch userData",
+		"details": err.Error(),
+	})
+}
+
+// NewUserLogsHandler returns a handler that dispatches logged user actions to the
+// handlers registered for their event type
+func NewUserLogsHandler(registry *UserEventRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handleUserLogs(c, registry)
+	}
+}
+
+func handleUserLogs(c *gin.Context, registry *UserEventRegistry) {
+	users, exists := middleware.GetUserRepository(c)
 	if !exists {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database connection not available",
+			"error": "User repository not available",
 		})
 		return
 	}
@@ -166,6 +271,29 @@ func HandleUserLogs(c *gin.Context) {
 
 	logRequestData := LogsWebhookPayload.Record
 
+	// Only a new log entry is a user action, later edits by the team must not replay it
+	switch LogsWebhookPayload.Type {
+	case models.WebhookInsert, "":
+	case models.WebhookUpdate:
+		var changes models.RecordDiff
+		if LogsWebhookPayload.OldRecord != nil {
+			changes = logRequestData.Diff(*LogsWebhookPayload.OldRecord)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "User log update acknowledged",
+			"changes": changes,
+		})
+		return
+	case models.WebhookDelete:
+		c.JSON(http.StatusOK, gin.H{"message": "User log deletion acknowledged"})
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Unsupported webhook type %q", LogsWebhookPayload.Type),
+		})
+		return
+	}
+
 	if logRequestData.UserID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": "user_id is empty",
@@ -174,12 +302,9 @@ func HandleUserLogs(c *gin.Context) {
 	}
 
 	// Fetch user data
-	userData, err := utils.GetUserDataById(logRequestData.UserID, db)
+	userData, err := users.GetByID(c.Request.Context(), logRequestData.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch userData",
-			"details": err.Error(),
-		})
+		respondUserLookupError(c, err)
 		return
 	}
 
@@ -189,214 +314,293 @@ func HandleUserLogs(c *gin.Context) {
 		"user_data": userData,
 	}
 
-	// Handle different event types
-	switch logRequestData.EventType {
-	case models.CallPressed, models.WhatsAppPressed:
-		log.Printf("User %s initiated contact for property %v (event: %s)", userData.Name, *logRequestData.PropertyID, logRequestData.EventType)
-		response["action"] = "Property contact initiated"
-		if logRequestData.EventType.RequiresPropertyID() && logRequestData.PropertyID == nil {
-			response["warning"] = "Event requires a property ID, but none was provided."
-		}
-		PropertyInterest(c, userData, int(*logRequestData.PropertyID))
-
-	case models.ConstructionCallPressed, models.ConstructionWhatsAppPressed:
-		log.Printf("User %s interacted with construction item (event: %s)", userData.Name, logRequestData.EventType)
-		response["action"] = "Construction interaction"
-		ConstructionServicesEnq(c, userData)
-
-	case models.PostRentalPropertyPressed:
-		log.Printf("User %s pressed post rental property button", userData.Name)
-		response["action"] = "Post rental property button pressed"
-		RentalPropertyPost(c, userData)
-
-	case models.CustomPropertySearchRequest:
-		log.Printf("User %s made a custom property search request", userData.Name)
-		response["action"] = "Custom property search request made"
-		CustomPropertySearch(c, userData)
-	case models.AccountDeletionRequest:
+	event, registered := registry.Lookup(logRequestData.EventType)
+	if !registered {
+		log.Printf("Unsupported event type: %s for user %s", logRequestData.EventType, userData.Name)
+		response["action"] = "Unsupported event type"
+		response["warning"] = fmt.Sprintf("Event type %q is not supported", logRequestData.EventType)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	if event.Type.RequiresPropertyID() && logRequestData.PropertyID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Event type %s requires a property_id", logRequestData.EventType),
+		})
+		return
+	}
 
-	default:
-		log.Printf("Unknown event type: %s for user %s", logRequestData.EventType, userData.Name)
-		response["action"] = "Unknown event type"
-		if !logRequestData.EventType.IsValid() {
-			response["warning"] = "Event type not recognized"
-		}
+	log.Printf("User %s triggered %s (category: %s)", userData.Name, event.Type, event.Type.GetCategory())
+	response["action"] = event.Action
+	event.Handle(c, userData, logRequestData)
+	if status, messaged := userSendStatus(c); messaged {
+		response["whatsapp"] = status
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
 func RentalPropertyPost(c *gin.Context, user models.User) {
-	whatsappService, exists := middleware.GetWhatsApp(c)
+	messenger, exists := middleware.GetMessenger(c)
 	if !exists {
 		// Handle error: service not found
 		return
 	}
 
-	internalWAMessage := fmt.Sprintf(` *New Rental property post Received*
-	👤 *Name:* %s
-	📞 *Phone:* %s
-	`, user.Name, user.Phone)
-
-	customerName := "Sir/Madam"
-	if user.Name != "" {
-		customerName = user.Name
+	data := models.UserMessageData{User: user}
+	onWhatsApp := recordUserSend(c, sendUserMessage(c, messenger, user, services.TemplateRentalPost, data))
+	if internalWAMessage, ok := renderAlert(c, services.TemplateRentalPostAlert, data); ok {
+		if !onWhatsApp {
+			internalWAMessage += notOnWhatsAppNotice
+		}
+		sendGroupAlert(c, messenger, models.CategoryRental, internalWAMessage)
 	}
 
-	userFacingMessage := fmt.Sprintf(`Hello %s,
-
-Fantastic! We're thrilled to help you list your rental property on Easyplots and connect you with qualified tenants quickly.
-
-To create a standout listing that gets maximum visibility, please provide the following:
-
-📸 *Photos:* 2-3 clear images of your property
-📍 *Location:* A Google Maps link for accuracy
-📝 *Description:* A brief summary (e.g., 2BHK, ground floor, key amenities)
-💰 *Rent:* The expected monthly rent
-📞 *Contact:* Your preferred phone & WhatsApp number
-
-Once we have these details, we'll get your property live for thousands of potential renters to see.
-
-Best,
-The Easyplots Team`, customerName)
-
-	whatsappService.SendGroupMessage(c.Request.Context(), services.InternalGroupWhatsAppId, internalWAMessage)
-	whatsappService.SendMessage(c.Request.Context(), user.Phone, userFacingMessage)
+	// Walk the user through the listing details one at a time
+	if workflows, exists := middleware.GetWorkflows(c); onWhatsApp && exists && workflows.RentalIntake != nil {
+		if err := workflows.RentalIntake.Start(c.Request.Context(), user); err != nil {
+			log.Printf("Failed to start rental intake for user %s: %v", user.ID, err)
+		}
+	}
 }
 
 func CustomPropertySearch(c *gin.Context, user models.User) {
-	whatsappService, exists := middleware.GetWhatsApp(c)
+	messenger, exists := middleware.GetMessenger(c)
 	if !exists {
 		// Handle error: service not found
 		return
 	}
 
-	internalWAMessage := fmt.Sprintf(`🤷 *Custom Property Request*
-	👤 *Name:* %s
-	📞 *Phone:* %s
-	`, user.Name, user.Phone)
-
-	customerName := "Sir/Madam"
-	if user.Name != "" {
-		customerName = user.Name
+	data := models.UserMessageData{User: user}
+	onWhatsApp := recordUserSend(c, sendUserMessage(c, messenger, user, services.TemplateCustomSearch, data))
+	if internalWAMessage, ok := renderAlert(c, services.TemplateCustomSearchAlert, data); ok {
+		if !onWhatsApp {
+			internalWAMessage += notOnWhatsAppNotice
+		}
+		sendGroupAlert(c, messenger, models.CategorySearch, internalWAMessage)
 	}
-
-	userFacingMessage := fmt.Sprintf(`Hello %s,
-
-Searching for the perfect property? Let our experts do the heavy lifting for you!
-
-We offer a complimentary custom search service to match you with exclusive listings that meet your exact needs.
-
-Simply reply with your requirements (e.g., location, budget, property type, size), and we'll send you a curated list of the best options available.
-
-We look forward to finding your ideal property.
-
-Warm regards,
-The Easyplots Team`, customerName)
-
-	whatsappService.SendGroupMessage(c.Request.Context(), services.InternalGroupWhatsAppId, internalWAMessage)
-	whatsappService.SendMessage(c.Request.Context(), user.Phone, userFacingMessage)
 }
 
 func ConstructionServicesEnq(c *gin.Context, user models.User) {
-	whatsappService, exists := middleware.GetWhatsApp(c)
+	messenger, exists := middleware.GetMessenger(c)
 	if !exists {
 		// Handle error: service not found
 		return
 	}
 
-	internalWAMessage := fmt.Sprintf(`🏗️ *Construction Services*
-	👤 *Name:* %s
-	📞 *Phone:* %s
-	`, user.Name, user.Phone)
-
-	customerName := "Sir/Madam"
-	if user.Name != "" {
-		customerName = user.Name
+	data := models.UserMessageData{User: user}
+	onWhatsApp := recordUserSend(c, sendUserMessage(c, messenger, user, services.TemplateConstructionEnquiry, data))
+	if internalWAMessage, ok := renderAlert(c, services.TemplateConstructionEnquiryAlert, data); ok {
+		if !onWhatsApp {
+			internalWAMessage += notOnWhatsAppNotice
+		}
+		sendGroupAlert(c, messenger, models.CategoryConstruction, internalWAMessage)
 	}
+}
 
-	userFacingMessage := fmt.Sprintf(`Hello %s,
-
-Thank you for your interest in our construction services!
-
-Whether you're planning to build your dream home or a new commercial project, our expert team is here to bring your vision to life with quality craftsmanship and on-time delivery.
+// brochureFileName is the name the brochure gets in the user's chat
+const brochureFileName = "Easyplots Construction Brochure.pdf"
 
-To provide you with a tailored consultation, could you tell us a bit more about your project?
+// ConstructionBrochureDownload sends the construction brochure to a user who downloaded
+// it in the app, records them as a construction lead and lets the team know
+func ConstructionBrochureDownload(c *gin.Context, user models.User) {
+	messenger, exists := middleware.GetMessenger(c)
+	if !exists {
+		// Handle error: service not found
+		return
+	}
 
-One of our specialists is ready to connect and discuss how we can help.
+	leadID := "not recorded"
+	if leads, exists := middleware.GetConstructionLeadRepository(c); exists {
+		lead, err := leads.Record(c.Request.Context(), models.ConstructionLead{
+			UserID: user.ID,
+			Name:   user.Name,
+			Phone:  user.Phone,
+			Source: models.ConstructionBrochureDownloaded,
+		})
+		if err != nil {
+			log.Printf("Failed to record construction lead for user %s: %v", user.ID, err)
+		} else {
+			leadID = fmt.Sprintf("#%d", lead.ID)
+		}
+	}
 
-Best regards,
-The Easyplots Team`, customerName)
+	data := models.BrochureMessageData{User: user, LeadID: leadID}
+	brochureStatus := "Sent to the user"
+	cfg, _ := middleware.GetConfig(c)
+	if cfg == nil || cfg.BrochurePath == "" {
+		brochureStatus = "Not sent, BROCHURE_PATH is not configured"
+	} else if _, err := os.Stat(cfg.BrochurePath); err != nil {
+		log.Printf("Construction brochure unavailable: %v", err)
+		brochureStatus = "Not sent, brochure file is missing"
+	} else {
+		caption, err := renderUserMessage(c, user, services.TemplateConstructionBrochure, data)
+		if err == nil {
+			err = messenger.SendMedia(c.Request.Context(), user.Phone, models.MediaAttachment{
+				Kind:     models.MediaDocument,
+				Path:     cfg.BrochurePath,
+				FileName: brochureFileName,
+				MimeType: "application/pdf",
+			}, caption)
+		}
+		switch {
+		case !recordUserSend(c, err):
+			brochureStatus = "Not sent" + notOnWhatsAppNotice
+		case err != nil:
+			brochureStatus = "Failed to send"
+		}
+	}
 
-	whatsappService.SendGroupMessage(c.Request.Context(), services.InternalGroupWhatsAppId, internalWAMessage)
-	whatsappService.SendMessage(c.Request.Context(), user.Phone, userFacingMessage)
+	data.BrochureStatus = brochureStatus
+	if internalWAMessage, ok := renderAlert(c, services.TemplateConstructionBrochureAlert, data); ok {
+		sendGroupAlert(c, messenger, models.CategoryConstruction, internalWAMessage)
+	}
 }
 
 func PropertyInterest(c *gin.Context, user models.User, propertyId int) {
-	whatsappService, waExists := middleware.GetWhatsApp(c)
-	db, dbExists := middleware.GetDB(c)
-	if !waExists || !dbExists {
-		log.Println("Could not get whatsapp service or db from context")
+	messenger, waExists := middleware.GetMessenger(c)
+	properties, repoExists := middleware.GetPropertyRepository(c)
+	if !waExists || !repoExists {
+		log.Println("Could not get whatsapp messenger or property repository from context")
 		return
 	}
 
-	propertyData, err := utils.GetPropertyDataById(propertyId, db)
+	propertyData, err := properties.GetByID(c.Request.Context(), propertyId)
 	if err != nil {
 		log.Printf("Failed to get property data for id %d: %v", propertyId, err)
-		// Optionally, notify the group that an error occurred
-		errorMsg := fmt.Sprintf("⚠️ Error fetching property details for ID: %d\nUser: %s\nError: %v", propertyId, user.Name, err)
-		whatsappService.SendGroupMessage(c.Request.Context(), services.InternalGroupWhatsAppId, errorMsg)
+		// Let the team know so they can look the property up themselves
+		data := models.PropertyInterestMessageData{User: user, Property: models.Property{ID: int64(propertyId)}, Error: err.Error()}
+		if alert, ok := renderAlert(c, services.TemplatePropertyLookupFailedAlert, data); ok {
+			sendGroupAlert(c, messenger, models.CategoryPropertyInteraction, alert)
+		}
 		return
 	}
 
-	internalWAMessage := fmt.Sprintf(`✅ *Property Interest*
-👤 *Name:* %s
-📞 *Phone:* %s
+	location, area := propertyLocation(c.Request.Context(), properties, propertyData)
+	data := models.PropertyInterestMessageData{
+		User:     user,
+		Property: propertyData,
+		URL:      services.PropertyURL(propertyData.ID),
+		Pinned:   location != nil,
+		Area:     area,
+	}
+	internalWAMessage, ok := renderAlert(c, services.TemplatePropertyInterestAlert, data)
+	if !ok {
+		return
+	}
 
-*Property Details:*
-ID: %d
-Title: %s
-Size: %s
-Link: https://easyplots.in/property/%d
+	// The banner or first photo doubles as the thumbnail of the link card
+	photos := propertyPhotos(c.Request.Context(), properties, propertyData)
+	thumbnailURL := ""
+	if len(photos) > 0 {
+		thumbnailURL = photos[0].media.URL
+	}
+	for _, group := range alertGroups(c, models.CategoryPropertyInteraction) {
+		if err := messenger.SendGroupLinkPreview(c.Request.Context(), group, internalWAMessage, services.PropertyLinkPreview(propertyData, thumbnailURL)); err != nil {
+			log.Printf("Failed to send interest in property %d to %s: %v", propertyData.ID, group, err)
+		}
 
-Message is not sent to the user, please call them directly.`, user.Name, user.Phone, propertyData.ID, propertyData.Title, propertyData.Size, propertyData.ID)
+		if location != nil {
+			if err := messenger.SendGroupLocation(c.Request.Context(), group, *location); err != nil {
+				log.Printf("Failed to send location of property %d: %v", propertyData.ID, err)
+			}
+		}
 
-	whatsappService.SendGroupMessage(c.Request.Context(), services.InternalGroupWhatsAppId, internalWAMessage)
+		// Follow up with the listing banner and photos so the team sees the property at a glance
+		for _, photo := range photos {
+			if err := messenger.SendGroupMedia(c.Request.Context(), group, photo.media, photo.caption); err != nil {
+				log.Printf("Failed to send photo of property %d: %v", propertyData.ID, err)
+			}
+		}
+	}
 }
 
-func AccountDeletionRequest(c *gin.Context, user models.User) {
-	whatsappService, exists := middleware.GetWhatsApp(c)
-	if !exists {
-		// Handle error: service not found
-		return
+// propertyLocation returns the map pin of a listing whose owner reveals its location,
+// along with the area it is in. Hidden or unparseable locations only get the area.
+func propertyLocation(ctx context.Context, properties repository.PropertyRepository, property models.Property) (*models.Location, string) {
+	area := ""
+	address, err := properties.GetAddress(ctx, property.AddressID)
+	if err == nil {
+		area = address.Area()
+	} else if !errors.Is(err, repository.ErrAddressNotFound) {
+		log.Printf("Failed to get address of property %d: %v", property.ID, err)
 	}
 
-	internalWAMessage := fmt.Sprintf(`❌ *Account Deletion Request*
-	user Id: %s
-	👤 *Name:* %s
-	📞 *Phone:* %s
-	`, user.ID, user.Name, user.Phone)
-
-	customerName := "Sir/Madam"
-	if user.Name != "" {
-		customerName = user.Name
+	if !property.RevealLocation || property.MapCenterpoint == nil {
+		return nil, area
+	}
+	location, err := models.ParseMapCenterpoint(*property.MapCenterpoint)
+	if err != nil {
+		log.Printf("Failed to parse location of property %d: %v", property.ID, err)
+		return nil, area
 	}
+	location.Name = property.Title
+	location.Address = area
+	return &location, area
+}
 
-	userFacingMessage := fmt.Sprintf(`Hello %s,
+// maxPropertyPhotos caps how many listing photos are sent with a property alert
+const maxPropertyPhotos = 3
 
-We understand you've requested to delete your account from Easyplots.
+// propertyPhoto is an image of a listing with its caption
+type propertyPhoto struct {
+	media   models.MediaAttachment
+	caption string
+}
 
-We're sorry to see you go! Before we proceed with your account deletion, we'd like to understand if there's anything we could have done better to improve your experience with us.
+// propertyPhotos returns the banner and the first photos of a listing. Lookup
+// failures are logged and skipped, the text alert has already gone out.
+func propertyPhotos(ctx context.Context, properties repository.PropertyRepository, property models.Property) []propertyPhoto {
+	var photos []propertyPhoto
 
-Your feedback helps us serve our community better.
+	if property.BannerID != nil {
+		url, err := properties.GetBannerURL(ctx, *property.BannerID)
+		if err != nil {
+			log.Printf("Failed to get banner %d of property %d: %v", *property.BannerID, property.ID, err)
+		} else {
+			photos = append(photos, propertyPhoto{
+				media:   models.MediaAttachment{Kind: models.MediaImage, URL: url},
+				caption: fmt.Sprintf("🖼️ *%s* (#%d)", property.Title, property.ID),
+			})
+		}
+	}
 
-We'll process your deletion request within 24-48 hours. If you change your mind, please contact us before then.
+	urls, err := properties.ListImageURLs(ctx, property.ID, maxPropertyPhotos)
+	if err != nil {
+		log.Printf("Failed to get photos of property %d: %v", property.ID, err)
+	}
+	for i, url := range urls {
+		photos = append(photos, propertyPhoto{
+			media:   models.MediaAttachment{Kind: models.MediaImage, URL: url},
+			caption: fmt.Sprintf("📸 Property #%d (%d/%d)", property.ID, i+1, len(urls)),
+		})
+	}
+	return photos
+}
 
-Thank you for being part of the Easyplots community.
+func AccountDeletionRequest(c *gin.Context, user models.User) {
+	messenger, exists := middleware.GetMessenger(c)
+	if !exists {
+		// Handle error: service not found
+		return
+	}
 
-Best regards,
-The Easyplots Team`, customerName)
+	// Schedule the deletion so it happens after the promised window unless the user cancels
+	var scheduled *models.AccountDeletion
+	if workflows, exists := middleware.GetWorkflows(c); exists && workflows.AccountDeletion != nil {
+		deletion, err := workflows.AccountDeletion.Start(c.Request.Context(), user)
+		if err != nil {
+			log.Printf("Failed to schedule account deletion for user %s: %v", user.ID, err)
+		} else {
+			scheduled = &deletion
+		}
+	}
 
-	whatsappService.SendGroupMessage(c.Request.Context(), services.InternalGroupWhatsAppId, internalWAMessage)
-	whatsappService.SendMessage(c.Request.Context(), user.Phone, userFacingMessage)
+	data := models.AccountDeletionMessageData{User: user, Deletion: scheduled}
+	onWhatsApp := recordUserSend(c, sendUserMessage(c, messenger, user, services.TemplateAccountDeletion, data))
+	if internalWAMessage, ok := renderAlert(c, services.TemplateAccountDeletionAlert, data); ok {
+		if !onWhatsApp {
+			internalWAMessage += notOnWhatsAppNotice
+		}
+		sendGroupAlert(c, messenger, models.CategoryAccount, internalWAMessage)
+	}
 }
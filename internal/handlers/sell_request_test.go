@@ -0,0 +1,648 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/middleware"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+var testUser = models.User{ID: "user-1", Name: "Asha", Phone: "919000000001"}
+
+// Alerts go to testGroupJID unless routed elsewhere, rentals go to the rental desk
+const (
+	testGroupJID       = "120363000000000001@g.us"
+	testRentalGroupJID = "120363000000000002@g.us"
+)
+
+// newTestGroupRouter returns the notification routes used by handler tests
+func newTestGroupRouter() services.GroupRouter {
+	return services.NewStaticNotificationRouter(models.NotificationRoutes{
+		Routes: map[string][]string{models.CategoryRental: {testRentalGroupJID}},
+	}, testGroupJID)
+}
+
+// testMessageTemplates renders the templates shipped with the service
+var testMessageTemplates = func() services.MessageRenderer {
+	templates, err := services.NewMessageTemplates(&config.Config{TemplatesDir: "../../templates"})
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}()
+
+// newTestRepositories returns in-memory repositories seeded with testUser and one property
+func newTestRepositories() repository.Repositories {
+	return repository.Repositories{
+		Users:      repository.NewMemoryUserRepository(testUser),
+		Properties: repository.NewMemoryPropertyRepository(models.Property{ID: 42, Title: "Corner plot", Size: "30x40"}),
+		Messages:   repository.NewMemoryMessageRepository(),
+		Leads:      repository.NewMemoryConstructionLeadRepository(),
+	}
+}
+
+// newTestRouter builds a router with the same middleware chain as production,
+// backed by the given fake messenger and in-memory repositories
+func newTestRouter(messenger services.Messenger) *gin.Engine {
+	return newTestRouterWith(messenger, newTestRepositories())
+}
+
+// newTestRouterWith builds a test router around specific repositories
+func newTestRouterWith(messenger services.Messenger, repos repository.Repositories) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.RepositoryMiddleware(repos))
+	router.Use(middleware.MessengerMiddleware(messenger))
+	router.Use(middleware.GroupRouterMiddleware(newTestGroupRouter()))
+	router.Use(middleware.MessageTemplatesMiddleware(testMessageTemplates))
+	return router
+}
+
+// postJSON sends a JSON body through the router and returns the recorded response
+func postJSON(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// runUserAction invokes a user facing handler like RentalPropertyPost inside a request
+func runUserAction(t *testing.T, messenger services.Messenger, action func(*gin.Context, models.User), user models.User) {
+	t.Helper()
+	router := newTestRouter(messenger)
+	router.POST("/action", func(c *gin.Context) {
+		action(c, user)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/action", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+}
+
+func TestUserActionsNotifyGroupAndUser(t *testing.T) {
+	user := testUser
+
+	tests := []struct {
+		name      string
+		action    func(*gin.Context, models.User)
+		group     string
+		groupText string
+		userText  string
+	}{
+		{"rental", RentalPropertyPost, testRentalGroupJID, "New Rental property post Received", "list your rental property"},
+		{"custom search", CustomPropertySearch, testGroupJID, "Custom Property Request", "custom search service"},
+		{"construction", ConstructionServicesEnq, testGroupJID, "Construction Services", "construction services"},
+		{"account deletion", AccountDeletionRequest, testGroupJID, "Account Deletion Request", "delete your account"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messenger := services.NewFakeMessenger()
+			runUserAction(t, messenger, tt.action, user)
+
+			groups := messenger.GroupMessages()
+			if len(groups) != 1 {
+				t.Fatalf("expected 1 group message, got %d", len(groups))
+			}
+			if groups[0].Recipient != tt.group {
+				t.Errorf("group message sent to %q, want %q", groups[0].Recipient, tt.group)
+			}
+			if !strings.Contains(groups[0].Text, tt.groupText) || !strings.Contains(groups[0].Text, user.Phone) {
+				t.Errorf("unexpected group message: %q", groups[0].Text)
+			}
+			if strings.Contains(groups[0].Text, "\t") {
+				t.Errorf("group message is indented: %q", groups[0].Text)
+			}
+
+			direct := messenger.DirectMessages()
+			if len(direct) != 1 {
+				t.Fatalf("expected 1 direct message, got %d", len(direct))
+			}
+			if direct[0].Recipient != user.Phone {
+				t.Errorf("direct message sent to %q", direct[0].Recipient)
+			}
+			if !strings.Contains(direct[0].Text, "Hello Asha,") || !strings.Contains(direct[0].Text, tt.userText) {
+				t.Errorf("unexpected user message: %q", direct[0].Text)
+			}
+		})
+	}
+}
+
+// fakeRentalIntake records the users a rental intake was started for
+type fakeRentalIntake struct {
+	started []models.User
+}
+
+func (f *fakeRentalIntake) Start(ctx context.Context, user models.User) error {
+	f.started = append(f.started, user)
+	return nil
+}
+
+func TestRentalPropertyPostStartsIntake(t *testing.T) {
+	intake := &fakeRentalIntake{}
+	router := newTestRouter(services.NewFakeMessenger())
+	router.Use(middleware.WorkflowMiddleware(services.Workflows{RentalIntake: intake}))
+	router.POST("/action", func(c *gin.Context) {
+		RentalPropertyPost(c, testUser)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/action", nil))
+
+	if len(intake.started) != 1 || intake.started[0].ID != testUser.ID {
+		t.Errorf("expected intake for %s, got %+v", testUser.ID, intake.started)
+	}
+}
+
+// fakeAccountDeletion schedules deletions without storing them
+type fakeAccountDeletion struct {
+	started []models.User
+}
+
+func (f *fakeAccountDeletion) Start(ctx context.Context, user models.User) (models.AccountDeletion, error) {
+	f.started = append(f.started, user)
+	return models.AccountDeletion{ID: 5, UserID: user.ID, ScheduledFor: time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)}, nil
+}
+
+func TestAccountDeletionRequestSchedulesDeletion(t *testing.T) {
+	deletion := &fakeAccountDeletion{}
+	messenger := services.NewFakeMessenger()
+	router := newTestRouter(messenger)
+	router.Use(middleware.WorkflowMiddleware(services.Workflows{AccountDeletion: deletion}))
+	router.POST("/action", func(c *gin.Context) {
+		AccountDeletionRequest(c, testUser)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/action", nil))
+
+	if len(deletion.started) != 1 || deletion.started[0].ID != testUser.ID {
+		t.Fatalf("expected deletion for %s, got %+v", testUser.ID, deletion.started)
+	}
+	if groups := messenger.GroupMessages(); len(groups) != 1 || !strings.Contains(groups[0].Text, "02 Jan 2026, 03:00 PM (#5)") {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+	if direct := messenger.DirectMessages(); len(direct) != 1 || !strings.Contains(direct[0].Text, "reply *CANCEL*") {
+		t.Errorf("unexpected direct messages: %+v", direct)
+	}
+}
+
+func TestConstructionBrochureDownload(t *testing.T) {
+	brochure := filepath.Join(t.TempDir(), "brochure.pdf")
+	if err := os.WriteFile(brochure, []byte("%PDF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantDirect int
+		wantStatus string
+	}{
+		{"brochure sent", brochure, 1, "Sent to the user"},
+		{"not configured", "", 0, "not configured"},
+		{"missing file", brochure + ".missing", 0, "file is missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messenger := services.NewFakeMessenger()
+			repos := newTestRepositories()
+			router := newTestRouterWith(messenger, repos)
+			router.Use(middleware.ConfigMiddleware(&config.Config{BrochurePath: tt.path}))
+			router.POST("/action", func(c *gin.Context) {
+				ConstructionBrochureDownload(c, testUser)
+			})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/action", nil))
+
+			direct := messenger.DirectMessages()
+			if len(direct) != tt.wantDirect {
+				t.Fatalf("expected %d direct messages, got %+v", tt.wantDirect, direct)
+			}
+			if tt.wantDirect == 1 && (direct[0].Media == nil || direct[0].Media.Kind != models.MediaDocument || direct[0].Media.Path != brochure) {
+				t.Errorf("expected the brochure as a document, got %+v", direct[0])
+			}
+			groups := messenger.GroupMessages()
+			if len(groups) != 1 || !strings.Contains(groups[0].Text, "*Lead:* #1") || !strings.Contains(groups[0].Text, tt.wantStatus) {
+				t.Errorf("unexpected group messages: %+v", groups)
+			}
+
+			leads := repos.Leads.(*repository.MemoryConstructionLeadRepository).Leads()
+			if len(leads) != 1 || leads[0].Source != models.ConstructionBrochureDownloaded {
+				t.Errorf("expected a brochure lead, got %+v", leads)
+			}
+		})
+	}
+}
+
+func TestUserActionsFallBackToGenericGreeting(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	runUserAction(t, messenger, RentalPropertyPost, models.User{Phone: "919000000002"})
+
+	direct := messenger.DirectMessages()
+	if len(direct) != 1 || !strings.HasPrefix(direct[0].Text, "Hello Sir/Madam,") {
+		t.Fatalf("expected generic greeting, got %+v", direct)
+	}
+}
+
+func TestUserActionsUsePreferredLanguage(t *testing.T) {
+	kannada := "kn"
+	user := testUser
+	user.PrefLang = &kannada
+
+	messenger := services.NewFakeMessenger()
+	runUserAction(t, messenger, CustomPropertySearch, user)
+
+	direct := messenger.DirectMessages()
+	if len(direct) != 1 || !strings.HasPrefix(direct[0].Text, "ನಮಸ್ಕಾರ Asha,") {
+		t.Fatalf("expected a Kannada message, got %+v", direct)
+	}
+	// The team reads alerts in its own language
+	groups := messenger.GroupMessages()
+	if len(groups) != 1 || !strings.Contains(groups[0].Text, "Custom Property Request") {
+		t.Errorf("expected an English alert, got %+v", groups)
+	}
+}
+
+func TestNewSellRequestHandlerRejectsBadPayloads(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{"record":`},
+		{"missing user id", `{"type":"INSERT","table":"sell_request","record":{"id":1}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messenger := services.NewFakeMessenger()
+			router := newTestRouter(messenger)
+			router.POST("/sell-request", NewSellRequestHandler())
+
+			w := postJSON(router, "/sell-request", tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+			if len(messenger.Messages()) != 0 {
+				t.Errorf("expected no messages, got %+v", messenger.Messages())
+			}
+		})
+	}
+}
+
+func TestNewSellRequestHandlerRequiresMessenger(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.RepositoryMiddleware(newTestRepositories()))
+	router.POST("/sell-request", NewSellRequestHandler())
+
+	w := postJSON(router, "/sell-request", `{}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestNewSellRequestHandlerNotifiesGroupAndSeller(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	router := newTestRouter(messenger)
+	router.POST("/sell-request", NewSellRequestHandler())
+
+	w := postJSON(router, "/sell-request", `{"type":"INSERT","table":"sell_request","record":{"id":1,"user_id":"user-1","property_type":"Plot","address":"Hubli","price":"10L"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	groups := messenger.GroupMessages()
+	if len(groups) != 1 || !strings.Contains(groups[0].Text, "New Sell Request Received") || !strings.Contains(groups[0].Text, "Hubli") {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+	direct := messenger.DirectMessages()
+	if len(direct) != 1 || direct[0].Recipient != testUser.Phone || !strings.Contains(direct[0].Text, "Hello Asha,") {
+		t.Errorf("unexpected direct messages: %+v", direct)
+	}
+}
+
+// fakeSellChecklist records the sell requests a checklist was started for
+type fakeSellChecklist struct {
+	started []models.SellRequest
+}
+
+func (f *fakeSellChecklist) Start(ctx context.Context, sellRequest models.SellRequest, user models.User) error {
+	f.started = append(f.started, sellRequest)
+	return nil
+}
+
+func TestNewSellRequestHandlerSellerNotOnWhatsApp(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	messenger.SetNotOnWhatsApp(testUser.Phone)
+	checklist := &fakeSellChecklist{}
+	router := newTestRouter(messenger)
+	router.Use(middleware.WorkflowMiddleware(services.Workflows{SellChecklist: checklist}))
+	router.POST("/sell-request", NewSellRequestHandler())
+
+	w := postJSON(router, "/sell-request", `{"type":"INSERT","table":"sell_request","record":{"id":1,"user_id":"user-1","property_type":"Plot"}}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"whatsapp":"not on WhatsApp"`) {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+
+	groups := messenger.GroupMessages()
+	if len(groups) != 1 || !strings.Contains(groups[0].Text, "New Sell Request Received") || !strings.Contains(groups[0].Text, "please call the user instead") {
+		t.Errorf("expected the alert to ask for a call, got %+v", groups)
+	}
+	if len(checklist.started) != 0 {
+		t.Error("no checklist should be tracked for a seller we can't message")
+	}
+}
+
+func TestUserLogsUserNotOnWhatsApp(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	messenger.SetNotOnWhatsApp(testUser.Phone)
+	intake := &fakeRentalIntake{}
+	router := newTestRouter(messenger)
+	router.Use(middleware.WorkflowMiddleware(services.Workflows{RentalIntake: intake}))
+	router.POST("/user-logs", NewUserLogsHandler(NewDefaultUserEventRegistry()))
+
+	w := postJSON(router, "/user-logs", `{"type":"INSERT","table":"user_logs","record":{"id":1,"user_id":"user-1","event_type":"POST_RENTAL_PROPERTY_PRESSED"}}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"whatsapp":"not on WhatsApp"`) {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+
+	groups := messenger.GroupMessages()
+	if len(groups) != 1 || !strings.Contains(groups[0].Text, "Not on WhatsApp") {
+		t.Errorf("expected the alert to flag the user, got %+v", groups)
+	}
+	if len(intake.started) != 0 {
+		t.Error("no intake should start for a user we can't message")
+	}
+}
+
+func TestNewSellRequestHandlerStartsChecklist(t *testing.T) {
+	checklist := &fakeSellChecklist{}
+	router := newTestRouter(services.NewFakeMessenger())
+	router.Use(middleware.WorkflowMiddleware(services.Workflows{SellChecklist: checklist}))
+	router.POST("/sell-request", NewSellRequestHandler())
+
+	w := postJSON(router, "/sell-request", `{"type":"INSERT","table":"sell_request","record":{"id":7,"user_id":"user-1"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(checklist.started) != 1 || checklist.started[0].Id != 7 {
+		t.Errorf("expected checklist for sell request 7, got %+v", checklist.started)
+	}
+}
+
+func TestNewSellRequestHandlerUpdates(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     string
+		oldRecord  string
+		wantDirect []string
+		wantGroup  int
+	}{
+		{
+			name:       "attended flips to true",
+			record:     `{"id":7,"user_id":"user-1","attended":true}`,
+			oldRecord:  `{"id":7,"user_id":"user-1","attended":false}`,
+			wantDirect: []string{"reviewed your property"},
+		},
+		{
+			name:       "assigned to a team member",
+			record:     `{"id":7,"user_id":"user-1","assign_to":"Ravi"}`,
+			oldRecord:  `{"id":7,"user_id":"user-1","assign_to":""}`,
+			wantDirect: []string{"*Ravi* from our team"},
+			wantGroup:  1,
+		},
+		{
+			name:      "notes only",
+			record:    `{"id":7,"user_id":"user-1","notes":"called twice"}`,
+			oldRecord: `{"id":7,"user_id":"user-1"}`,
+		},
+		{
+			name:      "attended flips back",
+			record:    `{"id":7,"user_id":"user-1","attended":false}`,
+			oldRecord: `{"id":7,"user_id":"user-1","attended":true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messenger := services.NewFakeMessenger()
+			router := newTestRouter(messenger)
+			router.POST("/sell-request", NewSellRequestHandler())
+
+			w := postJSON(router, "/sell-request", `{"type":"UPDATE","table":"sell_request","record":`+tt.record+`,"old_record":`+tt.oldRecord+`}`)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			direct := messenger.DirectMessages()
+			if len(direct) != len(tt.wantDirect) {
+				t.Fatalf("expected %d direct messages, got %+v", len(tt.wantDirect), direct)
+			}
+			for i, want := range tt.wantDirect {
+				if !strings.Contains(direct[i].Text, want) {
+					t.Errorf("message %d = %q, want it to contain %q", i, direct[i].Text, want)
+				}
+			}
+			if groups := messenger.GroupMessages(); len(groups) != tt.wantGroup {
+				t.Errorf("expected %d group messages, got %+v", tt.wantGroup, groups)
+			}
+		})
+	}
+}
+
+func TestNewSellRequestHandlerUpdateRequiresOldRecord(t *testing.T) {
+	router := newTestRouter(services.NewFakeMessenger())
+	router.POST("/sell-request", NewSellRequestHandler())
+
+	w := postJSON(router, "/sell-request", `{"type":"UPDATE","table":"sell_request","record":{"id":7,"user_id":"user-1"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestNewSellRequestHandlerDelete(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	router := newTestRouter(messenger)
+	router.POST("/sell-request", NewSellRequestHandler())
+
+	w := postJSON(router, "/sell-request", `{"type":"DELETE","table":"sell_request","record":null,"old_record":{"id":7,"user_id":"gone","address":"Hubli"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	groups := messenger.GroupMessages()
+	if len(groups) != 1 || !strings.Contains(groups[0].Text, "Sell Request #7 Deleted") {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+	if len(messenger.DirectMessages()) != 0 {
+		t.Error("the seller must not be messaged about a deletion")
+	}
+}
+
+func TestHandleUserLogsIgnoresUpdates(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	router := newTestRouter(messenger)
+	router.POST("/user-logs", NewUserLogsHandler(NewDefaultUserEventRegistry()))
+
+	w := postJSON(router, "/user-logs", `{"type":"UPDATE","table":"user_logs","record":{"id":1,"user_id":"user-1","event_type":"CONSTRUCTION_CALL_PRESSED","notes":"called"},"old_record":{"id":1,"user_id":"user-1","event_type":"CONSTRUCTION_CALL_PRESSED"}}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"field":"notes"`) {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if len(messenger.Messages()) != 0 {
+		t.Errorf("an update must not replay the user action, got %+v", messenger.Messages())
+	}
+}
+
+func TestNewSellRequestHandlerUnknownUser(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	router := newTestRouter(messenger)
+	router.POST("/sell-request", NewSellRequestHandler())
+
+	w := postJSON(router, "/sell-request", `{"type":"INSERT","table":"sell_request","record":{"id":1,"user_id":"ghost"}}`)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(messenger.Messages()) != 0 {
+		t.Errorf("expected no messages, got %+v", messenger.Messages())
+	}
+}
+
+func TestHandleUserLogsUnknownUser(t *testing.T) {
+	router := newTestRouter(services.NewFakeMessenger())
+	router.POST("/user-logs", NewUserLogsHandler(NewDefaultUserEventRegistry()))
+
+	w := postJSON(router, "/user-logs", `{"type":"INSERT","table":"user_logs","record":{"id":1,"user_id":"ghost","event_type":"POST_RENTAL_PROPERTY_PRESSED"}}`)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPropertyInterestSendsBannerAndPhotos(t *testing.T) {
+	bannerID := int64(7)
+	properties := repository.NewMemoryPropertyRepository(models.Property{ID: 42, Title: "Corner plot", BannerID: &bannerID})
+	properties.SetBanner(bannerID, "https://cdn.example.com/banner.jpg")
+	properties.SetImages(42, "https://cdn.example.com/1.jpg", "https://cdn.example.com/2.jpg", "https://cdn.example.com/3.jpg", "https://cdn.example.com/4.jpg")
+	repos := newTestRepositories()
+	repos.Properties = properties
+
+	messenger := services.NewFakeMessenger()
+	router := newTestRouterWith(messenger, repos)
+	router.POST("/action", func(c *gin.Context) {
+		PropertyInterest(c, testUser, 42)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/action", nil))
+
+	groups := messenger.GroupMessages()
+	// The text alert, the banner and the first three photos
+	if len(groups) != 5 {
+		t.Fatalf("expected 5 group messages, got %+v", groups)
+	}
+	if groups[0].Media != nil {
+		t.Error("the alert must go out as text first")
+	}
+	if preview := groups[0].Preview; preview == nil || preview.Image == nil || preview.Image.URL != "https://cdn.example.com/banner.jpg" {
+		t.Errorf("expected the banner as card thumbnail, got %+v", preview)
+	}
+	if banner := groups[1].Media; banner == nil || banner.Kind != models.MediaImage || banner.URL != "https://cdn.example.com/banner.jpg" {
+		t.Errorf("unexpected banner %+v", groups[1])
+	}
+	if photo := groups[4]; photo.Media == nil || photo.Media.URL != "https://cdn.example.com/3.jpg" || !strings.Contains(photo.Text, "(3/3)") {
+		t.Errorf("unexpected last photo %+v", photo)
+	}
+}
+
+func TestPropertyInterestLocation(t *testing.T) {
+	centerpoint, locality, city := "15.3647,75.1240", "Vidyanagar", "Hubballi"
+	tests := []struct {
+		name     string
+		reveal   bool
+		wantPin  bool
+		wantText string
+	}{
+		{"revealed", true, true, "Location: pinned below"},
+		{"hidden", false, false, "Approx. location: Vidyanagar, Hubballi (exact location hidden)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			properties := repository.NewMemoryPropertyRepository(models.Property{
+				ID: 42, Title: "Corner plot", AddressID: 3, MapCenterpoint: &centerpoint, RevealLocation: tt.reveal,
+			})
+			properties.AddAddress(models.Address{ID: 3, Locality: &locality, City: &city})
+			repos := newTestRepositories()
+			repos.Properties = properties
+
+			messenger := services.NewFakeMessenger()
+			router := newTestRouterWith(messenger, repos)
+			router.POST("/action", func(c *gin.Context) {
+				PropertyInterest(c, testUser, 42)
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/action", nil))
+
+			groups := messenger.GroupMessages()
+			if len(groups) == 0 || !strings.Contains(groups[0].Text, tt.wantText) {
+				t.Fatalf("expected %q in the alert, got %+v", tt.wantText, groups)
+			}
+
+			var pin *models.Location
+			for _, msg := range groups {
+				if msg.Location != nil {
+					pin = msg.Location
+				}
+			}
+			if !tt.wantPin {
+				if pin != nil {
+					t.Errorf("hidden location must not be pinned, got %+v", pin)
+				}
+				return
+			}
+			if pin == nil || pin.Latitude != 15.3647 || pin.Longitude != 75.1240 || pin.Name != "Corner plot" || pin.Address != "Vidyanagar, Hubballi" {
+				t.Errorf("unexpected pin %+v", pin)
+			}
+		})
+	}
+}
+
+func TestPropertyInterestMissingPropertyAlertsGroup(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	runUserAction(t, messenger, func(c *gin.Context, user models.User) {
+		PropertyInterest(c, user, 404)
+	}, testUser)
+
+	groups := messenger.GroupMessages()
+	if len(groups) != 1 || !strings.Contains(groups[0].Text, "Error fetching property details for ID: 404") {
+		t.Fatalf("unexpected group messages: %+v", groups)
+	}
+	if len(messenger.DirectMessages()) != 0 {
+		t.Error("user must not be messaged when the property is missing")
+	}
+}
+
+func TestPropertyInterestAlertsGroup(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	runUserAction(t, messenger, func(c *gin.Context, user models.User) {
+		PropertyInterest(c, user, 42)
+	}, testUser)
+
+	groups := messenger.GroupMessages()
+	if len(groups) != 1 || !strings.Contains(groups[0].Text, "Corner plot") || !strings.Contains(groups[0].Text, "https://easyplots.in/property/42") {
+		t.Fatalf("unexpected group messages: %+v", groups)
+	}
+	if preview := groups[0].Preview; preview == nil || preview.URL != "https://easyplots.in/property/42" || preview.Title != "Corner plot" || preview.Description != "30x40" {
+		t.Errorf("expected a property card, got %+v", preview)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// UserEventHandler reacts to a user action logged by the app
+type UserEventHandler func(c *gin.Context, user models.User, record models.LogsRequest)
+
+// UserEvent declares how a logged event type is handled. What the event needs, e.g.
+// a property_id, and its category come from the event type.
+type UserEvent struct {
+	Type models.EventType
+	// Action describes the outcome in the webhook response
+	Action string
+	Handle UserEventHandler
+}
+
+// UserEventRegistry maps logged event types to their handlers
+type UserEventRegistry struct {
+	mu     sync.RWMutex
+	events map[models.EventType]UserEvent
+	order  []models.EventType
+}
+
+// NewUserEventRegistry creates a registry without any events
+func NewUserEventRegistry() *UserEventRegistry {
+	return &UserEventRegistry{events: make(map[models.EventType]UserEvent)}
+}
+
+// NewDefaultUserEventRegistry creates a registry with every built-in event handler
+func NewDefaultUserEventRegistry() *UserEventRegistry {
+	registry := NewUserEventRegistry()
+	registry.Register(UserEvent{
+		Type:   models.CallPressed,
+		Action: "Property contact initiated",
+		Handle: handlePropertyInterest,
+	})
+	registry.Register(UserEvent{
+		Type:   models.WhatsAppPressed,
+		Action: "Property contact initiated",
+		Handle: handlePropertyInterest,
+	})
+	registry.Register(UserEvent{
+		Type:   models.ConstructionCallPressed,
+		Action: "Construction interaction",
+		Handle: withUser(ConstructionServicesEnq),
+	})
+	registry.Register(UserEvent{
+		Type:   models.ConstructionWhatsAppPressed,
+		Action: "Construction interaction",
+		Handle: withUser(ConstructionServicesEnq),
+	})
+	registry.Register(UserEvent{
+		Type:   models.ConstructionBrochureDownloaded,
+		Action: "Construction brochure sent",
+		Handle: withUser(ConstructionBrochureDownload),
+	})
+	registry.Register(UserEvent{
+		Type:   models.PostRentalPropertyPressed,
+		Action: "Post rental property button pressed",
+		Handle: withUser(RentalPropertyPost),
+	})
+	registry.Register(UserEvent{
+		Type:   models.CustomPropertySearchRequest,
+		Action: "Custom property search request made",
+		Handle: withUser(CustomPropertySearch),
+	})
+	registry.Register(UserEvent{
+		Type:   models.AccountDeletionRequest,
+		Action: "Account deletion scheduled",
+		Handle: withUser(AccountDeletionRequest),
+	})
+	return registry
+}
+
+// Register adds the handler of an event type. Registering a type twice is a
+// programming error and panics.
+func (r *UserEventRegistry) Register(event UserEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.events[event.Type]; exists {
+		panic(fmt.Sprintf("user event %s is already registered", event.Type))
+	}
+	r.events[event.Type] = event
+	r.order = append(r.order, event.Type)
+}
+
+// Lookup returns the registration of an event type
+func (r *UserEventRegistry) Lookup(eventType models.EventType) (UserEvent, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	event, ok := r.events[eventType]
+	return event, ok
+}
+
+// Events returns every registered event in registration order
+func (r *UserEventRegistry) Events() []UserEvent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := make([]UserEvent, len(r.order))
+	for i, eventType := range r.order {
+		events[i] = r.events[eventType]
+	}
+	return events
+}
+
+// NewUserEventsHandler returns a handler listing the supported user events and their categories
+func NewUserEventsHandler(registry *UserEventRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		events := registry.Events()
+		response := make([]gin.H, len(events))
+		for i, event := range events {
+			response[i] = gin.H{
+				"event_type":           event.Type,
+				"category":             event.Type.GetCategory(),
+				"action":               event.Action,
+				"requires_property_id": event.Type.RequiresPropertyID(),
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"events": response})
+	}
+}
+
+// withUser adapts a handler that only needs the user
+func withUser(handler func(c *gin.Context, user models.User)) UserEventHandler {
+	return func(c *gin.Context, user models.User, record models.LogsRequest) {
+		handler(c, user)
+	}
+}
+
+// handlePropertyInterest alerts the team about the property from the log entry
+func handlePropertyInterest(c *gin.Context, user models.User, record models.LogsRequest) {
+	PropertyInterest(c, user, *record.PropertyID)
+}
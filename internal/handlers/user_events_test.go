@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDefaultUserEventsHaveHandlers(t *testing.T) {
+	for _, event := range NewDefaultUserEventRegistry().Events() {
+		if event.Handle == nil {
+			t.Errorf("%s has no handler", event.Type)
+		}
+	}
+}
+
+func TestUserEventRegistryRejectsDuplicates(t *testing.T) {
+	registry := NewUserEventRegistry()
+	registry.Register(UserEvent{Type: models.CallPressed})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering an event twice must panic")
+		}
+	}()
+	registry.Register(UserEvent{Type: models.CallPressed})
+}
+
+func TestUserLogsDispatchesRegisteredEvent(t *testing.T) {
+	var handled []models.LogsRequest
+	registry := NewUserEventRegistry()
+	registry.Register(UserEvent{
+		Type:   models.CustomPropertySearchRequest,
+		Action: "Searched",
+		Handle: func(c *gin.Context, user models.User, record models.LogsRequest) {
+			handled = append(handled, record)
+		},
+	})
+
+	router := newTestRouter(services.NewFakeMessenger())
+	router.POST("/user-logs", NewUserLogsHandler(registry))
+
+	w := postJSON(router, "/user-logs", `{"type":"INSERT","record":{"id":3,"user_id":"user-1","event_type":"CUSTOM_PROPERTY_SEARCH_REQUEST"}}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"action":"Searched"`) {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if len(handled) != 1 || handled[0].Id != 3 {
+		t.Errorf("expected the handler to get log 3, got %+v", handled)
+	}
+}
+
+func TestUserLogsReportsUnsupportedEvents(t *testing.T) {
+	for _, eventType := range []string{"SOMETHING_NEW", "CONSTRUCTION_BROCHURE_DOWNLOADED"} {
+		messenger := services.NewFakeMessenger()
+		router := newTestRouter(messenger)
+		router.POST("/user-logs", NewUserLogsHandler(NewUserEventRegistry()))
+
+		w := postJSON(router, "/user-logs", `{"type":"INSERT","record":{"id":1,"user_id":"user-1","event_type":"`+eventType+`"}}`)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "is not supported") {
+			t.Errorf("%s: unexpected response %d: %s", eventType, w.Code, w.Body.String())
+		}
+		if len(messenger.Messages()) != 0 {
+			t.Errorf("%s: unsupported events must not send messages", eventType)
+		}
+	}
+}
+
+func TestUserLogsRequiresPropertyID(t *testing.T) {
+	messenger := services.NewFakeMessenger()
+	router := newTestRouter(messenger)
+	router.POST("/user-logs", NewUserLogsHandler(NewDefaultUserEventRegistry()))
+
+	w := postJSON(router, "/user-logs", `{"type":"INSERT","record":{"id":1,"user_id":"user-1","event_type":"CALL_PRESSED_PROPERTY"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(messenger.Messages()) != 0 {
+		t.Error("the handler must not run without a property ID")
+	}
+
+	w = postJSON(router, "/user-logs", `{"type":"INSERT","record":{"id":2,"user_id":"user-1","event_type":"CALL_PRESSED_PROPERTY","property_id":42}}`)
+	if w.Code != http.StatusOK || len(messenger.GroupMessages()) != 1 {
+		t.Errorf("unexpected response %d: %s, messages %+v", w.Code, w.Body.String(), messenger.Messages())
+	}
+}
+
+func TestUserEventsListsCategories(t *testing.T) {
+	registry := NewDefaultUserEventRegistry()
+	router := gin.New()
+	router.GET("/user-logs/events", NewUserEventsHandler(registry))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user-logs/events", nil))
+
+	var response struct {
+		Events []struct {
+			EventType          models.EventType `json:"event_type"`
+			Category           string           `json:"category"`
+			RequiresPropertyID bool             `json:"requires_property_id"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Events) != len(registry.Events()) {
+		t.Fatalf("expected every registered event, got %s", w.Body.String())
+	}
+	first := response.Events[0]
+	if first.EventType != models.CallPressed || first.Category != "property_interaction" || !first.RequiresPropertyID {
+		t.Errorf("unexpected first event: %+v", first)
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/middleware"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// whatsappStatusKey carries the outcome of messaging the user to the webhook response
+const whatsappStatusKey = "whatsapp_status"
+
+// Outcomes of messaging the user, as reported in webhook responses
+const (
+	whatsappQueued        = "WhatsApp message queued for delivery"
+	whatsappNotOnWhatsApp = "not on WhatsApp"
+)
+
+// notOnWhatsAppNotice is appended to team alerts about users we couldn't message
+const notOnWhatsAppNotice = "\n⚠️ *Not on WhatsApp:* this number has no WhatsApp account, please call the user instead."
+
+// recordUserSend keeps the outcome of messaging the user for the webhook response.
+// It returns false when the number is not on WhatsApp, so the team can be asked to call.
+func recordUserSend(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrNotOnWhatsApp):
+		log.Printf("WhatsApp: Message not sent, %v", err)
+		c.Set(whatsappStatusKey, whatsappNotOnWhatsApp)
+		return false
+	case err != nil:
+		log.Printf("WhatsApp Error: Failed to queue message: %v", err)
+		c.Set(whatsappStatusKey, "Failed to queue WhatsApp message: "+err.Error())
+	default:
+		c.Set(whatsappStatusKey, whatsappQueued)
+	}
+	return true
+}
+
+// userSendStatus returns the outcome kept by recordUserSend, if the user was messaged
+func userSendStatus(c *gin.Context) (string, bool) {
+	status := c.GetString(whatsappStatusKey)
+	return status, status != ""
+}
+
+// renderUserMessage renders a message template in the language the user prefers
+func renderUserMessage(c *gin.Context, user models.User, name string, data any) (string, error) {
+	templates, exists := middleware.GetMessageTemplates(c)
+	if !exists {
+		return "", errors.New("message templates not available")
+	}
+	return templates.Render(user.Locale(), name, data)
+}
+
+// sendUserMessage renders a message template and queues it to the user
+func sendUserMessage(c *gin.Context, messenger services.Messenger, user models.User, name string, data any) error {
+	message, err := renderUserMessage(c, user, name, data)
+	if err != nil {
+		return err
+	}
+	return messenger.SendMessage(c.Request.Context(), user.Phone, message)
+}
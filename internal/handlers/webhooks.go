@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// maxDuplicateEvents caps how many deduplicated events are listed
+const maxDuplicateEvents = 100
+
+// GetWebhookDuplicates reports the webhook events that were retried within the
+// deduplication window and how many retries were ignored
+func GetWebhookDuplicates(c *gin.Context) {
+	events, eventsExist := middleware.GetWebhookEventRepository(c)
+	cfg, configExists := middleware.GetConfig(c)
+	if !eventsExist || !configExists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Webhook event repository not available"})
+		return
+	}
+
+	since := time.Now().Add(-cfg.WebhookDedupWindow)
+	duplicates, err := events.ListDuplicates(c.Request.Context(), since, maxDuplicateEvents)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list duplicate webhooks",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	total := 0
+	for _, event := range duplicates {
+		total += event.Duplicates
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"window":           cfg.WebhookDedupWindow.String(),
+		"since":            since,
+		"duplicates_total": total,
+		"events":           duplicates,
+	})
+}
+
+// GetWebhookRejections reports how many webhook requests failed authentication since
+// startup, a growing count means a caller has a stale secret or someone is probing
+func GetWebhookRejections(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"rejected_total": middleware.WebhookRejections()})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/middleware"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
+)
+
+func TestGetWebhookDuplicates(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{WebhookDedupWindow: time.Hour}
+	repos := newTestRepositories()
+	events := repository.NewMemoryWebhookEventRepository()
+	repos.WebhookEvents = events
+
+	event := models.WebhookEvent{DedupKey: "sell_request:7:INSERT", EventTable: "sell_request", EventType: "INSERT", RecordID: 7}
+	for i := 0; i < 3; i++ {
+		events.Claim(ctx, event, time.Now().Add(-time.Hour))
+	}
+	events.Claim(ctx, models.WebhookEvent{DedupKey: "user_logs:1:INSERT"}, time.Now().Add(-time.Hour))
+
+	router := newTestRouterWith(services.NewFakeMessenger(), repos)
+	router.Use(middleware.ConfigMiddleware(cfg))
+	router.GET("/webhooks/duplicates", GetWebhookDuplicates)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/duplicates", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		DuplicatesTotal int                   `json:"duplicates_total"`
+		Events          []models.WebhookEvent `json:"events"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.DuplicatesTotal != 2 || len(response.Events) != 1 || response.Events[0].RecordID != 7 {
+		t.Errorf("unexpected response: %s", w.Body.String())
+	}
+}
+
+func TestGetWebhookRejections(t *testing.T) {
+	router := newTestRouterWith(services.NewFakeMessenger(), newTestRepositories())
+	router.POST("/hook", middleware.WebhookAuth(&config.Config{WebhookSecret: "secret"}))
+	router.GET("/webhooks/rejections", GetWebhookRejections)
+
+	rejections := func() uint64 {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/rejections", nil))
+		var response struct {
+			RejectedTotal uint64 `json:"rejected_total"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response.RejectedTotal
+	}
+
+	before := rejections()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/hook", nil))
+	if after := rejections(); after != before+1 {
+		t.Errorf("expected %d rejections, got %d", before+1, after)
+	}
+}
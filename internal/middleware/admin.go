@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenParam carries the admin token for clients that can't set headers,
+// e.g. an <img> showing the pairing QR code or an EventSource
+const AdminTokenParam = "token"
+
+// AdminAuth rejects requests without the configured admin token, sent as a bearer
+// token or in the token query parameter. Without a token configured every request
+// is rejected.
+func AdminAuth(cfg *config.Config) gin.HandlerFunc {
+	token := []byte(cfg.AdminToken)
+	if len(token) == 0 {
+		log.Println("Warning: ADMIN_TOKEN is not set, all admin requests will be rejected")
+	}
+
+	return func(c *gin.Context) {
+		if len(token) == 0 {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "admin authentication is not configured"})
+			return
+		}
+
+		presented := c.Query(AdminTokenParam)
+		if header := c.GetHeader("Authorization"); header != "" {
+			presented = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		}
+		if subtle.ConstantTimeCompare(token, []byte(presented)) != 1 {
+			log.Printf("Admin Auth: Rejected %s %s from %s", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		target string
+		header string
+		want   int
+	}{
+		{"bearer token", "secret", "/admin", "Bearer secret", http.StatusOK},
+		{"query token", "secret", "/admin?token=secret", "", http.StatusOK},
+		{"wrong bearer token", "secret", "/admin?token=secret", "Bearer guess", http.StatusUnauthorized},
+		{"wrong query token", "secret", "/admin?token=guess", "", http.StatusUnauthorized},
+		{"no token", "secret", "/admin", "", http.StatusUnauthorized},
+		{"not configured", "", "/admin", "Bearer ", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(AdminAuth(&config.Config{AdminToken: tt.token}))
+			router.GET("/admin", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
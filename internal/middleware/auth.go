@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// WebhookSecretHeader carries the shared secret as is
+	WebhookSecretHeader = "X-Webhook-Secret"
+	// WebhookSignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the request body
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// webhookRejections counts rejected requests since the process started
+var webhookRejections atomic.Uint64
+
+// WebhookAuth rejects requests that carry neither a valid shared secret nor a valid
+// HMAC signature of the body. Both the current and the previous secret are accepted
+// so a secret can be rotated without dropping webhooks. Without any secret configured
+// every request is rejected.
+func WebhookAuth(cfg *config.Config) gin.HandlerFunc {
+	secrets := make([][]byte, 0, 2)
+	for _, secret := range []string{cfg.WebhookSecret, cfg.WebhookSecretPrevious} {
+		if secret != "" {
+			secrets = append(secrets, []byte(secret))
+		}
+	}
+	if len(secrets) == 0 {
+		log.Println("Warning: WEBHOOK_SECRET is not set, all webhook requests will be rejected")
+	}
+
+	return func(c *gin.Context) {
+		if len(secrets) == 0 {
+			rejectWebhook(c, http.StatusServiceUnavailable, "webhook authentication is not configured")
+			return
+		}
+
+		if secret := c.GetHeader(WebhookSecretHeader); secret != "" {
+			if matchesSecret(secrets, []byte(secret)) {
+				c.Next()
+				return
+			}
+			rejectWebhook(c, http.StatusUnauthorized, "invalid shared secret")
+			return
+		}
+
+		signature := c.GetHeader(WebhookSignatureHeader)
+		if signature == "" {
+			rejectWebhook(c, http.StatusUnauthorized, "missing credentials")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			rejectWebhook(c, http.StatusBadRequest, "unreadable body")
+			return
+		}
+		// Let the handler read the body again
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !matchesSignature(secrets, body, signature) {
+			rejectWebhook(c, http.StatusUnauthorized, "invalid signature")
+			return
+		}
+		c.Next()
+	}
+}
+
+// SignBody returns the signature header value for a body, used by callers and tests
+func SignBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookRejections returns how many requests were rejected since startup
+func WebhookRejections() uint64 {
+	return webhookRejections.Load()
+}
+
+// matchesSecret compares the presented secret with every active secret in constant time
+func matchesSecret(secrets [][]byte, presented []byte) bool {
+	matched := false
+	for _, secret := range secrets {
+		if subtle.ConstantTimeCompare(secret, presented) == 1 {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchesSignature checks the body signature against every active secret
+func matchesSignature(secrets [][]byte, body []byte, signature string) bool {
+	presented, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), signaturePrefix))
+	if err != nil {
+		return false
+	}
+
+	matched := false
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		if hmac.Equal(mac.Sum(nil), presented) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// rejectWebhook aborts the request and logs the running rejection count
+func rejectWebhook(c *gin.Context, status int, reason string) {
+	count := webhookRejections.Add(1)
+	log.Printf("Webhook Auth: Rejected %s %s from %s: %s (%d rejected so far)",
+		c.Request.Method, c.Request.URL.Path, c.ClientIP(), reason, count)
+	c.AbortWithStatusJSON(status, gin.H{"error": "Unauthorized"})
+}
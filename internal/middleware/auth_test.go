@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newAuthRouter returns a router whose /hook endpoint echoes the body it received
+func newAuthRouter(cfg *config.Config) *gin.Engine {
+	router := gin.New()
+	router.Use(WebhookAuth(cfg))
+	router.POST("/hook", func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.String(http.StatusOK, string(body))
+	})
+	return router
+}
+
+func postHook(router *gin.Engine, body string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestWebhookAuth(t *testing.T) {
+	router := newAuthRouter(&config.Config{WebhookSecret: "new", WebhookSecretPrevious: "old"})
+	body := `{"type":"INSERT"}`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"current secret", map[string]string{WebhookSecretHeader: "new"}, http.StatusOK},
+		{"previous secret", map[string]string{WebhookSecretHeader: "old"}, http.StatusOK},
+		{"wrong secret", map[string]string{WebhookSecretHeader: "guess"}, http.StatusUnauthorized},
+		{"signed with current secret", map[string]string{WebhookSignatureHeader: SignBody("new", []byte(body))}, http.StatusOK},
+		{"signed with previous secret", map[string]string{WebhookSignatureHeader: SignBody("old", []byte(body))}, http.StatusOK},
+		{"signed other body", map[string]string{WebhookSignatureHeader: SignBody("new", []byte("{}"))}, http.StatusUnauthorized},
+		{"malformed signature", map[string]string{WebhookSignatureHeader: "sha256=zz"}, http.StatusUnauthorized},
+		{"no credentials", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := WebhookRejections()
+			w := postHook(router, body, tt.headers)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, w.Code)
+			}
+			if tt.want == http.StatusOK && w.Body.String() != body {
+				t.Errorf("handler must still see the body, got %q", w.Body.String())
+			}
+			if rejected := WebhookRejections() > before; rejected != (tt.want != http.StatusOK) {
+				t.Errorf("rejection counter incremented=%v for status %d", rejected, w.Code)
+			}
+		})
+	}
+}
+
+func TestWebhookAuthFailsClosedWithoutSecret(t *testing.T) {
+	router := newAuthRouter(&config.Config{})
+	w := postHook(router, "{}", map[string]string{WebhookSecretHeader: ""})
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -23,10 +24,46 @@ func ConfigMiddleware(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
-// WhatsAppMiddleware injects the WhatsApp service into the context
-func WhatsAppMiddleware(whatsappService *services.WhatsAppService) gin.HandlerFunc {
+// MessengerMiddleware injects the WhatsApp messenger into the context
+func MessengerMiddleware(messenger services.Messenger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Set("whatsapp", whatsappService)
+		c.Set("messenger", messenger)
+		c.Next()
+	}
+}
+
+// RepositoryMiddleware injects the repositories into the context
+func RepositoryMiddleware(repos repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("users", repos.Users)
+		c.Set("properties", repos.Properties)
+		c.Set("messages", repos.Messages)
+		c.Set("webhook_events", repos.WebhookEvents)
+		c.Set("leads", repos.Leads)
+		c.Next()
+	}
+}
+
+// WorkflowMiddleware injects the conversation workflows into the context
+func WorkflowMiddleware(workflows services.Workflows) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("workflows", workflows)
+		c.Next()
+	}
+}
+
+// GroupRouterMiddleware injects the routing of internal alerts into the context
+func GroupRouterMiddleware(routes services.GroupRouter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("group_router", routes)
+		c.Next()
+	}
+}
+
+// MessageTemplatesMiddleware injects the message templates into the context
+func MessageTemplatesMiddleware(templates services.MessageRenderer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("message_templates", templates)
 		c.Next()
 	}
 }
@@ -51,12 +88,92 @@ func GetConfig(c *gin.Context) (*config.Config, bool) {
 	return config, ok
 }
 
-// GetWhatsApp retrieves the WhatsApp service from the context
-func GetWhatsApp(c *gin.Context) (*services.WhatsAppService, bool) {
-	ws, exists := c.Get("whatsapp")
+// GetMessenger retrieves the WhatsApp messenger from the context
+func GetMessenger(c *gin.Context) (services.Messenger, bool) {
+	m, exists := c.Get("messenger")
+	if !exists {
+		return nil, false
+	}
+	messenger, ok := m.(services.Messenger)
+	return messenger, ok
+}
+
+// GetUserRepository retrieves the user repository from the context
+func GetUserRepository(c *gin.Context) (repository.UserRepository, bool) {
+	r, exists := c.Get("users")
+	if !exists {
+		return nil, false
+	}
+	users, ok := r.(repository.UserRepository)
+	return users, ok
+}
+
+// GetPropertyRepository retrieves the property repository from the context
+func GetPropertyRepository(c *gin.Context) (repository.PropertyRepository, bool) {
+	r, exists := c.Get("properties")
+	if !exists {
+		return nil, false
+	}
+	properties, ok := r.(repository.PropertyRepository)
+	return properties, ok
+}
+
+// GetMessageRepository retrieves the message status repository from the context
+func GetMessageRepository(c *gin.Context) (repository.MessageRepository, bool) {
+	r, exists := c.Get("messages")
+	if !exists {
+		return nil, false
+	}
+	messages, ok := r.(repository.MessageRepository)
+	return messages, ok
+}
+
+// GetWebhookEventRepository retrieves the webhook event repository from the context
+func GetWebhookEventRepository(c *gin.Context) (repository.WebhookEventRepository, bool) {
+	r, exists := c.Get("webhook_events")
+	if !exists {
+		return nil, false
+	}
+	events, ok := r.(repository.WebhookEventRepository)
+	return events, ok
+}
+
+// GetConstructionLeadRepository retrieves the construction lead repository from the context
+func GetConstructionLeadRepository(c *gin.Context) (repository.ConstructionLeadRepository, bool) {
+	r, exists := c.Get("leads")
+	if !exists {
+		return nil, false
+	}
+	leads, ok := r.(repository.ConstructionLeadRepository)
+	return leads, ok
+}
+
+// GetWorkflows retrieves the conversation workflows from the context
+func GetWorkflows(c *gin.Context) (services.Workflows, bool) {
+	w, exists := c.Get("workflows")
+	if !exists {
+		return services.Workflows{}, false
+	}
+	workflows, ok := w.(services.Workflows)
+	return workflows, ok
+}
+
+// GetGroupRouter retrieves the routing of internal alerts from the context
+func GetGroupRouter(c *gin.Context) (services.GroupRouter, bool) {
+	r, exists := c.Get("group_router")
+	if !exists {
+		return nil, false
+	}
+	routes, ok := r.(services.GroupRouter)
+	return routes, ok
+}
+
+// GetMessageTemplates retrieves the message templates from the context
+func GetMessageTemplates(c *gin.Context) (services.MessageRenderer, bool) {
+	t, exists := c.Get("message_templates")
 	if !exists {
 		return nil, false
 	}
-	whatsappService, ok := ws.(*services.WhatsAppService)
-	return whatsappService, ok
+	templates, ok := t.(services.MessageRenderer)
+	return templates, ok
 }
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// processingRetryAfter is how long a retry of an event that is still being processed
+// is asked to wait
+const processingRetryAfter = 30 * time.Second
+
+// capturingWriter keeps a copy of the response body so it can be replayed to retries
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// WebhookIdempotency makes webhook handlers safe to retry. The first delivery of an
+// event runs the handler and stores its response, later deliveries of the same event
+// within the configured window get a 200 with the original outcome instead. A retry
+// arriving while the first delivery is still processing gets a 409 with Retry-After,
+// since that delivery may still fail. Failed events (5xx) are forgotten so the retry
+// gets another chance.
+func WebhookIdempotency(events repository.WebhookEventRepository, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Unreadable body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var envelope models.WebhookEnvelope
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			// Let the handler report the malformed payload
+			c.Next()
+			return
+		}
+		key, recordID, ok := envelope.DedupKey()
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		event, isNew, err := events.Claim(ctx, models.WebhookEvent{
+			DedupKey:   key,
+			EventTable: envelope.Table,
+			EventType:  envelope.Type,
+			RecordID:   recordID,
+		}, time.Now().Add(-cfg.WebhookDedupWindow))
+		if err != nil {
+			// Rather risk a duplicate message than drop the event
+			log.Printf("Webhook Error: Deduplication unavailable for %s: %v", key, err)
+			c.Next()
+			return
+		}
+
+		if !isNew {
+			log.Printf("Webhook: Duplicate %s (%d duplicate(s) so far)", key, event.Duplicates)
+			response := gin.H{
+				"message":    "Duplicate webhook event ignored",
+				"duplicate":  true,
+				"dedup_key":  key,
+				"duplicates": event.Duplicates,
+				"first_seen": event.ReceivedAt,
+			}
+			if event.StatusCode == nil {
+				response["message"] = "Webhook event is still being processed"
+				response["original_status"] = "processing"
+				c.Header("Retry-After", strconv.Itoa(int(processingRetryAfter.Seconds())))
+				c.AbortWithStatusJSON(http.StatusConflict, response)
+				return
+			}
+			response["original_status"] = *event.StatusCode
+			response["original_response"] = event.Response
+			c.AbortWithStatusJSON(http.StatusOK, response)
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			if err := events.Release(ctx, key); err != nil {
+				log.Printf("Webhook Error: %v", err)
+			}
+			return
+		}
+
+		response := writer.body.Bytes()
+		if !json.Valid(response) {
+			response = nil
+		}
+		if err := events.Complete(ctx, key, status, response); err != nil {
+			log.Printf("Webhook Error: %v", err)
+		}
+	}
+}
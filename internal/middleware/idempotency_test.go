@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// newIdempotentRouter returns a router whose /hook endpoint counts how often it ran
+// and answers with the given status
+func newIdempotentRouter(events repository.WebhookEventRepository, status int, calls *int) *gin.Engine {
+	router := gin.New()
+	router.Use(WebhookIdempotency(events, &config.Config{WebhookDedupWindow: time.Hour}))
+	router.POST("/hook", func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"calls": *calls})
+	})
+	return router
+}
+
+const sellRequestEvent = `{"type":"INSERT","table":"sell_request","record":{"id":7,"user_id":"u1"}}`
+
+func TestWebhookIdempotencyShortCircuitsRetries(t *testing.T) {
+	events := repository.NewMemoryWebhookEventRepository()
+	calls := 0
+	router := newIdempotentRouter(events, http.StatusOK, &calls)
+
+	postHook(router, sellRequestEvent, nil)
+	w := postHook(router, sellRequestEvent, nil)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a duplicate, got %d", w.Code)
+	}
+
+	var response struct {
+		Duplicate        bool            `json:"duplicate"`
+		Duplicates       int             `json:"duplicates"`
+		OriginalStatus   int             `json:"original_status"`
+		OriginalResponse json.RawMessage `json:"original_response"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if !response.Duplicate || response.Duplicates != 1 || response.OriginalStatus != http.StatusOK || string(response.OriginalResponse) != `{"calls":1}` {
+		t.Errorf("unexpected duplicate response: %s", w.Body.String())
+	}
+
+	// Other events of the same row still go through
+	postHook(router, `{"type":"DELETE","table":"sell_request","old_record":{"id":7}}`, nil)
+	if calls != 2 {
+		t.Errorf("a different event type must be processed, handler ran %d times", calls)
+	}
+}
+
+func TestWebhookIdempotencyProcessesAgainAfterWindow(t *testing.T) {
+	events := repository.NewMemoryWebhookEventRepository()
+	calls := 0
+	router := newIdempotentRouter(events, http.StatusOK, &calls)
+
+	postHook(router, sellRequestEvent, nil)
+	events.Age("sell_request:7:INSERT", 2*time.Hour)
+	postHook(router, sellRequestEvent, nil)
+
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestWebhookIdempotencyRetriesFailures(t *testing.T) {
+	events := repository.NewMemoryWebhookEventRepository()
+	calls := 0
+	router := newIdempotentRouter(events, http.StatusInternalServerError, &calls)
+
+	postHook(router, sellRequestEvent, nil)
+	postHook(router, sellRequestEvent, nil)
+
+	if calls != 2 {
+		t.Errorf("failed events must be processed again, handler ran %d times", calls)
+	}
+}
+
+func TestWebhookIdempotencyDefersRetriesWhileProcessing(t *testing.T) {
+	events := repository.NewMemoryWebhookEventRepository()
+	calls := 0
+	started, finish := make(chan struct{}), make(chan struct{})
+	router := gin.New()
+	router.Use(WebhookIdempotency(events, &config.Config{WebhookDedupWindow: time.Hour}))
+	router.POST("/hook", func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			close(started)
+			<-finish
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"calls": calls})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		postHook(router, sellRequestEvent, nil)
+		close(done)
+	}()
+	<-started
+
+	w := postHook(router, sellRequestEvent, nil)
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Errorf("a retry while processing must be deferred, got %d with Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// The first delivery fails and is released, so the next retry is processed
+	close(finish)
+	<-done
+	postHook(router, sellRequestEvent, nil)
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestWebhookIdempotencyPassesUnkeyedPayloads(t *testing.T) {
+	events := repository.NewMemoryWebhookEventRepository()
+	calls := 0
+	router := newIdempotentRouter(events, http.StatusOK, &calls)
+
+	for _, body := range []string{`not json`, `{"type":"INSERT","table":"sell_request","record":{}}`} {
+		postHook(router, body, nil)
+		postHook(router, body, nil)
+	}
+	if calls != 4 {
+		t.Errorf("payloads without a record ID must always reach the handler, ran %d times", calls)
+	}
+}
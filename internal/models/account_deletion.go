@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// DeletionStatus is the state of an account deletion request
+type DeletionStatus string
+
+// Deletion status constants
+const (
+	DeletionPending   DeletionStatus = "pending"
+	DeletionDeleting  DeletionStatus = "deleting"
+	DeletionCancelled DeletionStatus = "cancelled"
+	DeletionCompleted DeletionStatus = "completed"
+	DeletionFailed    DeletionStatus = "failed"
+)
+
+// AccountDeletion represents a row of the account_deletions table. The account is
+// deleted once ScheduledFor has passed unless the user cancels before then.
+type AccountDeletion struct {
+	ID           int64          `json:"id" db:"id"`
+	UserID       string         `json:"user_id" db:"user_id"`
+	Phone        string         `json:"phone" db:"phone"`
+	Locale       string         `json:"locale" db:"locale"`
+	Status       DeletionStatus `json:"status" db:"status"`
+	RequestedAt  time.Time      `json:"requested_at" db:"requested_at"`
+	ScheduledFor time.Time      `json:"scheduled_for" db:"scheduled_for"`
+	CancelledAt  *time.Time     `json:"cancelled_at" db:"cancelled_at"`
+	CompletedAt  *time.Time     `json:"completed_at" db:"completed_at"`
+	LastError    *string        `json:"last_error" db:"last_error"`
+	// ClaimedAt is when the deletion was last claimed for deleting the account
+	ClaimedAt *time.Time `json:"claimed_at" db:"claimed_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "strings"
+
+// Address represents a row of the address table a property points to
+type Address struct {
+	ID       int64   `json:"id" db:"id"`
+	Locality *string `json:"locality" db:"locality"`
+	City     *string `json:"city" db:"city"`
+	District *string `json:"district" db:"district"`
+	State    *string `json:"state" db:"state"`
+}
+
+// Area describes where an address is without pinpointing it, e.g.
+// "Vidyanagar, Hubballi". It returns an empty string when nothing is known.
+func (a Address) Area() string {
+	var parts []string
+	for _, part := range []*string{a.Locality, a.City, a.District} {
+		if part == nil || strings.TrimSpace(*part) == "" {
+			continue
+		}
+		text := strings.TrimSpace(*part)
+		if len(parts) > 0 && strings.EqualFold(parts[len(parts)-1], text) {
+			continue
+		}
+		parts = append(parts, text)
+		if len(parts) == 2 {
+			break
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ConnectionState is the state of the WhatsApp session as seen by the supervisor
+type ConnectionState string
+
+// Connection state constants
+const (
+	ConnectionDisconnected ConnectionState = "disconnected"
+	ConnectionConnecting   ConnectionState = "connecting"
+	ConnectionConnected    ConnectionState = "connected"
+	// ConnectionReconnecting waits for the next attempt after a failure or drop
+	ConnectionReconnecting ConnectionState = "reconnecting"
+	// ConnectionPairing means the device is not linked and an operator has to pair it
+	ConnectionPairing ConnectionState = "pairing"
+)
+
+// ConnectionTransition records a change of the connection state
+type ConnectionTransition struct {
+	From   ConnectionState `json:"from"`
+	To     ConnectionState `json:"to"`
+	Reason string          `json:"reason,omitempty"`
+	At     time.Time       `json:"at"`
+}
+
+// ConnectionStatus is a snapshot of the WhatsApp session for operators
+type ConnectionStatus struct {
+	State ConnectionState `json:"state"`
+	Since time.Time       `json:"since"`
+	// Attempts counts failed connection attempts since the last session that stayed up
+	Attempts      int                    `json:"attempts"`
+	LastError     string                 `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time             `json:"next_attempt_at,omitempty"`
+	Transitions   []ConnectionTransition `json:"transitions"`
+}
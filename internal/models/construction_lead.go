@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ConstructionLead represents a row of the construction_leads table, a user who
+// showed interest in our construction services
+type ConstructionLead struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	Phone     string    `json:"phone" db:"phone"`
+	Source    EventType `json:"source" db:"source"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// ChatType tells whether an inbound message came from a direct chat or a group
+type ChatType string
+
+// Chat type constants
+const (
+	ChatDirect ChatType = "direct"
+	ChatGroup  ChatType = "group"
+)
+
+// ContentType is the kind of content carried by an inbound message
+type ContentType string
+
+// Content type constants
+const (
+	ContentText     ContentType = "text"
+	ContentImage    ContentType = "image"
+	ContentLocation ContentType = "location"
+	ContentDocument ContentType = "document"
+	ContentOther    ContentType = "other"
+)
+
+// ConversationStatus tells whether the handlers of an inbound message have run
+type ConversationStatus string
+
+// Conversation status constants
+const (
+	ConversationReceived ConversationStatus = "received"
+	ConversationHandled  ConversationStatus = "handled"
+	// ConversationFailed messages are handled again when WhatsApp redelivers them
+	ConversationFailed ConversationStatus = "failed"
+)
+
+// ConversationMessage represents an inbound message stored in the conversation_log table
+type ConversationMessage struct {
+	ID          int64       `json:"id" db:"id"`
+	MessageID   string      `json:"message_id" db:"message_id"`
+	ChatJID     string      `json:"chat_jid" db:"chat_jid"`
+	SenderPhone string      `json:"sender_phone" db:"sender_phone"`
+	UserID      *string     `json:"user_id" db:"user_id"`
+	ChatType    ChatType    `json:"chat_type" db:"chat_type"`
+	ContentType ContentType `json:"content_type" db:"content_type"`
+	Body        string      `json:"body" db:"body"`
+	ReceivedAt  time.Time   `json:"received_at" db:"received_at"`
+
+	Status    ConversationStatus `json:"status" db:"status"`
+	LastError *string            `json:"last_error" db:"last_error"`
+}
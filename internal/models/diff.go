@@ -0,0 +1,80 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FieldChange is a column whose value differs between the old and new record of an UPDATE
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// RecordDiff lists the changed columns of a record in declaration order
+type RecordDiff []FieldChange
+
+// Get returns the change of a column by its JSON name
+func (d RecordDiff) Get(field string) (FieldChange, bool) {
+	for _, change := range d {
+		if change.Field == field {
+			return change, true
+		}
+	}
+	return FieldChange{}, false
+}
+
+// Fields returns the names of the changed columns
+func (d RecordDiff) Fields() []string {
+	fields := make([]string, len(d))
+	for i, change := range d {
+		fields[i] = change.Field
+	}
+	return fields
+}
+
+// DiffRecords compares two records of the same struct type field by field and returns
+// the changed fields named by their JSON tags. Pointer fields are compared by value.
+func DiffRecords(old, new interface{}) RecordDiff {
+	oldValue, newValue := reflect.ValueOf(old), reflect.ValueOf(new)
+	if oldValue.Type() != newValue.Type() || oldValue.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var diff RecordDiff
+	for i := 0; i < oldValue.NumField(); i++ {
+		field := oldValue.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+
+		before, after := fieldValue(oldValue.Field(i)), fieldValue(newValue.Field(i))
+		if !reflect.DeepEqual(before, after) {
+			diff = append(diff, FieldChange{Field: name, Old: before, New: after})
+		}
+	}
+	return diff
+}
+
+// fieldValue dereferences pointer fields, nil pointers become nil
+func fieldValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// Diff returns the columns that changed from the old sell request
+func (r SellRequest) Diff(old SellRequest) RecordDiff {
+	return DiffRecords(old, r)
+}
+
+// Diff returns the columns that changed from the old log entry
+func (r LogsRequest) Diff(old LogsRequest) RecordDiff {
+	return DiffRecords(old, r)
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSellRequestDiff(t *testing.T) {
+	old := SellRequest{Id: 7, UserID: "u1", Attended: false, AssignTo: ""}
+	updated := SellRequest{Id: 7, UserID: "u1", Attended: true, AssignTo: "Ravi"}
+
+	diff := updated.Diff(old)
+	if got := diff.Fields(); !reflect.DeepEqual(got, []string{"attended", "assign_to"}) {
+		t.Fatalf("unexpected changed fields: %v", got)
+	}
+	if change, _ := diff.Get("attended"); change.Old != false || change.New != true {
+		t.Errorf("unexpected attended change: %+v", change)
+	}
+	if len(old.Diff(old)) != 0 {
+		t.Error("identical records must have no changes")
+	}
+}
+
+func TestLogsRequestDiffComparesPointerValues(t *testing.T) {
+	attended, stillAttended, notAttended := true, true, false
+	old := LogsRequest{Id: 1, Attended: &attended}
+
+	if diff := (LogsRequest{Id: 1, Attended: &stillAttended}).Diff(old); len(diff) != 0 {
+		t.Errorf("equal pointer values must not be a change, got %+v", diff)
+	}
+
+	diff := LogsRequest{Id: 1, Attended: &notAttended, Notes: nil}.Diff(old)
+	if change, ok := diff.Get("attended"); !ok || change.Old != true || change.New != false {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+}
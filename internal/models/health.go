@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Health status constants
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// OutboxStats describes the queue of outbound messages
+type OutboxStats struct {
+	// Pending counts messages waiting to be sent, Due those whose next attempt is overdue
+	// and Scheduled those held until quiet hours end
+	Pending         int        `json:"pending"`
+	Due             int        `json:"due"`
+	Scheduled       int        `json:"scheduled"`
+	Dead            int        `json:"dead"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+	LastSentAt      *time.Time `json:"last_sent_at,omitempty"`
+}
+
+// WhatsAppHealth describes the WhatsApp session
+type WhatsAppHealth struct {
+	State  ConnectionState `json:"state"`
+	Since  time.Time       `json:"since"`
+	Paired bool            `json:"paired"`
+}
+
+// DatabaseHealth is the result of pinging the database
+type DatabaseHealth struct {
+	OK        bool    `json:"ok"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport is the answer of the liveness and readiness probes
+type HealthReport struct {
+	Status   string          `json:"status"`
+	WhatsApp WhatsAppHealth  `json:"whatsapp"`
+	Database *DatabaseHealth `json:"database,omitempty"`
+	Outbox   *OutboxStats    `json:"outbox,omitempty"`
+	// Problems explains an unavailable status
+	Problems  []string  `json:"problems,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
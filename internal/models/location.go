@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Location is a point on the map sent as a native WhatsApp location pin
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Name      string  `json:"name,omitempty"`
+	Address   string  `json:"address,omitempty"`
+}
+
+// ParseMapCenterpoint reads the coordinates stored in property.map_centerpoint.
+// It accepts "lat,lng" (optionally in parentheses), WKT "POINT(lng lat)" and JSON,
+// either {"lat":..,"lng":..} or a GeoJSON point.
+func ParseMapCenterpoint(value string) (Location, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Location{}, fmt.Errorf("empty map centerpoint")
+	}
+
+	var lat, lng float64
+	var err error
+	switch {
+	case strings.HasPrefix(value, "{"):
+		lat, lng, err = parseJSONPoint(value)
+	case strings.HasPrefix(strings.ToUpper(value), "POINT"):
+		inner := strings.Trim(strings.TrimSpace(value[len("POINT"):]), "()")
+		fields := strings.Fields(inner)
+		if len(fields) != 2 {
+			return Location{}, fmt.Errorf("invalid map centerpoint %q", value)
+		}
+		lng, lat, err = parsePair(fields[0], fields[1])
+	default:
+		parts := strings.Split(strings.Trim(value, "()"), ",")
+		if len(parts) != 2 {
+			return Location{}, fmt.Errorf("invalid map centerpoint %q", value)
+		}
+		lat, lng, err = parsePair(parts[0], parts[1])
+	}
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid map centerpoint %q: %v", value, err)
+	}
+
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return Location{}, fmt.Errorf("map centerpoint %q is out of range", value)
+	}
+	return Location{Latitude: lat, Longitude: lng}, nil
+}
+
+// parseJSONPoint reads {"lat":..,"lng":..} or a GeoJSON point
+func parseJSONPoint(value string) (float64, float64, error) {
+	var point struct {
+		Lat         *float64  `json:"lat"`
+		Lng         *float64  `json:"lng"`
+		Latitude    *float64  `json:"latitude"`
+		Longitude   *float64  `json:"longitude"`
+		Coordinates []float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal([]byte(value), &point); err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case point.Lat != nil && point.Lng != nil:
+		return *point.Lat, *point.Lng, nil
+	case point.Latitude != nil && point.Longitude != nil:
+		return *point.Latitude, *point.Longitude, nil
+	case len(point.Coordinates) == 2:
+		// GeoJSON puts longitude first
+		return point.Coordinates[1], point.Coordinates[0], nil
+	default:
+		return 0, 0, fmt.Errorf("no coordinates")
+	}
+}
+
+func parsePair(first, second string) (float64, float64, error) {
+	a, err := strconv.ParseFloat(strings.TrimSpace(first), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := strconv.ParseFloat(strings.TrimSpace(second), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return a, b, nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestParseMapCenterpoint(t *testing.T) {
+	tests := []string{
+		"15.3647,75.1240",
+		" (15.3647, 75.1240) ",
+		"POINT(75.1240 15.3647)",
+		`{"lat": 15.3647, "lng": 75.1240}`,
+		`{"type": "Point", "coordinates": [75.1240, 15.3647]}`,
+	}
+	for _, value := range tests {
+		location, err := ParseMapCenterpoint(value)
+		if err != nil {
+			t.Errorf("ParseMapCenterpoint(%q) failed: %v", value, err)
+			continue
+		}
+		if location.Latitude != 15.3647 || location.Longitude != 75.1240 {
+			t.Errorf("ParseMapCenterpoint(%q) = %+v", value, location)
+		}
+	}
+
+	for _, value := range []string{"", "somewhere", "15.3", "95.0,75.1", `{"zoom": 12}`} {
+		if _, err := ParseMapCenterpoint(value); err == nil {
+			t.Errorf("ParseMapCenterpoint(%q) should fail", value)
+		}
+	}
+}
+
+func TestAddressArea(t *testing.T) {
+	locality, city := "Vidyanagar", "Hubballi"
+	if got := (Address{Locality: &locality, City: &city, District: &city}).Area(); got != "Vidyanagar, Hubballi" {
+		t.Errorf("unexpected area %q", got)
+	}
+	if got := (Address{}).Area(); got != "" {
+		t.Errorf("expected no area, got %q", got)
+	}
+}
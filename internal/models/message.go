@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// MessageStatus is the delivery state of a message we sent, driven by WhatsApp receipts
+type MessageStatus string
+
+// Message status constants, in the order a healthy message moves through them
+const (
+	MessageSent      MessageStatus = "sent"
+	MessageDelivered MessageStatus = "delivered"
+	MessageRead      MessageStatus = "read"
+	MessageFailed    MessageStatus = "failed"
+)
+
+// Supersedes reports whether a message in the current status may move to e.
+// Statuses only move forward, so a late delivery receipt never hides a read,
+// and a failure is only recorded for messages that were never delivered.
+func (e MessageStatus) Supersedes(current MessageStatus) bool {
+	switch e {
+	case MessageDelivered:
+		return current == MessageSent || current == MessageFailed
+	case MessageRead:
+		return current != MessageRead
+	case MessageFailed:
+		return current == MessageSent
+	default:
+		return false
+	}
+}
+
+// TrackedMessage represents a row of the whatsapp_messages table
+type TrackedMessage struct {
+	MessageID     string        `json:"message_id" db:"message_id"`
+	OutboxID      *int64        `json:"outbox_id" db:"outbox_id"`
+	RecipientType RecipientType `json:"recipient_type" db:"recipient_type"`
+	Recipient     string        `json:"recipient" db:"recipient"`
+	Status        MessageStatus `json:"status" db:"status"`
+	SentAt        time.Time     `json:"sent_at" db:"sent_at"`
+	DeliveredAt   *time.Time    `json:"delivered_at" db:"delivered_at"`
+	ReadAt        *time.Time    `json:"read_at" db:"read_at"`
+	FailedAt      *time.Time    `json:"failed_at" db:"failed_at"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+}
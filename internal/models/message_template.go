@@ -0,0 +1,76 @@
+package models
+
+// UserMessageData is the data of messages to a user and of alerts about them.
+// Templates greet users without a name with {{or .User.Name "Sir/Madam"}}.
+type UserMessageData struct {
+	User User
+}
+
+// AccountDeletionMessageData is the data of account deletion messages, Deletion is
+// nil when the deletion couldn't be scheduled
+type AccountDeletionMessageData struct {
+	User     User
+	Deletion *AccountDeletion
+	// Error is why the account couldn't be deleted
+	Error string
+}
+
+// BrochureMessageData is the data of the construction brochure caption and alert
+type BrochureMessageData struct {
+	User User
+	// LeadID is the recorded construction lead, e.g. "#12", or "not recorded"
+	LeadID string
+	// BrochureStatus tells the team whether the brochure reached the user
+	BrochureStatus string
+}
+
+// PropertyInterestMessageData is the data of the alert about a user interested in a property
+type PropertyInterestMessageData struct {
+	User     User
+	Property Property
+	// URL links to the listing
+	URL string
+	// Pinned is set when the location of the property follows as a pin, otherwise
+	// Area is the approximate location if known
+	Pinned bool
+	Area   string
+	// Error is why the property couldn't be looked up, Property is then empty
+	Error string
+}
+
+// SellRequestMessageData is the data of sell request messages and alerts
+type SellRequestMessageData struct {
+	User        User
+	SellRequest SellRequest
+}
+
+// UndeliverableMessageData is the data of the alert about a message that was dropped
+// because the user isn't on WhatsApp
+type UndeliverableMessageData struct {
+	Phone    string
+	OutboxID int64
+	// Text is the text or caption of the message, empty for e.g. a location
+	Text string
+}
+
+// SellChecklistMessageData is the data of the messages to a seller about the checklist
+// of their sell request
+type SellChecklistMessageData struct {
+	Checklist SellChecklist
+	// Missing are the items the seller still has to send
+	Missing []ChecklistItem
+	// Document is set when asking to resend a document rather than an image
+	Document bool
+}
+
+// RentalIntakeMessageData is the data of the rental intake conversation messages
+type RentalIntakeMessageData struct {
+	Intake RentalIntake
+	// DraftID is the draft listing created from a completed intake
+	DraftID int64
+	// Retry is set when the last reply couldn't be used and the question is asked again
+	Retry bool
+	// MinPhotos is how many photos a listing needs, MissingPhotos how many of them are still to come
+	MinPhotos     int
+	MissingPhotos int
+}
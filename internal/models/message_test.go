@@ -0,0 +1,26 @@
+package models
+
+import "testing"
+
+func TestMessageStatusSupersedes(t *testing.T) {
+	tests := []struct {
+		next, current MessageStatus
+		want          bool
+	}{
+		{MessageDelivered, MessageSent, true},
+		{MessageDelivered, MessageFailed, true},
+		{MessageDelivered, MessageRead, false},
+		{MessageRead, MessageSent, true},
+		{MessageRead, MessageDelivered, true},
+		{MessageRead, MessageRead, false},
+		{MessageFailed, MessageSent, true},
+		{MessageFailed, MessageDelivered, false},
+		{MessageSent, MessageSent, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.next.Supersedes(tt.current); got != tt.want {
+			t.Errorf("%s.Supersedes(%s) = %v, want %v", tt.next, tt.current, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// RecipientType identifies who an outbound message is addressed to
+type RecipientType string
+
+// Recipient type constants
+const (
+	RecipientUser  RecipientType = "user"
+	RecipientGroup RecipientType = "group"
+)
+
+// OutboxStatus represents the delivery state of an outbox message
+type OutboxStatus string
+
+// Outbox status constants
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	OutboxDead    OutboxStatus = "dead"
+	// OutboxCancelled messages were held for quiet hours and cancelled by an operator
+	OutboxCancelled OutboxStatus = "cancelled"
+)
+
+// MediaKind is the type of media attached to an outbound message
+type MediaKind string
+
+// Media kind constants
+const (
+	MediaImage    MediaKind = "image"
+	MediaDocument MediaKind = "document"
+	MediaAudio    MediaKind = "audio"
+	MediaVideo    MediaKind = "video"
+)
+
+// MediaAttachment is a file sent with an outbound message, either from local disk
+// (Path) or from the web (URL). The file is fetched and uploaded when the message is
+// delivered, so only its location is queued. An empty MimeType is detected from the
+// file name or content.
+type MediaAttachment struct {
+	Kind     MediaKind `json:"kind"`
+	Path     string    `json:"path,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	FileName string    `json:"file_name,omitempty"`
+	MimeType string    `json:"mime_type,omitempty"`
+}
+
+// LinkPreview makes a link in the message text render as a rich card. The
+// thumbnail is generated from Image when the message is delivered.
+type LinkPreview struct {
+	URL         string           `json:"url"`
+	Title       string           `json:"title"`
+	Description string           `json:"description,omitempty"`
+	Image       *MediaAttachment `json:"image,omitempty"`
+}
+
+// OutboundMessage is the content of a message waiting in the outbox.
+// With Media set, Text is sent as the caption. With Location set, a location
+// pin is sent and Text is ignored. With Preview set, the link in Text is shown
+// as a card.
+type OutboundMessage struct {
+	Text     string           `json:"text"`
+	Media    *MediaAttachment `json:"media,omitempty"`
+	Location *Location        `json:"location,omitempty"`
+	Preview  *LinkPreview     `json:"preview,omitempty"`
+}
+
+// OutboxMessage represents a row of the whatsapp_outbox table
+type OutboxMessage struct {
+	ID            int64           `json:"id" db:"id"`
+	RecipientType RecipientType   `json:"recipient_type" db:"recipient_type"`
+	Recipient     string          `json:"recipient" db:"recipient"`
+	Payload       OutboundMessage `json:"payload" db:"payload"`
+	Status        OutboxStatus    `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     *string         `json:"last_error" db:"last_error"`
+	WAMessageID   *string         `json:"wa_message_id" db:"wa_message_id"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	SentAt        *time.Time      `json:"sent_at" db:"sent_at"`
+	// ScheduledFor is when a message held for quiet hours goes out, nil for messages
+	// that were sent right away
+	ScheduledFor *time.Time `json:"scheduled_for" db:"scheduled_for"`
+	// Immediate messages are exempt from quiet hours, e.g. replies to an inbound message
+	Immediate bool `json:"immediate" db:"immediate"`
+}
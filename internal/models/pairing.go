@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// PairingMethod is how the WhatsApp device is linked to an account
+type PairingMethod string
+
+// Pairing method constants
+const (
+	// PairingQR shows a QR code that is scanned in the WhatsApp app
+	PairingQR PairingMethod = "qr"
+	// PairingPhone shows a code that is entered in the WhatsApp app of the given number
+	PairingPhone PairingMethod = "phone"
+)
+
+// PairingState is the progress of linking the device
+type PairingState string
+
+// Pairing state constants
+const (
+	// PairingIdle means no pairing was started, or the device is already paired
+	PairingIdle PairingState = "idle"
+	// PairingStarting means a pairing was requested and the connection is being set up
+	PairingStarting PairingState = "starting"
+	// PairingQRReady means a QR code is waiting to be scanned
+	PairingQRReady PairingState = "qr"
+	// PairingCodeReady means a pairing code is waiting to be entered
+	PairingCodeReady PairingState = "code"
+	PairingSuccess   PairingState = "success"
+	PairingFailed    PairingState = "failed"
+)
+
+// PairingRequest asks for the device to be linked
+type PairingRequest struct {
+	Method PairingMethod `json:"method"`
+	// Phone is the account number in international format, required for phone pairing
+	Phone string `json:"phone,omitempty"`
+}
+
+// PairingStatus is a snapshot of an ongoing or finished pairing
+type PairingStatus struct {
+	State  PairingState  `json:"state"`
+	Method PairingMethod `json:"method,omitempty"`
+	Phone  string        `json:"phone,omitempty"`
+	// QRCode is the content of the current QR code, rendered as an image by the API
+	QRCode string `json:"qr_code,omitempty"`
+	// PairingCode is entered under Linked Devices → Link with phone number
+	PairingCode string `json:"pairing_code,omitempty"`
+	// ExpiresAt is when the current QR code is replaced or the pairing times out
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
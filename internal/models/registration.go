@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// WhatsAppRegistration represents a row of the whatsapp_registrations table, caching
+// whether a phone number has a WhatsApp account
+type WhatsAppRegistration struct {
+	Phone      string    `json:"phone" db:"phone"`
+	Registered bool      `json:"registered" db:"registered"`
+	JID        *string   `json:"jid" db:"jid"`
+	CheckedAt  time.Time `json:"checked_at" db:"checked_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// IntakeStep is the item a rental intake conversation is currently asking for
+type IntakeStep string
+
+// Intake steps in the order the user is walked through them
+const (
+	IntakePhotos      IntakeStep = "photos"
+	IntakeLocation    IntakeStep = "location"
+	IntakeDescription IntakeStep = "description"
+	IntakeRent        IntakeStep = "rent"
+	IntakeContact     IntakeStep = "contact"
+)
+
+// IntakeStatus is the lifecycle state of a rental intake conversation
+type IntakeStatus string
+
+// Intake status constants
+const (
+	IntakeActive    IntakeStatus = "active"
+	IntakeCompleted IntakeStatus = "completed"
+	IntakeExpired   IntakeStatus = "expired"
+)
+
+// RentalIntake represents a row of the rental_intake_sessions table, the progress
+// of a user listing a rental property over WhatsApp
+type RentalIntake struct {
+	ID           int64        `json:"id" db:"id"`
+	UserID       string       `json:"user_id" db:"user_id"`
+	Phone        string       `json:"phone" db:"phone"`
+	Locale       string       `json:"locale" db:"locale"`
+	Step         IntakeStep   `json:"step" db:"step"`
+	Status       IntakeStatus `json:"status" db:"status"`
+	Photos       []string     `json:"photos" db:"photos"`
+	MapsLink     *string      `json:"maps_link" db:"maps_link"`
+	Latitude     *float64     `json:"latitude" db:"latitude"`
+	Longitude    *float64     `json:"longitude" db:"longitude"`
+	Description  *string      `json:"description" db:"description"`
+	Rent         *int64       `json:"rent" db:"rent"`
+	ContactPhone *string      `json:"contact_phone" db:"contact_phone"`
+	DraftID      *int64       `json:"draft_id" db:"draft_id"`
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at" db:"updated_at"`
+}
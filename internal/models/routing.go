@@ -0,0 +1,32 @@
+package models
+
+// Notification categories pick the internal groups an alert goes to. Logged events
+// use the category of EventType.GetCategory.
+const (
+	CategoryPropertyInteraction = "property_interaction"
+	CategoryConstruction        = "construction"
+	CategoryRental              = "rental"
+	CategorySearch              = "search"
+	CategoryAccount             = "account"
+	CategorySellRequest         = "sell_request"
+	// CategoryDelivery is for messages that could not be delivered to a user
+	CategoryDelivery = "delivery"
+)
+
+// NotificationCategories lists every category alerts are sent for
+var NotificationCategories = []string{
+	CategoryPropertyInteraction,
+	CategoryConstruction,
+	CategoryRental,
+	CategorySearch,
+	CategoryAccount,
+	CategorySellRequest,
+	CategoryDelivery,
+}
+
+// NotificationRoutes maps notification categories to the group JIDs alerting them.
+// Categories without a route go to the Default groups.
+type NotificationRoutes struct {
+	Default []string            `json:"default"`
+	Routes  map[string][]string `json:"routes"`
+}
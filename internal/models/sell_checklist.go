@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// ChecklistItem is one of the details we ask a seller to share after a sell request
+type ChecklistItem string
+
+// Checklist items, in the order they are listed in SellRequestWhatAppMessage
+const (
+	ChecklistSize         ChecklistItem = "size"
+	ChecklistPropertyType ChecklistItem = "property_type"
+	ChecklistFacing       ChecklistItem = "facing"
+	ChecklistMapLocation  ChecklistItem = "map_location"
+	ChecklistImages       ChecklistItem = "images"
+	ChecklistUtaaraCopy   ChecklistItem = "utaara_copy"
+)
+
+// SellChecklistItems lists every checklist item in display order
+var SellChecklistItems = []ChecklistItem{
+	ChecklistSize, ChecklistPropertyType, ChecklistFacing,
+	ChecklistMapLocation, ChecklistImages, ChecklistUtaaraCopy,
+}
+
+// MinChecklistImages is how many property images complete the images item
+const MinChecklistImages = 2
+
+// SellChecklist represents a row of the sell_request_checklists table, tracking
+// which of the requested details a seller has sent us so far
+type SellChecklist struct {
+	ID             int64      `json:"id" db:"id"`
+	SellRequestID  int        `json:"sell_request_id" db:"sell_request_id"`
+	UserID         string     `json:"user_id" db:"user_id"`
+	Phone          string     `json:"phone" db:"phone"`
+	Locale         string     `json:"locale" db:"locale"`
+	Size           *string    `json:"size" db:"size"`
+	PropertyType   *string    `json:"property_type" db:"property_type"`
+	Facing         *string    `json:"facing" db:"facing"`
+	MapLocation    *string    `json:"map_location" db:"map_location"`
+	Images         []string   `json:"images" db:"images"`
+	UtaaraCopy     *string    `json:"utaara_copy" db:"utaara_copy"`
+	RemindersSent  int        `json:"reminders_sent" db:"reminders_sent"`
+	LastReminderAt *time.Time `json:"last_reminder_at" db:"last_reminder_at"`
+	CompletedAt    *time.Time `json:"completed_at" db:"completed_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Has reports whether the seller already sent the given item
+func (c SellChecklist) Has(item ChecklistItem) bool {
+	switch item {
+	case ChecklistSize:
+		return c.Size != nil
+	case ChecklistPropertyType:
+		return c.PropertyType != nil
+	case ChecklistFacing:
+		return c.Facing != nil
+	case ChecklistMapLocation:
+		return c.MapLocation != nil
+	case ChecklistImages:
+		return len(c.Images) >= MinChecklistImages
+	case ChecklistUtaaraCopy:
+		return c.UtaaraCopy != nil
+	default:
+		return false
+	}
+}
+
+// Missing returns the items the seller still has to send, in display order
+func (c SellChecklist) Missing() []ChecklistItem {
+	var missing []ChecklistItem
+	for _, item := range SellChecklistItems {
+		if !c.Has(item) {
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
@@ -20,18 +20,6 @@ func (e EventType) String() string {
 	return string(e)
 }
 
-// IsValid checks if the event type is valid
-func (e EventType) IsValid() bool {
-	switch e {
-	case CallPressed, WhatsAppPressed, PostRentalPropertyPressed,
-		ConstructionCallPressed, ConstructionWhatsAppPressed,
-		ConstructionBrochureDownloaded, CustomPropertySearchRequest:
-		return true
-	default:
-		return false
-	}
-}
-
 // RequiresPropertyID returns true if this event type requires a property ID
 func (e EventType) RequiresPropertyID() bool {
 	switch e {
@@ -46,13 +34,15 @@ func (e EventType) RequiresPropertyID() bool {
 func (e EventType) GetCategory() string {
 	switch e {
 	case CallPressed, WhatsAppPressed:
-		return "property_interaction"
+		return CategoryPropertyInteraction
 	case ConstructionCallPressed, ConstructionWhatsAppPressed, ConstructionBrochureDownloaded:
-		return "construction"
+		return CategoryConstruction
 	case PostRentalPropertyPressed:
-		return "rental"
+		return CategoryRental
 	case CustomPropertySearchRequest:
-		return "search"
+		return CategorySearch
+	case AccountDeletionRequest:
+		return CategoryAccount
 	default:
 		return "unknown"
 	}
@@ -63,7 +53,7 @@ type SellRequest struct {
 	Notes             string `json:"notes"`
 	Price             string `json:"price"`
 	Address           string `json:"address"`
-	UserID            string `json:"user_id" binding:"required"`
+	UserID            string `json:"user_id"`
 	Attended          bool   `json:"attended"`
 	AssignTo          string `json:"assign_to"`
 	CreatedAt         string `json:"created_at"`
@@ -85,19 +75,30 @@ type LogsRequest struct {
 	LastCommunicated *string   `json:"last_communicated"`
 }
 
-// WebhookPayload represents the outer JSON structure for webhook requests
+// WebhookType is the database operation that triggered a webhook
+type WebhookType string
+
+// Webhook types sent by Supabase database webhooks
+const (
+	WebhookInsert WebhookType = "INSERT"
+	WebhookUpdate WebhookType = "UPDATE"
+	WebhookDelete WebhookType = "DELETE"
+)
+
+// WebhookPayload represents the outer JSON structure for webhook requests.
+// Record is empty for DELETE, OldRecord is only set for UPDATE and DELETE.
 type WebhookPayload struct {
-	Type      string      `json:"type"`
-	Table     string      `json:"table"`
-	Record    SellRequest `json:"record"`
-	Schema    string      `json:"schema"`
-	OldRecord interface{} `json:"old_record"`
+	Type      WebhookType  `json:"type"`
+	Table     string       `json:"table"`
+	Record    SellRequest  `json:"record"`
+	Schema    string       `json:"schema"`
+	OldRecord *SellRequest `json:"old_record"`
 }
 
 type LogsWebhookPayload struct {
-	Type      string      `json:"type"`
-	Table     string      `json:"table"`
-	Record    LogsRequest `json:"record"`
-	Schema    string      `json:"schema"`
-	OldRecord interface{} `json:"old_record"`
+	Type      WebhookType  `json:"type"`
+	Table     string       `json:"table"`
+	Record    LogsRequest  `json:"record"`
+	Schema    string       `json:"schema"`
+	OldRecord *LogsRequest `json:"old_record"`
 }
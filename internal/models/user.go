@@ -17,3 +17,11 @@ type User struct {
 	Notes                  *string   `json:"notes" db:"notes"` // nullable
 	SendPushNotifications  bool      `json:"send_push_notifications" db:"send_push_notifications"`
 }
+
+// Locale returns the language the user prefers messages in, empty when they haven't picked one
+func (u User) Locale() string {
+	if u.PrefLang == nil {
+		return ""
+	}
+	return *u.PrefLang
+}
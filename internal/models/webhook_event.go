@@ -0,0 +1,59 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WebhookEvent represents a row of the webhook_events table, remembering a processed
+// webhook and its response so that retries of the same event can be answered without
+// running the handler again
+type WebhookEvent struct {
+	ID              int64           `json:"id" db:"id"`
+	DedupKey        string          `json:"dedup_key" db:"dedup_key"`
+	EventTable      string          `json:"table" db:"event_table"`
+	EventType       string          `json:"type" db:"event_type"`
+	RecordID        int64           `json:"record_id" db:"record_id"`
+	StatusCode      *int            `json:"status_code" db:"status_code"`
+	Response        json.RawMessage `json:"response" db:"response"`
+	Duplicates      int             `json:"duplicates" db:"duplicates"`
+	ReceivedAt      time.Time       `json:"received_at" db:"received_at"`
+	LastDuplicateAt *time.Time      `json:"last_duplicate_at" db:"last_duplicate_at"`
+}
+
+// WebhookEnvelope holds the fields shared by every Supabase database webhook payload
+type WebhookEnvelope struct {
+	Type      string          `json:"type"`
+	Table     string          `json:"table"`
+	Record    json.RawMessage `json:"record"`
+	OldRecord json.RawMessage `json:"old_record"`
+}
+
+// DedupKey identifies the event by table, record ID and event type. An UPDATE also
+// includes a hash of the new record, so later changes to the same row are not mistaken
+// for retries. It returns false when the payload has no record ID to key on.
+func (e WebhookEnvelope) DedupKey() (string, int64, bool) {
+	// DELETE events only carry the old record
+	record := e.Record
+	if len(record) == 0 || string(record) == "null" {
+		record = e.OldRecord
+	}
+
+	var row struct {
+		ID *int64 `json:"id"`
+	}
+	if err := json.Unmarshal(record, &row); err != nil || row.ID == nil || e.Table == "" || e.Type == "" {
+		return "", 0, false
+	}
+
+	key := fmt.Sprintf("%s:%d:%s", e.Table, *row.ID, strings.ToUpper(e.Type))
+	if strings.EqualFold(e.Type, "UPDATE") {
+		sum := sha256.Sum256(record)
+		key += ":" + hex.EncodeToString(sum[:8])
+	}
+	return key, *row.ID, true
+}
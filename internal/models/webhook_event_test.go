@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestWebhookEnvelopeDedupKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		envelope WebhookEnvelope
+		want     string
+		ok       bool
+	}{
+		{
+			name:     "insert",
+			envelope: WebhookEnvelope{Type: "INSERT", Table: "sell_request", Record: []byte(`{"id":7,"price":"10L"}`)},
+			want:     "sell_request:7:INSERT",
+			ok:       true,
+		},
+		{
+			name:     "delete uses the old record",
+			envelope: WebhookEnvelope{Type: "DELETE", Table: "sell_request", Record: []byte(`null`), OldRecord: []byte(`{"id":7}`)},
+			want:     "sell_request:7:DELETE",
+			ok:       true,
+		},
+		{
+			name:     "missing id",
+			envelope: WebhookEnvelope{Type: "INSERT", Table: "sell_request", Record: []byte(`{"user_id":"u1"}`)},
+		},
+		{
+			name:     "missing table",
+			envelope: WebhookEnvelope{Type: "INSERT", Record: []byte(`{"id":7}`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, ok := tt.envelope.DedupKey()
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("DedupKey() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestWebhookEnvelopeDedupKeySeparatesUpdates(t *testing.T) {
+	first := WebhookEnvelope{Type: "UPDATE", Table: "sell_request", Record: []byte(`{"id":7,"attended":true}`)}
+	second := WebhookEnvelope{Type: "UPDATE", Table: "sell_request", Record: []byte(`{"id":7,"attended":false}`)}
+
+	firstKey, _, _ := first.DedupKey()
+	retryKey, _, _ := first.DedupKey()
+	secondKey, _, _ := second.DedupKey()
+	if firstKey != retryKey {
+		t.Error("a retried update must have the same key")
+	}
+	if firstKey == secondKey {
+		t.Error("different updates of the same row must have different keys")
+	}
+}
@@ -0,0 +1,204 @@
+// Package phone turns phone numbers as users type them into E.164, the form
+// WhatsApp addresses accounts by.
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultCountry is assumed for numbers without a country code when no other country is configured
+const DefaultCountry = "IN"
+
+// ErrInvalidNumber is matched by every error returned for a number that can't be dialled
+var ErrInvalidNumber = errors.New("invalid phone number")
+
+// Country describes the numbering plan of a country
+type Country struct {
+	// DialCode is the international calling code without the plus
+	DialCode string
+	// NationalLengths are the valid lengths of a national number without trunk prefix
+	NationalLengths []int
+	// TrunkPrefix is dialled before national numbers inside the country, e.g. "0"
+	TrunkPrefix string
+	// Leading restricts the first digit of national numbers, empty allows any
+	Leading string
+}
+
+// countries are the numbering plans we validate against, keyed by ISO 3166 code.
+// India only accepts mobile numbers as landlines can't use WhatsApp.
+var countries = map[string]Country{
+	"IN": {DialCode: "91", NationalLengths: []int{10}, TrunkPrefix: "0", Leading: "6789"},
+	"AE": {DialCode: "971", NationalLengths: []int{9}, TrunkPrefix: "0"},
+	"SA": {DialCode: "966", NationalLengths: []int{9}, TrunkPrefix: "0"},
+	"QA": {DialCode: "974", NationalLengths: []int{8}},
+	"OM": {DialCode: "968", NationalLengths: []int{8}},
+	"KW": {DialCode: "965", NationalLengths: []int{8}},
+	"BH": {DialCode: "973", NationalLengths: []int{8}},
+	"SG": {DialCode: "65", NationalLengths: []int{8}},
+	"GB": {DialCode: "44", NationalLengths: []int{10}, TrunkPrefix: "0"},
+	"US": {DialCode: "1", NationalLengths: []int{10}, TrunkPrefix: "1"},
+}
+
+// E.164 limits the full number, country code included, to 15 digits
+const (
+	minDigits = 8
+	maxDigits = 15
+)
+
+// Number is a normalized phone number
+type Number struct {
+	// Input is the number as it was given
+	Input string
+	// E164 is the number in international format, e.g. "+919035577330"
+	E164 string
+	// Changes describes each correction made to the input, empty when it was already E.164
+	Changes []string
+}
+
+// Digits returns the number without the plus, as used in WhatsApp JIDs
+func (n Number) Digits() string {
+	return strings.TrimPrefix(n.E164, "+")
+}
+
+// Changed reports whether the input had to be corrected
+func (n Number) Changed() bool {
+	return len(n.Changes) > 0
+}
+
+// SupportedCountry reports whether a default country code is known
+func SupportedCountry(code string) bool {
+	_, ok := countries[strings.ToUpper(code)]
+	return ok
+}
+
+// SupportedCountries returns the known country codes in alphabetical order
+func SupportedCountries() []string {
+	codes := make([]string, 0, len(countries))
+	for code := range countries {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Normalize converts a phone number to E.164. Numbers without a country code are
+// read as national numbers of defaultCountry, DefaultCountry when empty. Formatting
+// characters, a leading 00 and trunk prefixes are removed.
+func Normalize(raw, defaultCountry string) (Number, error) {
+	if defaultCountry == "" {
+		defaultCountry = DefaultCountry
+	}
+	country, ok := countries[strings.ToUpper(defaultCountry)]
+	if !ok {
+		return Number{}, fmt.Errorf("unsupported default country %q", defaultCountry)
+	}
+
+	number := Number{Input: raw}
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return Number{}, fmt.Errorf("%w: empty", ErrInvalidNumber)
+	}
+
+	international := strings.HasPrefix(trimmed, "+")
+	var digits strings.Builder
+	for i, r := range trimmed {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case strings.ContainsRune(" -.()/", r):
+		default:
+			return Number{}, fmt.Errorf("%w: %q contains %q", ErrInvalidNumber, raw, r)
+		}
+	}
+	value := digits.String()
+	if value != strings.TrimPrefix(trimmed, "+") {
+		number.Changes = append(number.Changes, "removed formatting characters")
+	}
+
+	if !international && strings.HasPrefix(value, "00") {
+		value = value[2:]
+		international = true
+		number.Changes = append(number.Changes, "replaced international prefix 00 with +")
+	}
+
+	if !international {
+		switch {
+		case country.TrunkPrefix != "" && hasLength(country, len(value)-len(country.TrunkPrefix)) && strings.HasPrefix(value, country.TrunkPrefix):
+			value = country.DialCode + value[len(country.TrunkPrefix):]
+			number.Changes = append(number.Changes, fmt.Sprintf("removed trunk prefix %s", country.TrunkPrefix), fmt.Sprintf("added country code +%s", country.DialCode))
+		case hasLength(country, len(value)):
+			value = country.DialCode + value
+			number.Changes = append(number.Changes, fmt.Sprintf("added country code +%s", country.DialCode))
+		default:
+			// Stored without the plus but with a country code, e.g. 919035577330
+			number.Changes = append(number.Changes, "added +")
+		}
+	}
+
+	if len(value) < minDigits || len(value) > maxDigits {
+		return Number{}, fmt.Errorf("%w: %q has %d digits", ErrInvalidNumber, raw, len(value))
+	}
+	if err := validate(value); err != nil {
+		return Number{}, fmt.Errorf("%w: %q %v", ErrInvalidNumber, raw, err)
+	}
+
+	number.E164 = "+" + value
+	return number, nil
+}
+
+// StoredForms returns the digits a number, given in international digits, may have
+// been stored as once formatting is stripped: with the country code, with a leading
+// 00 and, for numbers of defaultCountry, as a national number with or without trunk
+// prefix. Normalize maps each of them back to the number.
+func StoredForms(digits, defaultCountry string) []string {
+	if defaultCountry == "" {
+		defaultCountry = DefaultCountry
+	}
+	forms := []string{digits, "00" + digits}
+
+	country, ok := countries[strings.ToUpper(defaultCountry)]
+	if !ok || !strings.HasPrefix(digits, country.DialCode) {
+		return forms
+	}
+	national := digits[len(country.DialCode):]
+	if !hasLength(country, len(national)) {
+		return forms
+	}
+	forms = append(forms, national)
+	if country.TrunkPrefix != "" && country.TrunkPrefix+national != digits {
+		forms = append(forms, country.TrunkPrefix+national)
+	}
+	return forms
+}
+
+// validate checks the national part of numbers from a country we know the plan of.
+// None of the known dial codes is a prefix of another, so at most one plan applies.
+func validate(value string) error {
+	for _, country := range countries {
+		if !strings.HasPrefix(value, country.DialCode) {
+			continue
+		}
+		national := value[len(country.DialCode):]
+		if !hasLength(country, len(national)) {
+			return fmt.Errorf("is not a valid +%s number", country.DialCode)
+		}
+		if country.Leading != "" && !strings.ContainsRune(country.Leading, rune(national[0])) {
+			return fmt.Errorf("is not a mobile number")
+		}
+		return nil
+	}
+	return nil
+}
+
+func hasLength(country Country, length int) bool {
+	for _, valid := range country.NationalLengths {
+		if valid == length {
+			return true
+		}
+	}
+	return false
+}
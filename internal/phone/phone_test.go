@@ -0,0 +1,86 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		changed bool
+	}{
+		{"+919035577330", "+919035577330", false},
+		{"+91 90355 77330", "+919035577330", true},
+		{"09035577330", "+919035577330", true},
+		{"9035577330", "+919035577330", true},
+		{"919035577330", "+919035577330", true},
+		{"0091-90355-77330", "+919035577330", true},
+		{"+971 50 123 4567", "+971501234567", true},
+		{"+44 7700-900123", "+447700900123", true},
+	}
+	for _, tt := range tests {
+		number, err := Normalize(tt.input, "IN")
+		if err != nil {
+			t.Errorf("Normalize(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if number.E164 != tt.want || number.Changed() != tt.changed {
+			t.Errorf("Normalize(%q) = %s with changes %v, want %s", tt.input, number.E164, number.Changes, tt.want)
+		}
+	}
+}
+
+func TestNormalizeReportsChanges(t *testing.T) {
+	number, err := Normalize("090355 77330", "in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"removed formatting characters", "removed trunk prefix 0", "added country code +91"}
+	if len(number.Changes) != len(want) {
+		t.Fatalf("unexpected changes %v", number.Changes)
+	}
+	for i := range want {
+		if number.Changes[i] != want[i] {
+			t.Errorf("change %d = %q, want %q", i, number.Changes[i], want[i])
+		}
+	}
+	if number.Digits() != "919035577330" {
+		t.Errorf("unexpected digits %q", number.Digits())
+	}
+}
+
+func TestStoredForms(t *testing.T) {
+	tests := []struct {
+		digits, country string
+		want            []string
+	}{
+		{"919035577330", "IN", []string{"919035577330", "00919035577330", "9035577330", "09035577330"}},
+		{"12025550123", "US", []string{"12025550123", "0012025550123", "2025550123"}},
+		{"971501234567", "IN", []string{"971501234567", "00971501234567"}},
+	}
+	for _, tt := range tests {
+		got := StoredForms(tt.digits, tt.country)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("StoredForms(%s, %s) = %v, want %v", tt.digits, tt.country, got, tt.want)
+		}
+		for _, form := range got {
+			if number, err := Normalize(form, tt.country); err != nil || number.Digits() != tt.digits {
+				t.Errorf("Normalize(%q) = %s, %v, want %s", form, number.E164, err, tt.digits)
+			}
+		}
+	}
+}
+
+func TestNormalizeRejectsImpossibleNumbers(t *testing.T) {
+	for _, input := range []string{"", "12345", "+91 1035577330", "+91 903557733", "9035577330 ext 2", "+1234567890123456"} {
+		if _, err := Normalize(input, "IN"); !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("Normalize(%q) = %v, want ErrInvalidNumber", input, err)
+		}
+	}
+	if _, err := Normalize("9035577330", "XX"); err == nil || errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("expected an unsupported country error, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const deletionColumns = `id, user_id, phone, locale, status, requested_at, scheduled_for,
+	cancelled_at, completed_at, last_error, claimed_at`
+
+// PostgresAccountDeletionRepository stores deletion requests in the account_deletions table
+type PostgresAccountDeletionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresAccountDeletionRepository creates an account deletion repository backed by Postgres
+func NewPostgresAccountDeletionRepository(db *pgxpool.Pool) *PostgresAccountDeletionRepository {
+	return &PostgresAccountDeletionRepository{db: db}
+}
+
+// Schedule stores a pending deletion, or returns the pending one of the same user
+func (r *PostgresAccountDeletionRepository) Schedule(ctx context.Context, deletion models.AccountDeletion) (models.AccountDeletion, error) {
+	rows, err := r.db.Query(ctx,
+		`INSERT INTO account_deletions (user_id, phone, locale, scheduled_for)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (user_id) WHERE status = 'pending' DO UPDATE SET user_id = EXCLUDED.user_id
+		 RETURNING `+deletionColumns,
+		deletion.UserID, deletion.Phone, deletion.Locale, deletion.ScheduledFor)
+	if err != nil {
+		return models.AccountDeletion{}, fmt.Errorf("failed to schedule account deletion: %v", err)
+	}
+
+	scheduled, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.AccountDeletion])
+	if err != nil {
+		return models.AccountDeletion{}, fmt.Errorf("failed to schedule account deletion: %v", err)
+	}
+	return scheduled, nil
+}
+
+// GetPendingByPhone returns the pending deletion for a phone
+func (r *PostgresAccountDeletionRepository) GetPendingByPhone(ctx context.Context, phone string) (models.AccountDeletion, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+deletionColumns+` FROM account_deletions
+		 WHERE phone = $1 AND status = 'pending'
+		 ORDER BY requested_at DESC LIMIT 1`, phone)
+	if err != nil {
+		return models.AccountDeletion{}, fmt.Errorf("failed to fetch account deletion: %v", err)
+	}
+
+	deletion, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.AccountDeletion])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AccountDeletion{}, fmt.Errorf("%w: phone %s", ErrDeletionNotFound, phone)
+		}
+		return models.AccountDeletion{}, fmt.Errorf("failed to fetch account deletion: %v", err)
+	}
+	return deletion, nil
+}
+
+// ListDue returns pending deletions scheduled at or before the given time, and
+// deletions claimed before staleBefore that never finished
+func (r *PostgresAccountDeletionRepository) ListDue(ctx context.Context, at, staleBefore time.Time) ([]models.AccountDeletion, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+deletionColumns+` FROM account_deletions
+		 WHERE (status = 'pending' AND scheduled_for <= $1)
+			OR (status = 'deleting' AND (claimed_at IS NULL OR claimed_at < $2))
+		 ORDER BY scheduled_for`, at, staleBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account deletions: %v", err)
+	}
+
+	deletions, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.AccountDeletion])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account deletions: %v", err)
+	}
+	return deletions, nil
+}
+
+// Claim moves a pending deletion, or one whose claim went stale, to deleting
+func (r *PostgresAccountDeletionRepository) Claim(ctx context.Context, id int64, staleBefore time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE account_deletions SET status = 'deleting', claimed_at = now()
+		 WHERE id = $1 AND (status = 'pending'
+			OR (status = 'deleting' AND (claimed_at IS NULL OR claimed_at < $2)))`,
+		id, staleBefore)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim account deletion: %v", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Finish moves a pending deletion to cancelled, or a claimed one to completed or failed
+func (r *PostgresAccountDeletionRepository) Finish(ctx context.Context, id int64, status models.DeletionStatus, at time.Time, cause error) (bool, error) {
+	var lastError *string
+	if cause != nil {
+		message := cause.Error()
+		lastError = &message
+	}
+
+	tag, err := r.db.Exec(ctx,
+		`UPDATE account_deletions SET
+			status = $2,
+			cancelled_at = CASE WHEN $2 = 'cancelled' THEN $3::timestamptz END,
+			completed_at = CASE WHEN $2 = 'completed' THEN $3::timestamptz END,
+			last_error = $4
+		 WHERE id = $1 AND status = CASE WHEN $2 = 'cancelled' THEN 'pending' ELSE 'deleting' END`,
+		id, status, at, lastError)
+	if err != nil {
+		return false, fmt.Errorf("failed to update account deletion: %v", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresConstructionLeadRepository stores leads in the construction_leads table
+type PostgresConstructionLeadRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresConstructionLeadRepository creates a construction lead repository backed by Postgres
+func NewPostgresConstructionLeadRepository(db *pgxpool.Pool) *PostgresConstructionLeadRepository {
+	return &PostgresConstructionLeadRepository{db: db}
+}
+
+// Record stores a new lead
+func (r *PostgresConstructionLeadRepository) Record(ctx context.Context, lead models.ConstructionLead) (models.ConstructionLead, error) {
+	rows, err := r.db.Query(ctx,
+		`INSERT INTO construction_leads (user_id, name, phone, source)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, user_id, name, phone, source, created_at`,
+		lead.UserID, lead.Name, lead.Phone, lead.Source)
+	if err != nil {
+		return models.ConstructionLead{}, fmt.Errorf("failed to record construction lead: %v", err)
+	}
+
+	recorded, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.ConstructionLead])
+	if err != nil {
+		return models.ConstructionLead{}, fmt.Errorf("failed to record construction lead: %v", err)
+	}
+	return recorded, nil
+}
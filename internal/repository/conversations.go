@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresConversationRepository stores inbound messages in the conversation_log table
+type PostgresConversationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresConversationRepository creates a conversation repository backed by Postgres
+func NewPostgresConversationRepository(db *pgxpool.Pool) *PostgresConversationRepository {
+	return &PostgresConversationRepository{db: db}
+}
+
+// Append stores an inbound message unless it was already stored and didn't fail
+func (r *PostgresConversationRepository) Append(ctx context.Context, msg models.ConversationMessage) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		`INSERT INTO conversation_log
+			(message_id, chat_jid, sender_phone, user_id, chat_type, content_type, body, received_at, status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'received')
+		 ON CONFLICT (message_id) DO UPDATE SET status = 'received', last_error = NULL
+		 WHERE conversation_log.status = 'failed'`,
+		msg.MessageID, msg.ChatJID, msg.SenderPhone, msg.UserID,
+		msg.ChatType, msg.ContentType, msg.Body, msg.ReceivedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to store conversation message: %v", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Finish records the outcome of the handlers of a message
+func (r *PostgresConversationRepository) Finish(ctx context.Context, messageID string, status models.ConversationStatus, cause error) error {
+	var lastError *string
+	if cause != nil {
+		message := cause.Error()
+		lastError = &message
+	}
+	_, err := r.db.Exec(ctx,
+		`UPDATE conversation_log SET status = $2, last_error = $3 WHERE message_id = $1`,
+		messageID, status, lastError,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation message: %v", err)
+	}
+	return nil
+}
+
+// ListBySender returns the most recent messages from a phone number
+func (r *PostgresConversationRepository) ListBySender(ctx context.Context, phone string, limit int) ([]models.ConversationMessage, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT id, message_id, chat_jid, sender_phone, user_id, chat_type, content_type, body, received_at,
+			status, last_error
+		 FROM conversation_log WHERE sender_phone = $1
+		 ORDER BY received_at DESC LIMIT $2`,
+		phone, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation: %v", err)
+	}
+
+	messages, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.ConversationMessage])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation: %v", err)
+	}
+	return messages, nil
+}
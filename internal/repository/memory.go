@@ -0,0 +1,784 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	phonenumbers "github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/phone"
+)
+
+// MemoryUserRepository is an in-memory UserRepository for tests
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+}
+
+// NewMemoryUserRepository creates an in-memory user repository seeded with the given users
+func NewMemoryUserRepository(users ...models.User) *MemoryUserRepository {
+	r := &MemoryUserRepository{users: make(map[string]models.User)}
+	for _, user := range users {
+		r.Add(user)
+	}
+	return r
+}
+
+// Add stores or replaces a user
+func (r *MemoryUserRepository) Add(user models.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = user
+}
+
+// GetByID returns the user with the given ID
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, fmt.Errorf("%w: id %s", ErrUserNotFound, id)
+	}
+	return user, nil
+}
+
+// GetByPhone returns the user whose phone number, once normalized, is the given
+// international digits. Numbers without a country code are read as Indian numbers.
+func (r *MemoryUserRepository) GetByPhone(ctx context.Context, phone string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if number, err := phonenumbers.Normalize(user.Phone, phonenumbers.DefaultCountry); err == nil && number.Digits() == phone {
+			return user, nil
+		}
+	}
+	return models.User{}, fmt.Errorf("%w: phone %s", ErrUserNotFound, phone)
+}
+
+// Delete removes the user with the given ID
+func (r *MemoryUserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return fmt.Errorf("%w: id %s", ErrUserNotFound, id)
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// MemoryPropertyRepository is an in-memory PropertyRepository for tests
+type MemoryPropertyRepository struct {
+	mu         sync.RWMutex
+	properties map[int64]models.Property
+	images     map[int64][]string
+	banners    map[int64]string
+	addresses  map[int64]models.Address
+}
+
+// NewMemoryPropertyRepository creates an in-memory property repository seeded with the given properties
+func NewMemoryPropertyRepository(properties ...models.Property) *MemoryPropertyRepository {
+	r := &MemoryPropertyRepository{
+		properties: make(map[int64]models.Property),
+		images:     make(map[int64][]string),
+		banners:    make(map[int64]string),
+		addresses:  make(map[int64]models.Address),
+	}
+	for _, property := range properties {
+		r.Add(property)
+	}
+	return r
+}
+
+// Add stores or replaces a property
+func (r *MemoryPropertyRepository) Add(property models.Property) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.properties[property.ID] = property
+}
+
+// GetByID returns the property with the given ID
+func (r *MemoryPropertyRepository) GetByID(ctx context.Context, id int) (models.Property, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	property, ok := r.properties[int64(id)]
+	if !ok {
+		return models.Property{}, fmt.Errorf("%w: id %d", ErrPropertyNotFound, id)
+	}
+	return property, nil
+}
+
+// SetImages replaces the photo URLs of a property
+func (r *MemoryPropertyRepository) SetImages(propertyID int64, urls ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images[propertyID] = urls
+}
+
+// SetBanner stores or replaces the image URL of a banner
+func (r *MemoryPropertyRepository) SetBanner(bannerID int64, url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.banners[bannerID] = url
+}
+
+// ListImageURLs returns up to limit photo URLs of a property
+func (r *MemoryPropertyRepository) ListImageURLs(ctx context.Context, propertyID int64, limit int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	urls := r.images[propertyID]
+	if len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return append([]string(nil), urls...), nil
+}
+
+// GetBannerURL returns the image URL of a banner
+func (r *MemoryPropertyRepository) GetBannerURL(ctx context.Context, bannerID int64) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	url, ok := r.banners[bannerID]
+	if !ok {
+		return "", fmt.Errorf("%w: id %d", ErrBannerNotFound, bannerID)
+	}
+	return url, nil
+}
+
+// AddAddress stores or replaces an address
+func (r *MemoryPropertyRepository) AddAddress(address models.Address) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addresses[address.ID] = address
+}
+
+// GetAddress returns the address with the given ID
+func (r *MemoryPropertyRepository) GetAddress(ctx context.Context, addressID int64) (models.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	address, ok := r.addresses[addressID]
+	if !ok {
+		return models.Address{}, fmt.Errorf("%w: id %d", ErrAddressNotFound, addressID)
+	}
+	return address, nil
+}
+
+// MemoryMessageRepository is an in-memory MessageRepository for tests
+type MemoryMessageRepository struct {
+	mu       sync.RWMutex
+	messages map[string]models.TrackedMessage
+}
+
+// NewMemoryMessageRepository creates an empty in-memory message repository
+func NewMemoryMessageRepository() *MemoryMessageRepository {
+	return &MemoryMessageRepository{messages: make(map[string]models.TrackedMessage)}
+}
+
+// Record stores a freshly sent message
+func (r *MemoryMessageRepository) Record(ctx context.Context, msg models.TrackedMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.messages[msg.MessageID]; exists {
+		return nil
+	}
+	msg.Status = models.MessageSent
+	msg.UpdatedAt = msg.SentAt
+	r.messages[msg.MessageID] = msg
+	return nil
+}
+
+// UpdateStatus moves the given messages forward to status
+func (r *MemoryMessageRepository) UpdateStatus(ctx context.Context, messageIDs []string, status models.MessageStatus, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range messageIDs {
+		msg, ok := r.messages[id]
+		if !ok || !status.Supersedes(msg.Status) {
+			continue
+		}
+		msg.Status = status
+		switch status {
+		case models.MessageDelivered:
+			if msg.DeliveredAt == nil {
+				msg.DeliveredAt = &at
+			}
+		case models.MessageRead:
+			if msg.DeliveredAt == nil {
+				msg.DeliveredAt = &at
+			}
+			if msg.ReadAt == nil {
+				msg.ReadAt = &at
+			}
+		case models.MessageFailed:
+			msg.FailedAt = &at
+		}
+		msg.UpdatedAt = at
+		r.messages[id] = msg
+	}
+	return nil
+}
+
+// GetByID returns the message with the given WhatsApp ID
+func (r *MemoryMessageRepository) GetByID(ctx context.Context, messageID string) (models.TrackedMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	msg, ok := r.messages[messageID]
+	if !ok {
+		return models.TrackedMessage{}, fmt.Errorf("%w: id %s", ErrMessageNotFound, messageID)
+	}
+	return msg, nil
+}
+
+// ListByRecipient returns the most recent messages sent to a recipient
+func (r *MemoryMessageRepository) ListByRecipient(ctx context.Context, recipient string, limit int) ([]models.TrackedMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var messages []models.TrackedMessage
+	for _, msg := range r.messages {
+		if msg.Recipient == recipient {
+			messages = append(messages, msg)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].SentAt.After(messages[j].SentAt)
+	})
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+// MemoryConversationRepository is an in-memory ConversationRepository for tests
+type MemoryConversationRepository struct {
+	mu       sync.RWMutex
+	messages []models.ConversationMessage
+}
+
+// NewMemoryConversationRepository creates an empty in-memory conversation log
+func NewMemoryConversationRepository() *MemoryConversationRepository {
+	return &MemoryConversationRepository{}
+}
+
+// Append stores an inbound message unless it was already stored and didn't fail
+func (r *MemoryConversationRepository) Append(ctx context.Context, msg models.ConversationMessage) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.messages {
+		if existing.MessageID != msg.MessageID {
+			continue
+		}
+		if existing.Status != models.ConversationFailed {
+			return false, nil
+		}
+		r.messages[i].Status = models.ConversationReceived
+		r.messages[i].LastError = nil
+		return true, nil
+	}
+	msg.ID = int64(len(r.messages) + 1)
+	msg.Status = models.ConversationReceived
+	r.messages = append(r.messages, msg)
+	return true, nil
+}
+
+// Finish records the outcome of the handlers of a message
+func (r *MemoryConversationRepository) Finish(ctx context.Context, messageID string, status models.ConversationStatus, cause error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.messages {
+		if r.messages[i].MessageID != messageID {
+			continue
+		}
+		r.messages[i].Status = status
+		r.messages[i].LastError = nil
+		if cause != nil {
+			message := cause.Error()
+			r.messages[i].LastError = &message
+		}
+	}
+	return nil
+}
+
+// ListBySender returns the most recent messages from a phone number
+func (r *MemoryConversationRepository) ListBySender(ctx context.Context, phone string, limit int) ([]models.ConversationMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var messages []models.ConversationMessage
+	for i := len(r.messages) - 1; i >= 0 && len(messages) < limit; i-- {
+		if r.messages[i].SenderPhone == phone {
+			messages = append(messages, r.messages[i])
+		}
+	}
+	return messages, nil
+}
+
+// MemoryRentalIntakeRepository is an in-memory RentalIntakeRepository for tests
+type MemoryRentalIntakeRepository struct {
+	mu      sync.Mutex
+	intakes map[int64]models.RentalIntake
+	drafts  map[int64]models.RentalIntake
+	nextID  int64
+}
+
+// NewMemoryRentalIntakeRepository creates an empty in-memory intake repository
+func NewMemoryRentalIntakeRepository() *MemoryRentalIntakeRepository {
+	return &MemoryRentalIntakeRepository{
+		intakes: make(map[int64]models.RentalIntake),
+		drafts:  make(map[int64]models.RentalIntake),
+	}
+}
+
+// Start begins a new intake, expiring any intake still active for the same phone
+func (r *MemoryRentalIntakeRepository) Start(ctx context.Context, intake models.RentalIntake) (models.RentalIntake, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, existing := range r.intakes {
+		if existing.Phone == intake.Phone && existing.Status == models.IntakeActive {
+			existing.Status = models.IntakeExpired
+			r.intakes[id] = existing
+		}
+	}
+	r.nextID++
+	now := time.Now()
+	intake.ID = r.nextID
+	intake.Status = models.IntakeActive
+	intake.CreatedAt, intake.UpdatedAt = now, now
+	r.intakes[intake.ID] = intake
+	return intake, nil
+}
+
+// GetActiveByPhone returns the active intake for a phone
+func (r *MemoryRentalIntakeRepository) GetActiveByPhone(ctx context.Context, phone string) (models.RentalIntake, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, intake := range r.intakes {
+		if intake.Phone == phone && intake.Status == models.IntakeActive {
+			return intake, nil
+		}
+	}
+	return models.RentalIntake{}, fmt.Errorf("%w: phone %s", ErrIntakeNotFound, phone)
+}
+
+// Save stores the step and collected data of an active intake
+func (r *MemoryRentalIntakeRepository) Save(ctx context.Context, intake models.RentalIntake) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.intakes[intake.ID]; !ok || existing.Status != models.IntakeActive {
+		return nil
+	}
+	intake.UpdatedAt = time.Now()
+	r.intakes[intake.ID] = intake
+	return nil
+}
+
+// Complete turns the intake into a draft listing and closes the intake
+func (r *MemoryRentalIntakeRepository) Complete(ctx context.Context, intake models.RentalIntake) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	draftID := int64(len(r.drafts) + 1)
+	intake.Status = models.IntakeCompleted
+	intake.DraftID = &draftID
+	r.intakes[intake.ID] = intake
+	r.drafts[draftID] = intake
+	return draftID, nil
+}
+
+// ExpireInactive expires active intakes not updated since the given time
+func (r *MemoryRentalIntakeRepository) ExpireInactive(ctx context.Context, before time.Time) ([]models.RentalIntake, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var expired []models.RentalIntake
+	for id, intake := range r.intakes {
+		if intake.Status == models.IntakeActive && intake.UpdatedAt.Before(before) {
+			intake.Status = models.IntakeExpired
+			r.intakes[id] = intake
+			expired = append(expired, intake)
+		}
+	}
+	return expired, nil
+}
+
+// Draft returns the intake data a draft was created from
+func (r *MemoryRentalIntakeRepository) Draft(id int64) (models.RentalIntake, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	draft, ok := r.drafts[id]
+	return draft, ok
+}
+
+// Touch overrides the last update time of an intake, tests use it to simulate inactivity
+func (r *MemoryRentalIntakeRepository) Touch(id int64, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if intake, ok := r.intakes[id]; ok {
+		intake.UpdatedAt = at
+		r.intakes[id] = intake
+	}
+}
+
+// MemorySellChecklistRepository is an in-memory SellChecklistRepository for tests
+type MemorySellChecklistRepository struct {
+	mu         sync.Mutex
+	checklists map[int64]models.SellChecklist
+}
+
+// NewMemorySellChecklistRepository creates an empty in-memory checklist repository
+func NewMemorySellChecklistRepository() *MemorySellChecklistRepository {
+	return &MemorySellChecklistRepository{checklists: make(map[int64]models.SellChecklist)}
+}
+
+// Create stores a new checklist, or returns the existing one for the same sell request
+func (r *MemorySellChecklistRepository) Create(ctx context.Context, checklist models.SellChecklist) (models.SellChecklist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.checklists {
+		if existing.SellRequestID == checklist.SellRequestID {
+			return existing, nil
+		}
+	}
+	now := time.Now()
+	checklist.ID = int64(len(r.checklists) + 1)
+	checklist.CreatedAt, checklist.UpdatedAt = now, now
+	r.checklists[checklist.ID] = checklist
+	return checklist, nil
+}
+
+// GetOpenByPhone returns the latest incomplete checklist for a phone
+func (r *MemorySellChecklistRepository) GetOpenByPhone(ctx context.Context, phone string) (models.SellChecklist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *models.SellChecklist
+	for _, checklist := range r.checklists {
+		if checklist.Phone == phone && checklist.CompletedAt == nil && (latest == nil || checklist.ID > latest.ID) {
+			latest = &checklist
+		}
+	}
+	if latest == nil {
+		return models.SellChecklist{}, fmt.Errorf("%w: phone %s", ErrChecklistNotFound, phone)
+	}
+	return *latest, nil
+}
+
+// Save stores the collected details and completion time of a checklist
+func (r *MemorySellChecklistRepository) Save(ctx context.Context, checklist models.SellChecklist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	checklist.UpdatedAt = time.Now()
+	r.checklists[checklist.ID] = checklist
+	return nil
+}
+
+// ListDueReminders returns incomplete checklists that are due a reminder
+func (r *MemorySellChecklistRepository) ListDueReminders(ctx context.Context, before time.Time, maxReminders int) ([]models.SellChecklist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []models.SellChecklist
+	for _, checklist := range r.checklists {
+		lastTouched := checklist.UpdatedAt
+		if checklist.LastReminderAt != nil && checklist.LastReminderAt.After(lastTouched) {
+			lastTouched = *checklist.LastReminderAt
+		}
+		if checklist.CompletedAt == nil && checklist.RemindersSent < maxReminders && lastTouched.Before(before) {
+			due = append(due, checklist)
+		}
+	}
+	return due, nil
+}
+
+// MarkReminded records that a reminder was sent for a checklist
+func (r *MemorySellChecklistRepository) MarkReminded(ctx context.Context, id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if checklist, ok := r.checklists[id]; ok {
+		checklist.RemindersSent++
+		checklist.LastReminderAt = &at
+		r.checklists[id] = checklist
+	}
+	return nil
+}
+
+// Get returns a checklist by ID
+func (r *MemorySellChecklistRepository) Get(id int64) (models.SellChecklist, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	checklist, ok := r.checklists[id]
+	return checklist, ok
+}
+
+// Touch overrides the last update and reminder times of a checklist, tests use it to simulate inactivity
+func (r *MemorySellChecklistRepository) Touch(id int64, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if checklist, ok := r.checklists[id]; ok {
+		checklist.CreatedAt, checklist.UpdatedAt = at, at
+		if checklist.LastReminderAt != nil {
+			checklist.LastReminderAt = &at
+		}
+		r.checklists[id] = checklist
+	}
+}
+
+// MemoryWebhookEventRepository is an in-memory WebhookEventRepository for tests
+type MemoryWebhookEventRepository struct {
+	mu     sync.Mutex
+	nextID int64
+	events map[string]models.WebhookEvent
+}
+
+// NewMemoryWebhookEventRepository creates an empty in-memory webhook event repository
+func NewMemoryWebhookEventRepository() *MemoryWebhookEventRepository {
+	return &MemoryWebhookEventRepository{events: make(map[string]models.WebhookEvent)}
+}
+
+// Claim records an event, or counts a duplicate of one received after windowStart
+func (r *MemoryWebhookEventRepository) Claim(ctx context.Context, event models.WebhookEvent, windowStart time.Time) (models.WebhookEvent, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	existing, ok := r.events[event.DedupKey]
+	if ok && !existing.ReceivedAt.Before(windowStart) {
+		existing.Duplicates++
+		existing.LastDuplicateAt = &now
+		r.events[event.DedupKey] = existing
+		return existing, false, nil
+	}
+
+	if ok {
+		event.ID = existing.ID
+	} else {
+		r.nextID++
+		event.ID = r.nextID
+	}
+	event.StatusCode, event.Response, event.Duplicates, event.LastDuplicateAt = nil, nil, 0, nil
+	event.ReceivedAt = now
+	r.events[event.DedupKey] = event
+	return event, true, nil
+}
+
+// Complete stores the response the event was answered with
+func (r *MemoryWebhookEventRepository) Complete(ctx context.Context, dedupKey string, statusCode int, response []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event, ok := r.events[dedupKey]; ok {
+		event.StatusCode, event.Response = &statusCode, response
+		r.events[dedupKey] = event
+	}
+	return nil
+}
+
+// Release forgets an event so a retry is processed again
+func (r *MemoryWebhookEventRepository) Release(ctx context.Context, dedupKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.events, dedupKey)
+	return nil
+}
+
+// ListDuplicates returns events that were retried since the given time, most recent first
+func (r *MemoryWebhookEventRepository) ListDuplicates(ctx context.Context, since time.Time, limit int) ([]models.WebhookEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var duplicates []models.WebhookEvent
+	for _, event := range r.events {
+		if event.Duplicates > 0 && !event.LastDuplicateAt.Before(since) {
+			duplicates = append(duplicates, event)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].LastDuplicateAt.After(*duplicates[j].LastDuplicateAt)
+	})
+	if len(duplicates) > limit {
+		duplicates = duplicates[:limit]
+	}
+	return duplicates, nil
+}
+
+// Age moves the receive time of an event back, tests use it to simulate an expired window
+func (r *MemoryWebhookEventRepository) Age(dedupKey string, by time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event, ok := r.events[dedupKey]; ok {
+		event.ReceivedAt = event.ReceivedAt.Add(-by)
+		r.events[dedupKey] = event
+	}
+}
+
+// MemoryAccountDeletionRepository is an in-memory AccountDeletionRepository for tests
+type MemoryAccountDeletionRepository struct {
+	mu        sync.Mutex
+	nextID    int64
+	deletions map[int64]models.AccountDeletion
+}
+
+// NewMemoryAccountDeletionRepository creates an empty in-memory account deletion repository
+func NewMemoryAccountDeletionRepository() *MemoryAccountDeletionRepository {
+	return &MemoryAccountDeletionRepository{deletions: make(map[int64]models.AccountDeletion)}
+}
+
+// Schedule stores a pending deletion, or returns the pending one of the same user
+func (r *MemoryAccountDeletionRepository) Schedule(ctx context.Context, deletion models.AccountDeletion) (models.AccountDeletion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.deletions {
+		if existing.UserID == deletion.UserID && existing.Status == models.DeletionPending {
+			return existing, nil
+		}
+	}
+	r.nextID++
+	deletion.ID = r.nextID
+	deletion.Status = models.DeletionPending
+	deletion.RequestedAt = time.Now()
+	r.deletions[deletion.ID] = deletion
+	return deletion, nil
+}
+
+// GetPendingByPhone returns the pending deletion for a phone
+func (r *MemoryAccountDeletionRepository) GetPendingByPhone(ctx context.Context, phone string) (models.AccountDeletion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, deletion := range r.deletions {
+		if deletion.Phone == phone && deletion.Status == models.DeletionPending {
+			return deletion, nil
+		}
+	}
+	return models.AccountDeletion{}, fmt.Errorf("%w: phone %s", ErrDeletionNotFound, phone)
+}
+
+// ListDue returns pending deletions scheduled at or before the given time, and
+// deletions claimed before staleBefore that never finished
+func (r *MemoryAccountDeletionRepository) ListDue(ctx context.Context, at, staleBefore time.Time) ([]models.AccountDeletion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []models.AccountDeletion
+	for _, deletion := range r.deletions {
+		if deletion.Status == models.DeletionPending && !deletion.ScheduledFor.After(at) || staleClaim(deletion, staleBefore) {
+			due = append(due, deletion)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ScheduledFor.Before(due[j].ScheduledFor) })
+	return due, nil
+}
+
+// Claim moves a pending deletion, or one whose claim went stale, to deleting
+func (r *MemoryAccountDeletionRepository) Claim(ctx context.Context, id int64, staleBefore time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deletion, ok := r.deletions[id]
+	if !ok || deletion.Status != models.DeletionPending && !staleClaim(deletion, staleBefore) {
+		return false, nil
+	}
+	now := time.Now()
+	deletion.Status = models.DeletionDeleting
+	deletion.ClaimedAt = &now
+	r.deletions[id] = deletion
+	return true, nil
+}
+
+// staleClaim reports whether a deletion was claimed before staleBefore and never finished
+func staleClaim(deletion models.AccountDeletion, staleBefore time.Time) bool {
+	return deletion.Status == models.DeletionDeleting && (deletion.ClaimedAt == nil || deletion.ClaimedAt.Before(staleBefore))
+}
+
+// Finish moves a pending deletion to cancelled, or a claimed one to completed or failed
+func (r *MemoryAccountDeletionRepository) Finish(ctx context.Context, id int64, status models.DeletionStatus, at time.Time, cause error) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	from := models.DeletionDeleting
+	if status == models.DeletionCancelled {
+		from = models.DeletionPending
+	}
+	deletion, ok := r.deletions[id]
+	if !ok || deletion.Status != from {
+		return false, nil
+	}
+	deletion.Status = status
+	switch status {
+	case models.DeletionCancelled:
+		deletion.CancelledAt = &at
+	case models.DeletionCompleted:
+		deletion.CompletedAt = &at
+	}
+	if cause != nil {
+		message := cause.Error()
+		deletion.LastError = &message
+	}
+	r.deletions[id] = deletion
+	return true, nil
+}
+
+// AgeClaim moves the claim time of a deletion back, tests use it to simulate a worker
+// that died while deleting
+func (r *MemoryAccountDeletionRepository) AgeClaim(id int64, by time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if deletion, ok := r.deletions[id]; ok && deletion.ClaimedAt != nil {
+		claimedAt := deletion.ClaimedAt.Add(-by)
+		deletion.ClaimedAt = &claimedAt
+		r.deletions[id] = deletion
+	}
+}
+
+// Get returns a deletion by ID
+func (r *MemoryAccountDeletionRepository) Get(id int64) (models.AccountDeletion, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deletion, ok := r.deletions[id]
+	return deletion, ok
+}
+
+// MemoryConstructionLeadRepository is an in-memory ConstructionLeadRepository for tests
+type MemoryConstructionLeadRepository struct {
+	mu    sync.Mutex
+	leads []models.ConstructionLead
+}
+
+// NewMemoryConstructionLeadRepository creates an empty in-memory lead repository
+func NewMemoryConstructionLeadRepository() *MemoryConstructionLeadRepository {
+	return &MemoryConstructionLeadRepository{}
+}
+
+// Record stores a new lead
+func (r *MemoryConstructionLeadRepository) Record(ctx context.Context, lead models.ConstructionLead) (models.ConstructionLead, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lead.ID = int64(len(r.leads) + 1)
+	lead.CreatedAt = time.Now()
+	r.leads = append(r.leads, lead)
+	return lead, nil
+}
+
+// Leads returns every recorded lead
+func (r *MemoryConstructionLeadRepository) Leads() []models.ConstructionLead {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.ConstructionLead(nil), r.leads...)
+}
+
+// MemoryRegistrationRepository is an in-memory RegistrationRepository for tests
+type MemoryRegistrationRepository struct {
+	mu            sync.RWMutex
+	registrations map[string]models.WhatsAppRegistration
+}
+
+// NewMemoryRegistrationRepository creates an empty in-memory registration repository
+func NewMemoryRegistrationRepository() *MemoryRegistrationRepository {
+	return &MemoryRegistrationRepository{registrations: make(map[string]models.WhatsAppRegistration)}
+}
+
+// Get returns the last check of a phone number
+func (r *MemoryRegistrationRepository) Get(ctx context.Context, phone string) (models.WhatsAppRegistration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	registration, ok := r.registrations[phone]
+	if !ok {
+		return models.WhatsAppRegistration{}, fmt.Errorf("%w: phone %s", ErrRegistrationNotFound, phone)
+	}
+	return registration, nil
+}
+
+// Save stores or replaces the result of a check
+func (r *MemoryRegistrationRepository) Save(ctx context.Context, registration models.WhatsAppRegistration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.registrations[registration.Phone] = registration
+	return nil
+}
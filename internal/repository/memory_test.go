@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+func TestMemoryUserRepository(t *testing.T) {
+	repo := NewMemoryUserRepository(models.User{ID: "u1", Name: "Asha"})
+
+	user, err := repo.GetByID(context.Background(), "u1")
+	if err != nil || user.Name != "Asha" {
+		t.Fatalf("GetByID(u1) = %+v, %v", user, err)
+	}
+
+	_, err = repo.GetByID(context.Background(), "missing")
+	if !errors.Is(err, ErrUserNotFound) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a user not-found error, got %v", err)
+	}
+	if errors.Is(err, ErrPropertyNotFound) {
+		t.Error("user not-found error must not match ErrPropertyNotFound")
+	}
+}
+
+func TestMemoryUserRepositoryGetByPhone(t *testing.T) {
+	repo := NewMemoryUserRepository(
+		models.User{ID: "trunk", Phone: "09035577330"},
+		models.User{ID: "national", Phone: "90000 00001"},
+		models.User{ID: "international", Phone: "+91 90000 00002"},
+	)
+
+	for phone, want := range map[string]string{"919035577330": "trunk", "919000000001": "national", "919000000002": "international"} {
+		if user, err := repo.GetByPhone(context.Background(), phone); err != nil || user.ID != want {
+			t.Errorf("GetByPhone(%s) = %+v, %v, want %s", phone, user, err, want)
+		}
+	}
+	if _, err := repo.GetByPhone(context.Background(), "919000000003"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected a user not-found error, got %v", err)
+	}
+}
+
+func TestMemoryPropertyRepository(t *testing.T) {
+	repo := NewMemoryPropertyRepository(models.Property{ID: 7, Title: "Corner plot"})
+
+	property, err := repo.GetByID(context.Background(), 7)
+	if err != nil || property.Title != "Corner plot" {
+		t.Fatalf("GetByID(7) = %+v, %v", property, err)
+	}
+
+	_, err = repo.GetByID(context.Background(), 8)
+	if !errors.Is(err, ErrPropertyNotFound) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a property not-found error, got %v", err)
+	}
+}
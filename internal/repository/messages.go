@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const messageColumns = `message_id, outbox_id, recipient_type, recipient, status,
+	sent_at, delivered_at, read_at, failed_at, updated_at`
+
+// allStatuses lists every message status, used to work out which ones a new status supersedes
+var allStatuses = []models.MessageStatus{
+	models.MessageSent, models.MessageDelivered, models.MessageRead, models.MessageFailed,
+}
+
+// supersededBy returns the statuses that may be replaced by status
+func supersededBy(status models.MessageStatus) []string {
+	var superseded []string
+	for _, current := range allStatuses {
+		if status.Supersedes(current) {
+			superseded = append(superseded, string(current))
+		}
+	}
+	return superseded
+}
+
+// PostgresMessageRepository stores message statuses in the whatsapp_messages table
+type PostgresMessageRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresMessageRepository creates a message repository backed by Postgres
+func NewPostgresMessageRepository(db *pgxpool.Pool) *PostgresMessageRepository {
+	return &PostgresMessageRepository{db: db}
+}
+
+// Record stores a freshly sent message
+func (r *PostgresMessageRepository) Record(ctx context.Context, msg models.TrackedMessage) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO whatsapp_messages (message_id, outbox_id, recipient_type, recipient, status, sent_at)
+		 VALUES ($1, $2, $3, $4, 'sent', $5)
+		 ON CONFLICT (message_id) DO NOTHING`,
+		msg.MessageID, msg.OutboxID, msg.RecipientType, msg.Recipient, msg.SentAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record message: %v", err)
+	}
+	return nil
+}
+
+// UpdateStatus moves the given messages forward to status
+func (r *PostgresMessageRepository) UpdateStatus(ctx context.Context, messageIDs []string, status models.MessageStatus, at time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE whatsapp_messages SET
+			status = $2,
+			delivered_at = CASE WHEN $2 IN ('delivered', 'read') THEN COALESCE(delivered_at, $3) ELSE delivered_at END,
+			read_at = CASE WHEN $2 = 'read' THEN COALESCE(read_at, $3) ELSE read_at END,
+			failed_at = CASE WHEN $2 = 'failed' THEN $3 ELSE failed_at END,
+			updated_at = now()
+		 WHERE message_id = ANY($1) AND status = ANY($4)`,
+		messageIDs, status, at, supersededBy(status),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update message status: %v", err)
+	}
+	return nil
+}
+
+// GetByID returns the message with the given WhatsApp ID
+func (r *PostgresMessageRepository) GetByID(ctx context.Context, messageID string) (models.TrackedMessage, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+messageColumns+` FROM whatsapp_messages WHERE message_id = $1`, messageID)
+	if err != nil {
+		return models.TrackedMessage{}, fmt.Errorf("failed to fetch message: %v", err)
+	}
+
+	msg, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.TrackedMessage])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TrackedMessage{}, fmt.Errorf("%w: id %s", ErrMessageNotFound, messageID)
+		}
+		return models.TrackedMessage{}, fmt.Errorf("failed to fetch message: %v", err)
+	}
+	return msg, nil
+}
+
+// ListByRecipient returns the most recent messages sent to a recipient
+func (r *PostgresMessageRepository) ListByRecipient(ctx context.Context, recipient string, limit int) ([]models.TrackedMessage, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+messageColumns+` FROM whatsapp_messages
+		 WHERE recipient = $1 ORDER BY sent_at DESC LIMIT $2`,
+		recipient, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %v", err)
+	}
+
+	messages, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.TrackedMessage])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %v", err)
+	}
+	return messages, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	phonenumbers "github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/phone"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresUserRepository reads users from the users table
+type PostgresUserRepository struct {
+	db *pgxpool.Pool
+	// defaultCountry is the country of phone numbers stored without a country code
+	defaultCountry string
+}
+
+// NewPostgresUserRepository creates a user repository backed by Postgres
+func NewPostgresUserRepository(db *pgxpool.Pool, defaultCountry string) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db, defaultCountry: defaultCountry}
+}
+
+// GetByID returns the user with the given ID
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id string) (models.User, error) {
+	// Select specific columns instead of * to be more explicit
+	rows, err := r.db.Query(ctx, `SELECT name, role, is_blocked, id, phone, pref_lang, address,
+		created_at, push_notification_tokens, notes, send_push_notifications
+		FROM users WHERE id = $1`, id)
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to fetch user: %v", err)
+	}
+
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.User])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%w: id %s", ErrUserNotFound, id)
+		}
+		return models.User{}, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	return user, nil
+}
+
+// Delete removes the user with the given ID
+func (r *PostgresUserRepository) Delete(ctx context.Context, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: id %s", ErrUserNotFound, id)
+	}
+	return nil
+}
+
+// GetByPhone returns the user whose phone number is the given international digits,
+// whether it was stored in international or national format. The digits expression is
+// indexed by users_phone_digits_idx, keep both in sync.
+func (r *PostgresUserRepository) GetByPhone(ctx context.Context, phone string) (models.User, error) {
+	rows, err := r.db.Query(ctx, `SELECT name, role, is_blocked, id, phone, pref_lang, address,
+		created_at, push_notification_tokens, notes, send_push_notifications
+		FROM users WHERE regexp_replace(phone, '[^0-9]', '', 'g') = ANY($1)
+		ORDER BY created_at DESC LIMIT 1`, phonenumbers.StoredForms(phone, r.defaultCountry))
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to fetch user: %v", err)
+	}
+
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.User])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%w: phone %s", ErrUserNotFound, phone)
+		}
+		return models.User{}, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	return user, nil
+}
+
+// PostgresPropertyRepository reads listings from the property table
+type PostgresPropertyRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresPropertyRepository creates a property repository backed by Postgres
+func NewPostgresPropertyRepository(db *pgxpool.Pool) *PostgresPropertyRepository {
+	return &PostgresPropertyRepository{db: db}
+}
+
+// GetByID returns the property with the given ID
+func (r *PostgresPropertyRepository) GetByID(ctx context.Context, id int) (models.Property, error) {
+	rows, err := r.db.Query(ctx, `SELECT id, category_id, title, size, developer_id, owner_id, address_id,
+		recommended, banner_id, custom_phone_no, status,
+		facing, estimated_price, negotiable, map_centerpoint, custom_zoom,
+		featured, created_at, show_as_new, visibility_score, rental,
+		rent_amount, reveal_location
+		FROM property WHERE id = $1`, id)
+	if err != nil {
+		return models.Property{}, fmt.Errorf("failed to fetch property: %v", err)
+	}
+
+	property, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Property])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Property{}, fmt.Errorf("%w: id %d", ErrPropertyNotFound, id)
+		}
+		return models.Property{}, fmt.Errorf("failed to fetch property: %v", err)
+	}
+	return property, nil
+}
+
+// ListImageURLs returns up to limit photo URLs of a property from the property_images table
+func (r *PostgresPropertyRepository) ListImageURLs(ctx context.Context, propertyID int64, limit int) ([]string, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT url FROM property_images WHERE property_id = $1 ORDER BY id LIMIT $2`,
+		propertyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch property images: %v", err)
+	}
+
+	urls, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch property images: %v", err)
+	}
+	return urls, nil
+}
+
+// GetBannerURL returns the image URL of a banner from the banners table
+func (r *PostgresPropertyRepository) GetBannerURL(ctx context.Context, bannerID int64) (string, error) {
+	var url string
+	err := r.db.QueryRow(ctx, `SELECT url FROM banners WHERE id = $1`, bannerID).Scan(&url)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("%w: id %d", ErrBannerNotFound, bannerID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch banner: %v", err)
+	}
+	return url, nil
+}
+
+// GetAddress returns an address from the address table
+func (r *PostgresPropertyRepository) GetAddress(ctx context.Context, addressID int64) (models.Address, error) {
+	rows, err := r.db.Query(ctx, `SELECT id, locality, city, district, state FROM address WHERE id = $1`, addressID)
+	if err != nil {
+		return models.Address{}, fmt.Errorf("failed to fetch address: %v", err)
+	}
+
+	address, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Address])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Address{}, fmt.Errorf("%w: id %d", ErrAddressNotFound, addressID)
+		}
+		return models.Address{}, fmt.Errorf("failed to fetch address: %v", err)
+	}
+	return address, nil
+}
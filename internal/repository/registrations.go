@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresRegistrationRepository caches registration checks in the whatsapp_registrations table
+type PostgresRegistrationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRegistrationRepository creates a registration repository backed by Postgres
+func NewPostgresRegistrationRepository(db *pgxpool.Pool) *PostgresRegistrationRepository {
+	return &PostgresRegistrationRepository{db: db}
+}
+
+// Get returns the last check of a phone number
+func (r *PostgresRegistrationRepository) Get(ctx context.Context, phone string) (models.WhatsAppRegistration, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT phone, registered, jid, checked_at FROM whatsapp_registrations WHERE phone = $1`, phone)
+	if err != nil {
+		return models.WhatsAppRegistration{}, fmt.Errorf("failed to fetch registration: %v", err)
+	}
+
+	registration, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.WhatsAppRegistration])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.WhatsAppRegistration{}, fmt.Errorf("%w: phone %s", ErrRegistrationNotFound, phone)
+		}
+		return models.WhatsAppRegistration{}, fmt.Errorf("failed to fetch registration: %v", err)
+	}
+	return registration, nil
+}
+
+// Save stores or replaces the result of a check
+func (r *PostgresRegistrationRepository) Save(ctx context.Context, registration models.WhatsAppRegistration) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO whatsapp_registrations (phone, registered, jid, checked_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (phone) DO UPDATE
+		 SET registered = EXCLUDED.registered, jid = EXCLUDED.jid, checked_at = EXCLUDED.checked_at`,
+		registration.Phone, registration.Registered, registration.JID, registration.CheckedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save registration: %v", err)
+	}
+	return nil
+}
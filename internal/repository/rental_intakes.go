@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const intakeColumns = `id, user_id, phone, locale, step, status, photos, maps_link, latitude, longitude,
+	description, rent, contact_phone, draft_id, created_at, updated_at`
+
+// PostgresRentalIntakeRepository stores intakes in the rental_intake_sessions table
+// and completed listings in rental_property_drafts
+type PostgresRentalIntakeRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRentalIntakeRepository creates a rental intake repository backed by Postgres
+func NewPostgresRentalIntakeRepository(db *pgxpool.Pool) *PostgresRentalIntakeRepository {
+	return &PostgresRentalIntakeRepository{db: db}
+}
+
+// Start begins a new intake, expiring any intake still active for the same phone
+func (r *PostgresRentalIntakeRepository) Start(ctx context.Context, intake models.RentalIntake) (models.RentalIntake, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return models.RentalIntake{}, fmt.Errorf("failed to start intake: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE rental_intake_sessions SET status = 'expired', updated_at = now()
+		 WHERE phone = $1 AND status = 'active'`, intake.Phone)
+	if err != nil {
+		return models.RentalIntake{}, fmt.Errorf("failed to expire previous intake: %v", err)
+	}
+
+	rows, err := tx.Query(ctx,
+		`INSERT INTO rental_intake_sessions (user_id, phone, locale, step)
+		 VALUES ($1, $2, $3, $4) RETURNING `+intakeColumns,
+		intake.UserID, intake.Phone, intake.Locale, intake.Step)
+	if err != nil {
+		return models.RentalIntake{}, fmt.Errorf("failed to start intake: %v", err)
+	}
+	started, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RentalIntake])
+	if err != nil {
+		return models.RentalIntake{}, fmt.Errorf("failed to start intake: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.RentalIntake{}, fmt.Errorf("failed to start intake: %v", err)
+	}
+	return started, nil
+}
+
+// GetActiveByPhone returns the active intake for a phone
+func (r *PostgresRentalIntakeRepository) GetActiveByPhone(ctx context.Context, phone string) (models.RentalIntake, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+intakeColumns+` FROM rental_intake_sessions
+		 WHERE phone = $1 AND status = 'active'`, phone)
+	if err != nil {
+		return models.RentalIntake{}, fmt.Errorf("failed to fetch intake: %v", err)
+	}
+
+	intake, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RentalIntake])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RentalIntake{}, fmt.Errorf("%w: phone %s", ErrIntakeNotFound, phone)
+		}
+		return models.RentalIntake{}, fmt.Errorf("failed to fetch intake: %v", err)
+	}
+	return intake, nil
+}
+
+// Save stores the step and collected data of an active intake
+func (r *PostgresRentalIntakeRepository) Save(ctx context.Context, intake models.RentalIntake) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE rental_intake_sessions SET
+			step = $2, photos = $3, maps_link = $4, latitude = $5, longitude = $6,
+			description = $7, rent = $8, contact_phone = $9, updated_at = now()
+		 WHERE id = $1 AND status = 'active'`,
+		intake.ID, intake.Step, intake.Photos, intake.MapsLink, intake.Latitude, intake.Longitude,
+		intake.Description, intake.Rent, intake.ContactPhone)
+	if err != nil {
+		return fmt.Errorf("failed to save intake: %v", err)
+	}
+	return nil
+}
+
+// Complete turns the intake into a draft listing and closes the intake
+func (r *PostgresRentalIntakeRepository) Complete(ctx context.Context, intake models.RentalIntake) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to complete intake: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var draftID int64
+	err = tx.QueryRow(ctx,
+		`INSERT INTO rental_property_drafts
+			(user_id, photos, maps_link, latitude, longitude, description, rent, contact_phone)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		intake.UserID, intake.Photos, intake.MapsLink, intake.Latitude, intake.Longitude,
+		intake.Description, intake.Rent, intake.ContactPhone,
+	).Scan(&draftID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create rental draft: %v", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE rental_intake_sessions SET status = 'completed', draft_id = $2, updated_at = now()
+		 WHERE id = $1`, intake.ID, draftID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to complete intake: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to complete intake: %v", err)
+	}
+	return draftID, nil
+}
+
+// ExpireInactive expires active intakes not updated since the given time
+func (r *PostgresRentalIntakeRepository) ExpireInactive(ctx context.Context, before time.Time) ([]models.RentalIntake, error) {
+	rows, err := r.db.Query(ctx,
+		`UPDATE rental_intake_sessions SET status = 'expired'
+		 WHERE status = 'active' AND updated_at < $1
+		 RETURNING `+intakeColumns, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire intakes: %v", err)
+	}
+
+	intakes, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.RentalIntake])
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire intakes: %v", err)
+	}
+	return intakes, nil
+}
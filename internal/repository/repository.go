@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNotFound is matched by every not-found error returned from a repository
+var ErrNotFound = errors.New("record not found")
+
+// Typed not-found errors, all of them wrap ErrNotFound
+var (
+	ErrUserNotFound         = fmt.Errorf("user %w", ErrNotFound)
+	ErrPropertyNotFound     = fmt.Errorf("property %w", ErrNotFound)
+	ErrBannerNotFound       = fmt.Errorf("banner %w", ErrNotFound)
+	ErrAddressNotFound      = fmt.Errorf("address %w", ErrNotFound)
+	ErrMessageNotFound      = fmt.Errorf("message %w", ErrNotFound)
+	ErrIntakeNotFound       = fmt.Errorf("rental intake %w", ErrNotFound)
+	ErrChecklistNotFound    = fmt.Errorf("sell checklist %w", ErrNotFound)
+	ErrDeletionNotFound     = fmt.Errorf("account deletion %w", ErrNotFound)
+	ErrRegistrationNotFound = fmt.Errorf("whatsapp registration %w", ErrNotFound)
+)
+
+// Repositories groups every repository the handlers depend on
+type Repositories struct {
+	Users         UserRepository
+	Properties    PropertyRepository
+	Messages      MessageRepository
+	Conversations ConversationRepository
+	RentalIntakes RentalIntakeRepository
+	Checklists    SellChecklistRepository
+	WebhookEvents WebhookEventRepository
+	Deletions     AccountDeletionRepository
+	Leads         ConstructionLeadRepository
+	Registrations RegistrationRepository
+}
+
+// NewPostgresRepositories creates the Postgres implementation of every repository.
+// Phone numbers stored without a country code are read as numbers of defaultCountry.
+func NewPostgresRepositories(db *pgxpool.Pool, defaultCountry string) Repositories {
+	return Repositories{
+		Users:         NewPostgresUserRepository(db, defaultCountry),
+		Properties:    NewPostgresPropertyRepository(db),
+		Messages:      NewPostgresMessageRepository(db),
+		Conversations: NewPostgresConversationRepository(db),
+		RentalIntakes: NewPostgresRentalIntakeRepository(db),
+		Checklists:    NewPostgresSellChecklistRepository(db),
+		WebhookEvents: NewPostgresWebhookEventRepository(db),
+		Deletions:     NewPostgresAccountDeletionRepository(db),
+		Leads:         NewPostgresConstructionLeadRepository(db),
+		Registrations: NewPostgresRegistrationRepository(db),
+	}
+}
+
+// UserRepository reads users
+type UserRepository interface {
+	// GetByID returns the user with the given ID or an error matching ErrUserNotFound
+	GetByID(ctx context.Context, id string) (models.User, error)
+	// GetByPhone returns the user whose phone, once normalized, is the given international
+	// digits (e.g. 919035577330) or an error matching ErrUserNotFound
+	GetByPhone(ctx context.Context, phone string) (models.User, error)
+	// Delete removes a user or returns an error matching ErrUserNotFound
+	Delete(ctx context.Context, id string) error
+}
+
+// PropertyRepository reads property listings
+type PropertyRepository interface {
+	// GetByID returns the property with the given ID or an error matching ErrPropertyNotFound
+	GetByID(ctx context.Context, id int) (models.Property, error)
+	// ListImageURLs returns up to limit photo URLs of a property in display order
+	ListImageURLs(ctx context.Context, propertyID int64, limit int) ([]string, error)
+	// GetBannerURL returns the image URL of a listing banner or an error matching ErrBannerNotFound
+	GetBannerURL(ctx context.Context, bannerID int64) (string, error)
+	// GetAddress returns the address of a listing or an error matching ErrAddressNotFound
+	GetAddress(ctx context.Context, addressID int64) (models.Address, error)
+}
+
+// MessageRepository stores the delivery status of messages we sent
+type MessageRepository interface {
+	// Record stores a freshly sent message with status sent
+	Record(ctx context.Context, msg models.TrackedMessage) error
+	// UpdateStatus moves the given messages to status if it supersedes their current one
+	UpdateStatus(ctx context.Context, messageIDs []string, status models.MessageStatus, at time.Time) error
+	// GetByID returns the message with the given WhatsApp ID or an error matching ErrMessageNotFound
+	GetByID(ctx context.Context, messageID string) (models.TrackedMessage, error)
+	// ListByRecipient returns the most recent messages sent to a recipient, newest first
+	ListByRecipient(ctx context.Context, recipient string, limit int) ([]models.TrackedMessage, error)
+}
+
+// ConversationRepository stores inbound messages
+type ConversationRepository interface {
+	// Append stores an inbound message as received. It returns false if the message was
+	// already stored, unless its handlers failed, in which case it is received again.
+	Append(ctx context.Context, msg models.ConversationMessage) (bool, error)
+	// Finish records whether the handlers of a message succeeded
+	Finish(ctx context.Context, messageID string, status models.ConversationStatus, cause error) error
+	// ListBySender returns the most recent messages from a phone number, newest first
+	ListBySender(ctx context.Context, phone string, limit int) ([]models.ConversationMessage, error)
+}
+
+// RentalIntakeRepository stores the progress of rental intake conversations
+type RentalIntakeRepository interface {
+	// Start begins a new intake, expiring any intake still active for the same phone
+	Start(ctx context.Context, intake models.RentalIntake) (models.RentalIntake, error)
+	// GetActiveByPhone returns the active intake for a phone or an error matching ErrIntakeNotFound
+	GetActiveByPhone(ctx context.Context, phone string) (models.RentalIntake, error)
+	// Save stores the step and collected data of an active intake
+	Save(ctx context.Context, intake models.RentalIntake) error
+	// Complete turns the intake into a draft listing for review and returns the draft ID
+	Complete(ctx context.Context, intake models.RentalIntake) (int64, error)
+	// ExpireInactive expires active intakes not updated since the given time and returns them
+	ExpireInactive(ctx context.Context, before time.Time) ([]models.RentalIntake, error)
+}
+
+// SellChecklistRepository stores which requested details each seller has sent
+type SellChecklistRepository interface {
+	// Create stores a new checklist, or returns the existing one for the same sell request
+	Create(ctx context.Context, checklist models.SellChecklist) (models.SellChecklist, error)
+	// GetOpenByPhone returns the latest incomplete checklist for a phone or an error matching ErrChecklistNotFound
+	GetOpenByPhone(ctx context.Context, phone string) (models.SellChecklist, error)
+	// Save stores the collected details and completion time of a checklist
+	Save(ctx context.Context, checklist models.SellChecklist) error
+	// ListDueReminders returns incomplete checklists last touched before the given time
+	// that have had fewer than maxReminders reminders
+	ListDueReminders(ctx context.Context, before time.Time, maxReminders int) ([]models.SellChecklist, error)
+	// MarkReminded records that a reminder was sent for a checklist
+	MarkReminded(ctx context.Context, id int64, at time.Time) error
+}
+
+// WebhookEventRepository remembers processed webhooks so retries aren't handled twice
+type WebhookEventRepository interface {
+	// Claim records an event unless it was already received after windowStart. It returns
+	// the stored event and true when the caller should process it, or the original event
+	// with its duplicate count bumped and false for a retry.
+	Claim(ctx context.Context, event models.WebhookEvent, windowStart time.Time) (models.WebhookEvent, bool, error)
+	// Complete stores the response the event was answered with
+	Complete(ctx context.Context, dedupKey string, statusCode int, response []byte) error
+	// Release forgets an event so a retry is processed again, used after a failure
+	Release(ctx context.Context, dedupKey string) error
+	// ListDuplicates returns events that were retried since the given time, most recent first
+	ListDuplicates(ctx context.Context, since time.Time, limit int) ([]models.WebhookEvent, error)
+}
+
+// AccountDeletionRepository stores account deletion requests and their outcome
+type AccountDeletionRepository interface {
+	// Schedule stores a pending deletion, or returns the pending one of the same user
+	Schedule(ctx context.Context, deletion models.AccountDeletion) (models.AccountDeletion, error)
+	// GetPendingByPhone returns the pending deletion for a phone or an error matching ErrDeletionNotFound
+	GetPendingByPhone(ctx context.Context, phone string) (models.AccountDeletion, error)
+	// ListDue returns pending deletions scheduled at or before the given time, and
+	// deletions claimed before staleBefore that never finished
+	ListDue(ctx context.Context, at, staleBefore time.Time) ([]models.AccountDeletion, error)
+	// Claim moves a pending deletion, or one whose claim is older than staleBefore, to
+	// deleting so it can no longer be cancelled. It returns false when the deletion
+	// couldn't be claimed (e.g. cancelled or claimed by another worker meanwhile).
+	Claim(ctx context.Context, id int64, staleBefore time.Time) (bool, error)
+	// Finish moves a pending deletion to cancelled, or a claimed one to completed or
+	// failed. It returns false when the deletion wasn't in that state.
+	Finish(ctx context.Context, id int64, status models.DeletionStatus, at time.Time, cause error) (bool, error)
+}
+
+// ConstructionLeadRepository stores users interested in our construction services
+type ConstructionLeadRepository interface {
+	// Record stores a new lead and returns it with its ID
+	Record(ctx context.Context, lead models.ConstructionLead) (models.ConstructionLead, error)
+}
+
+// RegistrationRepository caches whether phone numbers have a WhatsApp account
+type RegistrationRepository interface {
+	// Get returns the last check of a phone number or an error matching ErrRegistrationNotFound
+	Get(ctx context.Context, phone string) (models.WhatsAppRegistration, error)
+	// Save stores or replaces the result of a check
+	Save(ctx context.Context, registration models.WhatsAppRegistration) error
+}
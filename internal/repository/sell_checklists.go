@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const checklistColumns = `id, sell_request_id, user_id, phone, locale, size, property_type, facing,
+	map_location, images, utaara_copy, reminders_sent, last_reminder_at, completed_at,
+	created_at, updated_at`
+
+// PostgresSellChecklistRepository stores checklists in the sell_request_checklists table
+type PostgresSellChecklistRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresSellChecklistRepository creates a sell checklist repository backed by Postgres
+func NewPostgresSellChecklistRepository(db *pgxpool.Pool) *PostgresSellChecklistRepository {
+	return &PostgresSellChecklistRepository{db: db}
+}
+
+// Create stores a new checklist, or returns the existing one for the same sell request
+func (r *PostgresSellChecklistRepository) Create(ctx context.Context, checklist models.SellChecklist) (models.SellChecklist, error) {
+	rows, err := r.db.Query(ctx,
+		`INSERT INTO sell_request_checklists (sell_request_id, user_id, phone, locale)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (sell_request_id) DO UPDATE SET sell_request_id = EXCLUDED.sell_request_id
+		 RETURNING `+checklistColumns,
+		checklist.SellRequestID, checklist.UserID, checklist.Phone, checklist.Locale)
+	if err != nil {
+		return models.SellChecklist{}, fmt.Errorf("failed to create checklist: %v", err)
+	}
+
+	created, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.SellChecklist])
+	if err != nil {
+		return models.SellChecklist{}, fmt.Errorf("failed to create checklist: %v", err)
+	}
+	return created, nil
+}
+
+// GetOpenByPhone returns the latest incomplete checklist for a phone
+func (r *PostgresSellChecklistRepository) GetOpenByPhone(ctx context.Context, phone string) (models.SellChecklist, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+checklistColumns+` FROM sell_request_checklists
+		 WHERE phone = $1 AND completed_at IS NULL
+		 ORDER BY created_at DESC LIMIT 1`, phone)
+	if err != nil {
+		return models.SellChecklist{}, fmt.Errorf("failed to fetch checklist: %v", err)
+	}
+
+	checklist, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.SellChecklist])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.SellChecklist{}, fmt.Errorf("%w: phone %s", ErrChecklistNotFound, phone)
+		}
+		return models.SellChecklist{}, fmt.Errorf("failed to fetch checklist: %v", err)
+	}
+	return checklist, nil
+}
+
+// Save stores the collected details and completion time of a checklist
+func (r *PostgresSellChecklistRepository) Save(ctx context.Context, checklist models.SellChecklist) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE sell_request_checklists SET
+			size = $2, property_type = $3, facing = $4, map_location = $5,
+			images = $6, utaara_copy = $7, completed_at = $8, updated_at = now()
+		 WHERE id = $1`,
+		checklist.ID, checklist.Size, checklist.PropertyType, checklist.Facing, checklist.MapLocation,
+		checklist.Images, checklist.UtaaraCopy, checklist.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save checklist: %v", err)
+	}
+	return nil
+}
+
+// ListDueReminders returns incomplete checklists that are due a reminder
+func (r *PostgresSellChecklistRepository) ListDueReminders(ctx context.Context, before time.Time, maxReminders int) ([]models.SellChecklist, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+checklistColumns+` FROM sell_request_checklists
+		 WHERE completed_at IS NULL AND reminders_sent < $2
+		   AND GREATEST(updated_at, COALESCE(last_reminder_at, created_at)) < $1`,
+		before, maxReminders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checklists: %v", err)
+	}
+
+	checklists, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.SellChecklist])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checklists: %v", err)
+	}
+	return checklists, nil
+}
+
+// MarkReminded records that a reminder was sent for a checklist
+func (r *PostgresSellChecklistRepository) MarkReminded(ctx context.Context, id int64, at time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE sell_request_checklists
+		 SET reminders_sent = reminders_sent + 1, last_reminder_at = $2
+		 WHERE id = $1`, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to mark checklist reminded: %v", err)
+	}
+	return nil
+}
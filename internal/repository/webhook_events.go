@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const webhookEventColumns = `id, dedup_key, event_table, event_type, record_id, status_code,
+	response, duplicates, received_at, last_duplicate_at`
+
+// PostgresWebhookEventRepository stores processed webhooks in the webhook_events table
+type PostgresWebhookEventRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresWebhookEventRepository creates a webhook event repository backed by Postgres
+func NewPostgresWebhookEventRepository(db *pgxpool.Pool) *PostgresWebhookEventRepository {
+	return &PostgresWebhookEventRepository{db: db}
+}
+
+// Claim records an event, or counts a duplicate of one received after windowStart.
+// An event older than the window is treated as new and its stored outcome is reset.
+func (r *PostgresWebhookEventRepository) Claim(ctx context.Context, event models.WebhookEvent, windowStart time.Time) (models.WebhookEvent, bool, error) {
+	rows, err := r.db.Query(ctx,
+		`INSERT INTO webhook_events (dedup_key, event_table, event_type, record_id)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (dedup_key) DO UPDATE SET
+			duplicates = CASE WHEN webhook_events.received_at >= $5 THEN webhook_events.duplicates + 1 ELSE 0 END,
+			status_code = CASE WHEN webhook_events.received_at >= $5 THEN webhook_events.status_code END,
+			response = CASE WHEN webhook_events.received_at >= $5 THEN webhook_events.response END,
+			last_duplicate_at = CASE WHEN webhook_events.received_at >= $5 THEN now() END,
+			received_at = CASE WHEN webhook_events.received_at >= $5 THEN webhook_events.received_at ELSE now() END
+		 RETURNING `+webhookEventColumns,
+		event.DedupKey, event.EventTable, event.EventType, event.RecordID, windowStart)
+	if err != nil {
+		return models.WebhookEvent{}, false, fmt.Errorf("failed to claim webhook event: %v", err)
+	}
+
+	claimed, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.WebhookEvent])
+	if err != nil {
+		return models.WebhookEvent{}, false, fmt.Errorf("failed to claim webhook event: %v", err)
+	}
+	return claimed, claimed.Duplicates == 0, nil
+}
+
+// Complete stores the response the event was answered with
+func (r *PostgresWebhookEventRepository) Complete(ctx context.Context, dedupKey string, statusCode int, response []byte) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE webhook_events SET status_code = $2, response = $3 WHERE dedup_key = $1`,
+		dedupKey, statusCode, response)
+	if err != nil {
+		return fmt.Errorf("failed to complete webhook event: %v", err)
+	}
+	return nil
+}
+
+// Release forgets an event so a retry is processed again
+func (r *PostgresWebhookEventRepository) Release(ctx context.Context, dedupKey string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM webhook_events WHERE dedup_key = $1`, dedupKey)
+	if err != nil {
+		return fmt.Errorf("failed to release webhook event: %v", err)
+	}
+	return nil
+}
+
+// ListDuplicates returns events that were retried since the given time, most recent first
+func (r *PostgresWebhookEventRepository) ListDuplicates(ctx context.Context, since time.Time, limit int) ([]models.WebhookEvent, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+webhookEventColumns+` FROM webhook_events
+		 WHERE duplicates > 0 AND last_duplicate_at >= $1
+		 ORDER BY last_duplicate_at DESC LIMIT $2`,
+		since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook duplicates: %v", err)
+	}
+
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.WebhookEvent])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook duplicates: %v", err)
+	}
+	return events, nil
+}
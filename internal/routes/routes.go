@@ -4,25 +4,88 @@ import (
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/handlers"
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/middleware"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Dependencies are the services the routes are served with
+type Dependencies struct {
+	DB            *pgxpool.Pool
+	Repos         repository.Repositories
+	Messenger     services.Messenger
+	Workflows     services.Workflows
+	Notifications services.NotificationRoutesProvider
+	Connection    services.ConnectionStatusProvider
+	Pairing       services.PairingController
+	Health        services.HealthReporter
+	Templates     services.MessageRenderer
+	// Scheduled lists and cancels messages held back by quiet hours
+	Scheduled services.ScheduledMessages
+	Config    *config.Config
+}
+
 // SetupRoutes configures all application routes
-func SetupRoutes(router *gin.Engine, dbpool *pgxpool.Pool, whatsappService *services.WhatsAppService, cfg *config.Config) {
+func SetupRoutes(router *gin.Engine, deps Dependencies) {
 	router.GET("/ping", handlers.PingHandler)
 
+	// Probes for the orchestrator and uptime monitoring
+	router.GET("/healthz", handlers.NewLivenessHandler(deps.Health))
+	router.GET("/readyz", handlers.NewReadinessHandler(deps.Health))
+
+	// Webhooks are only authenticated with the webhook secret, everything that reads
+	// state back requires the admin token
 	protectedRoute := router.Group("/")
 	// middle-ware
-	protectedRoute.Use(middleware.DatabaseMiddleware(dbpool))
-	protectedRoute.Use(middleware.ConfigMiddleware(cfg))
-	protectedRoute.Use(middleware.WhatsAppMiddleware(whatsappService))
+	protectedRoute.Use(middleware.WebhookAuth(deps.Config))
+	protectedRoute.Use(middleware.DatabaseMiddleware(deps.DB))
+	protectedRoute.Use(middleware.RepositoryMiddleware(deps.Repos))
+	protectedRoute.Use(middleware.ConfigMiddleware(deps.Config))
+	protectedRoute.Use(middleware.MessengerMiddleware(deps.Messenger))
+	protectedRoute.Use(middleware.WorkflowMiddleware(deps.Workflows))
+	protectedRoute.Use(middleware.GroupRouterMiddleware(deps.Notifications))
+	protectedRoute.Use(middleware.MessageTemplatesMiddleware(deps.Templates))
+
+	// Webhook endpoints, retried deliveries of the same event are answered without reprocessing
+	dedup := middleware.WebhookIdempotency(deps.Repos.WebhookEvents, deps.Config)
+	protectedRoute.POST("/sell-request", dedup, handlers.NewSellRequestHandler())
+
+	// User logs endpoint, each event type is handled by its registered handler
+	userEvents := handlers.NewDefaultUserEventRegistry()
+	protectedRoute.POST("/user-logs", dedup, handlers.NewUserLogsHandler(userEvents))
+
+	// Operator endpoints
+	admin := router.Group("/admin")
+	admin.Use(middleware.AdminAuth(deps.Config))
+	admin.Use(middleware.RepositoryMiddleware(deps.Repos))
+	admin.Use(middleware.ConfigMiddleware(deps.Config))
+
+	// Event types the user logs webhook handles
+	admin.GET("/user-logs/events", handlers.NewUserEventsHandler(userEvents))
+
+	// Webhook retries that were deduplicated, and requests rejected by webhook authentication
+	admin.GET("/webhooks/duplicates", handlers.GetWebhookDuplicates)
+	admin.GET("/webhooks/rejections", handlers.GetWebhookRejections)
+
+	// Delivery and read receipts of sent messages
+	admin.GET("/messages/:id", handlers.GetMessageStatus)
+	admin.GET("/users/:id/messages", handlers.GetUserMessages)
+
+	// State of the WhatsApp session, e.g. whether it has to be paired again
+	admin.GET("/whatsapp/status", handlers.NewConnectionStatusHandler(deps.Connection))
+
+	// Link the WhatsApp device with a QR code or a phone pairing code
+	admin.POST("/pairing", handlers.NewStartPairingHandler(deps.Pairing))
+	admin.GET("/pairing", handlers.NewPairingStatusHandler(deps.Pairing))
+	admin.GET("/pairing/qr.png", handlers.NewPairingQRHandler(deps.Pairing))
+	admin.GET("/pairing/events", handlers.NewPairingEventsHandler(deps.Pairing))
 
-	// Webhook endpoints
-	protectedRoute.POST("/sell-request", handlers.NewSellRequestHandler())
+	// Groups each category of internal alerts goes to
+	admin.GET("/notification-routes", handlers.NewNotificationRoutesHandler(deps.Notifications))
 
-	// User logs endpoint
-	protectedRoute.POST("/user-logs", handlers.HandleUserLogs)
+	// Messages to users held until quiet hours end
+	admin.GET("/scheduled-messages", handlers.NewScheduledMessagesHandler(deps.Scheduled))
+	admin.DELETE("/scheduled-messages/:id", handlers.NewCancelScheduledMessageHandler(deps.Scheduled))
 
 }
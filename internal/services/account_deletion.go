@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+)
+
+const (
+	// deletionSweepInterval is how often due account deletions are carried out
+	deletionSweepInterval = 5 * time.Minute
+	// deletionLease is how long a claimed deletion may take. A deletion still not
+	// finished after that, e.g. because the process died, is carried out again.
+	deletionLease = 15 * time.Minute
+)
+
+// cancelReplies are the replies that cancel a pending account deletion
+var cancelReplies = map[string]bool{"cancel": true, "cancel deletion": true, "stop": true}
+
+// AccountDeleter removes a user's account once the grace period is over
+type AccountDeleter interface {
+	DeleteAccount(ctx context.Context, userID string) error
+}
+
+// UserAccountDeleter deletes the user record through the user repository
+type UserAccountDeleter struct {
+	users repository.UserRepository
+}
+
+// NewUserAccountDeleter creates an account deleter backed by the user repository
+func NewUserAccountDeleter(users repository.UserRepository) *UserAccountDeleter {
+	return &UserAccountDeleter{users: users}
+}
+
+// DeleteAccount deletes the user, an already deleted user counts as done
+func (d *UserAccountDeleter) DeleteAccount(ctx context.Context, userID string) error {
+	if err := d.users.Delete(ctx, userID); err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return err
+	}
+	return nil
+}
+
+// AccountDeletionStarter schedules the deletion of a user's account
+type AccountDeletionStarter interface {
+	Start(ctx context.Context, user models.User) (models.AccountDeletion, error)
+}
+
+// AccountDeletionWorkflow deletes accounts after a grace period in which the user can
+// still cancel by replying CANCEL. The team is kept informed at every step.
+type AccountDeletionWorkflow struct {
+	deletions repository.AccountDeletionRepository
+	deleter   AccountDeleter
+	messenger Messenger
+	templates MessageRenderer
+	routes    GroupRouter
+	config    *config.Config
+}
+
+var _ AccountDeletionStarter = (*AccountDeletionWorkflow)(nil)
+
+// NewAccountDeletionWorkflow creates a new account deletion workflow
+func NewAccountDeletionWorkflow(deletions repository.AccountDeletionRepository, deleter AccountDeleter, messenger Messenger, templates MessageRenderer, routes GroupRouter, cfg *config.Config) *AccountDeletionWorkflow {
+	return &AccountDeletionWorkflow{
+		deletions: deletions,
+		deleter:   deleter,
+		messenger: messenger,
+		templates: templates,
+		routes:    routes,
+		config:    cfg,
+	}
+}
+
+// Register hooks the cancellation reply into the inbound router. It should be
+// registered before other text handlers so CANCEL always reaches it.
+func (w *AccountDeletionWorkflow) Register(router *InboundRouter) {
+	router.Handle(models.ChatDirect, models.ContentText, w.handleCancel)
+}
+
+// Start schedules the deletion of the user's account after the grace period.
+// A repeated request returns the deletion that is already pending.
+func (w *AccountDeletionWorkflow) Start(ctx context.Context, user models.User) (models.AccountDeletion, error) {
+	deletion, err := w.deletions.Schedule(ctx, models.AccountDeletion{
+		UserID:       user.ID,
+		Phone:        userPhone(user.Phone, w.config.DefaultPhoneCountry),
+		Locale:       user.Locale(),
+		ScheduledFor: time.Now().Add(w.config.AccountDeletionGracePeriod),
+	})
+	if err != nil {
+		return models.AccountDeletion{}, err
+	}
+
+	log.Printf("Account Deletion: Deletion %d of user %s scheduled for %s", deletion.ID, user.ID, deletion.ScheduledFor.Format(time.RFC3339))
+	return deletion, nil
+}
+
+// Run carries out due deletions until the context is cancelled
+func (w *AccountDeletionWorkflow) Run(ctx context.Context) {
+	ticker := time.NewTicker(deletionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.processDue(ctx)
+		}
+	}
+}
+
+// processDue deletes every account whose grace period is over
+func (w *AccountDeletionWorkflow) processDue(ctx context.Context) {
+	now := time.Now()
+	due, err := w.deletions.ListDue(ctx, now, now.Add(-deletionLease))
+	if err != nil {
+		log.Printf("Account Deletion Error: %v", err)
+		return
+	}
+
+	for _, deletion := range due {
+		w.delete(ctx, deletion)
+	}
+}
+
+// delete removes one account and reports the outcome to the team. The deletion is
+// claimed first so a CANCEL arriving meanwhile can't be confirmed to the user while
+// the account is deleted anyway.
+func (w *AccountDeletionWorkflow) delete(ctx context.Context, deletion models.AccountDeletion) {
+	claimed, err := w.deletions.Claim(ctx, deletion.ID, time.Now().Add(-deletionLease))
+	if err != nil {
+		log.Printf("Account Deletion Error: %v", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	if deletion.Status == models.DeletionDeleting {
+		log.Printf("Account Deletion: Retrying deletion %d of user %s, the last attempt never finished", deletion.ID, deletion.UserID)
+	}
+
+	// Outcomes that couldn't be recorded are retried once the claim is stale, the team
+	// only hears about recorded ones
+	if err := w.deleter.DeleteAccount(ctx, deletion.UserID); err != nil {
+		log.Printf("Account Deletion Error: Failed to delete user %s: %v", deletion.UserID, err)
+		finished, finishErr := w.deletions.Finish(ctx, deletion.ID, models.DeletionFailed, time.Now(), err)
+		if finishErr != nil {
+			log.Printf("Account Deletion Error: %v", finishErr)
+			return
+		}
+		if !finished {
+			return
+		}
+		w.notifyGroup(ctx, TemplateAccountDeletionFailedAlert, models.AccountDeletionMessageData{Deletion: &deletion, Error: err.Error()})
+		return
+	}
+
+	finished, err := w.deletions.Finish(ctx, deletion.ID, models.DeletionCompleted, time.Now(), nil)
+	if err != nil {
+		log.Printf("Account Deletion Error: %v", err)
+		return
+	}
+	if !finished {
+		return
+	}
+
+	log.Printf("Account Deletion: Deleted user %s (deletion %d)", deletion.UserID, deletion.ID)
+	if err := w.sendUser(ctx, deletion, TemplateAccountDeletionCompleted); err != nil {
+		log.Printf("Account Deletion Error: Failed to notify user %s: %v", deletion.UserID, err)
+	}
+	w.notifyGroup(ctx, TemplateAccountDeletionCompletedAlert, models.AccountDeletionMessageData{Deletion: &deletion})
+}
+
+// handleCancel cancels the pending deletion of a user who replies CANCEL
+func (w *AccountDeletionWorkflow) handleCancel(ctx context.Context, msg *InboundMessage) (bool, error) {
+	if !cancelReplies[normalizeReply(msg.Text)] {
+		return false, nil
+	}
+
+	deletion, err := w.deletions.GetPendingByPhone(ctx, msg.SenderPhone)
+	if errors.Is(err, repository.ErrDeletionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	cancelled, err := w.deletions.Finish(ctx, deletion.ID, models.DeletionCancelled, time.Now(), nil)
+	if err != nil {
+		return true, err
+	}
+	if !cancelled {
+		return false, nil
+	}
+
+	log.Printf("Account Deletion: Deletion %d of user %s cancelled by the user", deletion.ID, deletion.UserID)
+	// The user is writing to us right now, the reply goes out even during quiet hours
+	if err := w.sendUser(WithImmediateDelivery(ctx), deletion, TemplateAccountDeletionCancelled); err != nil {
+		return true, err
+	}
+	w.notifyGroup(ctx, TemplateAccountDeletionCancelledAlert, models.AccountDeletionMessageData{Deletion: &deletion})
+	return true, nil
+}
+
+// sendUser sends a message about the deletion to its user in their language
+func (w *AccountDeletionWorkflow) sendUser(ctx context.Context, deletion models.AccountDeletion, name string) error {
+	data := models.AccountDeletionMessageData{Deletion: &deletion}
+	return sendTemplate(ctx, w.messenger, w.templates, deletion.Locale, deletion.Phone, name, data)
+}
+
+// notifyGroup posts a status update to the groups handling account requests
+func (w *AccountDeletionWorkflow) notifyGroup(ctx context.Context, name string, data models.AccountDeletionMessageData) {
+	if err := sendAlert(ctx, w.messenger, w.templates, w.routes, models.CategoryAccount, name, data); err != nil {
+		log.Printf("Account Deletion Error: Failed to notify group: %v", err)
+	}
+}
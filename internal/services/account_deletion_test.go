@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+)
+
+// fakeDeleter records deleted accounts and fails with Err when set. During runs
+// while an account is being deleted.
+type fakeDeleter struct {
+	deleted []string
+	Err     error
+	During  func()
+}
+
+func (d *fakeDeleter) DeleteAccount(ctx context.Context, userID string) error {
+	if d.During != nil {
+		d.During()
+	}
+	if d.Err != nil {
+		return d.Err
+	}
+	d.deleted = append(d.deleted, userID)
+	return nil
+}
+
+func newTestDeletionWorkflow(grace time.Duration) (*AccountDeletionWorkflow, *repository.MemoryAccountDeletionRepository, *fakeDeleter, *FakeMessenger) {
+	deletions := repository.NewMemoryAccountDeletionRepository()
+	deleter := &fakeDeleter{}
+	messenger := NewFakeMessenger()
+	cfg := &config.Config{AccountDeletionGracePeriod: grace}
+	return NewAccountDeletionWorkflow(deletions, deleter, messenger, testTemplates, testGroupRouter, cfg), deletions, deleter, messenger
+}
+
+func TestAccountDeletionWorkflowDeletesAfterGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	workflow, deletions, deleter, messenger := newTestDeletionWorkflow(time.Hour)
+
+	deletion, err := workflow.Start(ctx, models.User{ID: "u1", Phone: "+91 90000 00001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := workflow.Start(ctx, models.User{ID: "u1", Phone: "919000000001"}); again.ID != deletion.ID {
+		t.Error("a repeated request must return the pending deletion")
+	}
+
+	workflow.processDue(ctx)
+	if len(deleter.deleted) != 0 {
+		t.Fatal("accounts must not be deleted during the grace period")
+	}
+
+	// A request made with an elapsed grace period is due right away
+	workflow.config.AccountDeletionGracePeriod = -time.Minute
+	workflow.Start(ctx, models.User{ID: "u2", Phone: "919000000002"})
+	workflow.processDue(ctx)
+
+	if len(deleter.deleted) != 1 || deleter.deleted[0] != "u2" {
+		t.Fatalf("expected u2 to be deleted, got %v", deleter.deleted)
+	}
+	done, _ := deletions.Get(2)
+	if done.Status != models.DeletionCompleted || done.CompletedAt == nil {
+		t.Errorf("unexpected deletion: %+v", done)
+	}
+	if groups := messenger.GroupMessages(); len(groups) != 1 || !strings.Contains(groups[0].Text, "Completed") {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+}
+
+func TestAccountDeletionWorkflowCancelReply(t *testing.T) {
+	ctx := context.Background()
+	workflow, deletions, deleter, messenger := newTestDeletionWorkflow(-time.Minute)
+	workflow.Start(ctx, models.User{ID: "u1", Phone: "919000000001"})
+
+	if handled, _ := workflow.handleCancel(ctx, intakeText("T1", "please wait")); handled {
+		t.Error("other replies must be left for other handlers")
+	}
+	handled, err := workflow.handleCancel(ctx, intakeText("T2", "Cancel!"))
+	if err != nil || !handled {
+		t.Fatalf("handled=%v err=%v", handled, err)
+	}
+
+	workflow.processDue(ctx)
+	if len(deleter.deleted) != 0 {
+		t.Error("a cancelled deletion must not run")
+	}
+	if deletion, _ := deletions.Get(1); deletion.Status != models.DeletionCancelled {
+		t.Errorf("unexpected status %s", deletion.Status)
+	}
+	if direct := messenger.DirectMessages(); len(direct) != 1 || !strings.Contains(direct[0].Text, "cancelled") {
+		t.Errorf("unexpected direct messages: %+v", direct)
+	}
+	if groups := messenger.GroupMessages(); len(groups) != 1 || !strings.Contains(groups[0].Text, "Cancelled") {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+
+	// Without a pending deletion CANCEL is left for other handlers
+	if handled, _ := workflow.handleCancel(ctx, intakeText("T3", "cancel")); handled {
+		t.Error("CANCEL without a pending deletion must not be consumed")
+	}
+}
+
+func TestAccountDeletionWorkflowIgnoresCancelWhileDeleting(t *testing.T) {
+	ctx := context.Background()
+	workflow, deletions, deleter, messenger := newTestDeletionWorkflow(-time.Minute)
+	workflow.Start(ctx, models.User{ID: "u1", Phone: "919000000001"})
+
+	deleter.During = func() {
+		if handled, _ := workflow.handleCancel(ctx, intakeText("T1", "cancel")); handled {
+			t.Error("CANCEL must not be confirmed once the deletion has started")
+		}
+	}
+	workflow.processDue(ctx)
+
+	if len(deleter.deleted) != 1 {
+		t.Fatalf("expected the account to be deleted, got %v", deleter.deleted)
+	}
+	if deletion, _ := deletions.Get(1); deletion.Status != models.DeletionCompleted {
+		t.Errorf("unexpected status %s", deletion.Status)
+	}
+	for _, message := range messenger.DirectMessages() {
+		if strings.Contains(message.Text, "cancelled") {
+			t.Errorf("the user must not be told the deletion was cancelled: %q", message.Text)
+		}
+	}
+}
+
+func TestAccountDeletionWorkflowReportsFailures(t *testing.T) {
+	ctx := context.Background()
+	workflow, deletions, deleter, messenger := newTestDeletionWorkflow(-time.Minute)
+	deleter.Err = errors.New("foreign key violation")
+	workflow.Start(ctx, models.User{ID: "u1", Phone: "919000000001"})
+
+	workflow.processDue(ctx)
+
+	deletion, _ := deletions.Get(1)
+	if deletion.Status != models.DeletionFailed || deletion.LastError == nil {
+		t.Errorf("unexpected deletion: %+v", deletion)
+	}
+	if groups := messenger.GroupMessages(); len(groups) != 1 || !strings.Contains(groups[0].Text, "delete the account manually") {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+}
+
+func TestAccountDeletionWorkflowRetriesStaleClaims(t *testing.T) {
+	ctx := context.Background()
+	workflow, deletions, deleter, messenger := newTestDeletionWorkflow(-time.Minute)
+	workflow.Start(ctx, models.User{ID: "u1", Phone: "919000000001"})
+
+	// A worker that died after claiming leaves the deletion in deleting
+	if claimed, err := deletions.Claim(ctx, 1, time.Now().Add(-deletionLease)); err != nil || !claimed {
+		t.Fatalf("claimed=%v err=%v", claimed, err)
+	}
+	workflow.processDue(ctx)
+	if len(deleter.deleted) != 0 {
+		t.Fatal("a deletion must not be retried while its claim is fresh")
+	}
+
+	deletions.AgeClaim(1, deletionLease+time.Minute)
+	workflow.processDue(ctx)
+
+	if len(deleter.deleted) != 1 || deleter.deleted[0] != "u1" {
+		t.Fatalf("expected u1 to be deleted, got %v", deleter.deleted)
+	}
+	if deletion, _ := deletions.Get(1); deletion.Status != models.DeletionCompleted {
+		t.Errorf("unexpected status %s", deletion.Status)
+	}
+	if groups := messenger.GroupMessages(); len(groups) != 1 || !strings.Contains(groups[0].Text, "Completed") {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+}
+
+func TestUserAccountDeleterIgnoresMissingUsers(t *testing.T) {
+	users := repository.NewMemoryUserRepository(models.User{ID: "u1"})
+	deleter := NewUserAccountDeleter(users)
+
+	if err := deleter.DeleteAccount(context.Background(), "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.GetByID(context.Background(), "u1"); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Error("user must be deleted")
+	}
+	if err := deleter.DeleteAccount(context.Background(), "u1"); err != nil {
+		t.Errorf("deleting twice must succeed, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+// ErrNotScheduled is returned when cancelling a message that isn't waiting for quiet hours to end
+var ErrNotScheduled = errors.New("message is not scheduled")
+
+// ScheduledMessages lets operators see and cancel messages deferred by quiet hours
+type ScheduledMessages interface {
+	ListScheduled(ctx context.Context) ([]models.OutboxMessage, error)
+	CancelScheduled(ctx context.Context, id int64) (models.OutboxMessage, error)
+}
+
+// DeliveryPolicy decides when messages to users may go out. Messages queued during
+// quiet hours are held until they end, group alerts are never held.
+type DeliveryPolicy struct {
+	location *time.Location
+	// start and end of the quiet hours in minutes after midnight, start == end disables them
+	start, end int
+}
+
+// NewDeliveryPolicy reads the quiet hours from the configuration
+func NewDeliveryPolicy(cfg *config.Config) (*DeliveryPolicy, error) {
+	location, err := time.LoadLocation(cfg.DeliveryTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DELIVERY_TIMEZONE: %v", err)
+	}
+	policy := &DeliveryPolicy{location: location}
+	if cfg.QuietHoursStart == "" && cfg.QuietHoursEnd == "" {
+		return policy, nil
+	}
+
+	if policy.start, err = parseTimeOfDay(cfg.QuietHoursStart); err != nil {
+		return nil, fmt.Errorf("invalid QUIET_HOURS_START: %v", err)
+	}
+	if policy.end, err = parseTimeOfDay(cfg.QuietHoursEnd); err != nil {
+		return nil, fmt.Errorf("invalid QUIET_HOURS_END: %v", err)
+	}
+	return policy, nil
+}
+
+// Enabled reports whether messages are ever held back
+func (p *DeliveryPolicy) Enabled() bool {
+	return p != nil && p.start != p.end
+}
+
+// Next returns the earliest time from t a message to a user may go out, t itself
+// outside quiet hours. Quiet hours may span midnight, e.g. 21:00 to 08:00.
+func (p *DeliveryPolicy) Next(t time.Time) time.Time {
+	if !p.Enabled() {
+		return t
+	}
+
+	local := t.In(p.location)
+	minute := local.Hour()*60 + local.Minute()
+	endToday := time.Date(local.Year(), local.Month(), local.Day(), p.end/60, p.end%60, 0, 0, p.location)
+
+	if p.start < p.end {
+		if minute >= p.start && minute < p.end {
+			return endToday
+		}
+		return t
+	}
+
+	// The quiet hours span midnight
+	switch {
+	case minute >= p.start:
+		return endToday.AddDate(0, 0, 1)
+	case minute < p.end:
+		return endToday
+	}
+	return t
+}
+
+// parseTimeOfDay parses "HH:MM" into minutes after midnight
+func parseTimeOfDay(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time of day like 21:00", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+type immediateDeliveryKey struct{}
+
+// WithImmediateDelivery marks messages queued with the context as exempt from quiet
+// hours, e.g. replies to a user who is writing to us right now
+func WithImmediateDelivery(ctx context.Context) context.Context {
+	return context.WithValue(ctx, immediateDeliveryKey{}, true)
+}
+
+// immediateDelivery reports whether the context was marked by WithImmediateDelivery
+func immediateDelivery(ctx context.Context) bool {
+	immediate, _ := ctx.Value(immediateDeliveryKey{}).(bool)
+	return immediate
+}
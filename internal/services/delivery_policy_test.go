@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+func TestDeliveryPolicyNext(t *testing.T) {
+	ist, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, ist)
+	}
+
+	tests := []struct {
+		name       string
+		start, end string
+		now        time.Time
+		want       time.Time
+	}{
+		{"daytime", "21:00", "08:00", at(10, 14, 0), at(10, 14, 0)},
+		{"evening", "21:00", "08:00", at(10, 21, 0), at(11, 8, 0)},
+		{"2 AM", "21:00", "08:00", at(11, 2, 0), at(11, 8, 0)},
+		{"end of quiet hours", "21:00", "08:00", at(11, 8, 0), at(11, 8, 0)},
+		{"same day window", "13:00", "15:30", at(10, 14, 0), at(10, 15, 30)},
+		{"before same day window", "13:00", "15:30", at(10, 12, 59), at(10, 12, 59)},
+		{"disabled", "", "", at(11, 2, 0), at(11, 2, 0)},
+		// 20:45 UTC is 02:15 the next day in IST
+		{"other timezone", "21:00", "08:00", time.Date(2026, time.March, 10, 20, 45, 0, 0, time.UTC), at(11, 8, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewDeliveryPolicy(&config.Config{QuietHoursStart: tt.start, QuietHoursEnd: tt.end, DeliveryTimezone: "Asia/Kolkata"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := policy.Next(tt.now); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDeliveryPolicyRejectsInvalidConfig(t *testing.T) {
+	tests := []config.Config{
+		{QuietHoursStart: "9pm", QuietHoursEnd: "08:00", DeliveryTimezone: "Asia/Kolkata"},
+		{QuietHoursStart: "21:00", QuietHoursEnd: "", DeliveryTimezone: "Asia/Kolkata"},
+		{QuietHoursStart: "21:00", QuietHoursEnd: "08:00", DeliveryTimezone: "Mars/Olympus"},
+	}
+	for _, cfg := range tests {
+		if _, err := NewDeliveryPolicy(&cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestImmediateDelivery(t *testing.T) {
+	if immediateDelivery(context.Background()) {
+		t.Error("plain contexts must follow quiet hours")
+	}
+	if !immediateDelivery(WithImmediateDelivery(context.Background())) {
+		t.Error("expected the context to be marked for immediate delivery")
+	}
+
+	// Only the replies to an inbound message go out right away
+	router, _ := newTestInboundRouter()
+	var immediate bool
+	router.Handle(models.ChatDirect, models.ContentText, func(ctx context.Context, msg *InboundMessage) (bool, error) {
+		immediate = immediateDelivery(ctx)
+		return true, nil
+	})
+	router.handleEvent(textEvent("M1", "919000000001", "hello"))
+	if immediate {
+		t.Error("inbound handlers must mark their replies themselves")
+	}
+
+	ctx := context.Background()
+	flow, _, messenger := newTestIntakeFlow(t)
+	flow.Start(ctx, models.User{ID: "u1", Phone: "919000000001"})
+	flow.handle(ctx, intakeText("T1", "done"))
+	flow.config.RentalIntakeTimeout = -time.Minute
+	flow.expireInactive(ctx)
+
+	direct := messenger.DirectMessages()
+	if len(direct) != 3 || direct[0].Immediate || !direct[1].Immediate || direct[2].Immediate {
+		t.Errorf("expected only the reply to be immediate, got %+v", direct)
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+// SentMessage is a message captured by FakeMessenger
+type SentMessage struct {
+	RecipientType models.RecipientType
+	Recipient     string
+	Text          string
+	Media         *models.MediaAttachment
+	Location      *models.Location
+	Preview       *models.LinkPreview
+	// Immediate is set for direct messages queued with WithImmediateDelivery
+	Immediate bool
+}
+
+// FakeMessenger is an in-memory Messenger that records every send instead of
+// talking to WhatsApp. Set Err to make all sends fail.
+type FakeMessenger struct {
+	Err error
+
+	mu           sync.Mutex
+	messages     []SentMessage
+	unregistered map[string]bool
+}
+
+var _ Messenger = (*FakeMessenger)(nil)
+
+// NewFakeMessenger creates an empty recording messenger
+func NewFakeMessenger() *FakeMessenger {
+	return &FakeMessenger{}
+}
+
+// SendMessage records a direct message
+func (f *FakeMessenger) SendMessage(ctx context.Context, phoneNumber, message string) error {
+	return f.record(ctx, models.RecipientUser, phoneNumber, message)
+}
+
+// SendGroupMessage records a group message
+func (f *FakeMessenger) SendGroupMessage(ctx context.Context, groupJID, message string) error {
+	return f.record(ctx, models.RecipientGroup, groupJID, message)
+}
+
+// SendMedia records a direct media message, the caption becomes its text
+func (f *FakeMessenger) SendMedia(ctx context.Context, phoneNumber string, media models.MediaAttachment, caption string) error {
+	return f.recordMessage(ctx, SentMessage{RecipientType: models.RecipientUser, Recipient: phoneNumber, Text: caption, Media: &media})
+}
+
+// SendGroupMedia records a group media message, the caption becomes its text
+func (f *FakeMessenger) SendGroupMedia(ctx context.Context, groupJID string, media models.MediaAttachment, caption string) error {
+	return f.recordMessage(ctx, SentMessage{RecipientType: models.RecipientGroup, Recipient: groupJID, Text: caption, Media: &media})
+}
+
+// SendLinkPreview records a direct message with a link preview
+func (f *FakeMessenger) SendLinkPreview(ctx context.Context, phoneNumber, message string, preview models.LinkPreview) error {
+	return f.recordMessage(ctx, SentMessage{RecipientType: models.RecipientUser, Recipient: phoneNumber, Text: message, Preview: &preview})
+}
+
+// SendGroupLinkPreview records a group message with a link preview
+func (f *FakeMessenger) SendGroupLinkPreview(ctx context.Context, groupJID, message string, preview models.LinkPreview) error {
+	return f.recordMessage(ctx, SentMessage{RecipientType: models.RecipientGroup, Recipient: groupJID, Text: message, Preview: &preview})
+}
+
+// SendLocation records a direct location pin, its name becomes the text
+func (f *FakeMessenger) SendLocation(ctx context.Context, phoneNumber string, location models.Location) error {
+	return f.recordMessage(ctx, SentMessage{RecipientType: models.RecipientUser, Recipient: phoneNumber, Text: location.Name, Location: &location})
+}
+
+// SendGroupLocation records a group location pin, its name becomes the text
+func (f *FakeMessenger) SendGroupLocation(ctx context.Context, groupJID string, location models.Location) error {
+	return f.recordMessage(ctx, SentMessage{RecipientType: models.RecipientGroup, Recipient: groupJID, Text: location.Name, Location: &location})
+}
+
+// SetNotOnWhatsApp makes direct sends to the given phone numbers fail with ErrNotOnWhatsApp
+func (f *FakeMessenger) SetNotOnWhatsApp(phoneNumbers ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.unregistered == nil {
+		f.unregistered = make(map[string]bool)
+	}
+	for _, phoneNumber := range phoneNumbers {
+		f.unregistered[phoneNumber] = true
+	}
+}
+
+// Messages returns every recorded message in send order
+func (f *FakeMessenger) Messages() []SentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]SentMessage(nil), f.messages...)
+}
+
+// DirectMessages returns the recorded messages sent to phone numbers
+func (f *FakeMessenger) DirectMessages() []SentMessage {
+	return f.filter(models.RecipientUser)
+}
+
+// GroupMessages returns the recorded messages sent to groups
+func (f *FakeMessenger) GroupMessages() []SentMessage {
+	return f.filter(models.RecipientGroup)
+}
+
+// Reset forgets all recorded messages
+func (f *FakeMessenger) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = nil
+}
+
+func (f *FakeMessenger) record(ctx context.Context, recipientType models.RecipientType, recipient, text string) error {
+	return f.recordMessage(ctx, SentMessage{
+		RecipientType: recipientType,
+		Recipient:     recipient,
+		Text:          text,
+	})
+}
+
+func (f *FakeMessenger) recordMessage(ctx context.Context, msg SentMessage) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	msg.Immediate = msg.RecipientType == models.RecipientUser && immediateDelivery(ctx)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if msg.RecipientType == models.RecipientUser && f.unregistered[msg.Recipient] {
+		return fmt.Errorf("%w: %s", ErrNotOnWhatsApp, msg.Recipient)
+	}
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+func (f *FakeMessenger) filter(recipientType models.RecipientType) []SentMessage {
+	var filtered []SentMessage
+	for _, msg := range f.Messages() {
+		if msg.RecipientType == recipientType {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+func TestFakeMessengerRecordsSends(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeMessenger()
+
+	fake.SendMessage(ctx, "919000000001", "hello")
+	fake.SendGroupMessage(ctx, "123@g.us", "New Sell Request Received")
+	fake.SendMedia(ctx, "919000000001", models.MediaAttachment{Kind: models.MediaDocument, Path: "brochure.pdf"}, "caption")
+
+	if got := len(fake.Messages()); got != 3 {
+		t.Fatalf("expected 3 messages, got %d", got)
+	}
+	if direct := fake.DirectMessages(); len(direct) != 2 || direct[0].Text != "hello" || direct[1].Media.Path != "brochure.pdf" {
+		t.Errorf("unexpected direct messages: %+v", direct)
+	}
+	if groups := fake.GroupMessages(); len(groups) != 1 || groups[0].Recipient != "123@g.us" {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+
+	fake.Reset()
+	if len(fake.Messages()) != 0 {
+		t.Error("expected Reset to clear recorded messages")
+	}
+}
+
+func TestFakeMessengerFailsWithErr(t *testing.T) {
+	fake := NewFakeMessenger()
+	fake.Err = errors.New("offline")
+
+	if err := fake.SendGroupMessage(context.Background(), "123@g.us", "hi"); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(fake.Messages()) != 0 {
+		t.Error("failed sends must not be recorded")
+	}
+}
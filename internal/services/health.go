@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+const (
+	// healthCheckTimeout bounds the database queries of a readiness check
+	healthCheckTimeout = 2 * time.Second
+	// livenessWatchInterval is how often Watch checks liveness
+	livenessWatchInterval = 30 * time.Second
+)
+
+// DatabasePinger checks that the database answers
+type DatabasePinger interface {
+	Ping(ctx context.Context) error
+}
+
+// OutboxStatsProvider summarizes the outbound message queue
+type OutboxStatsProvider interface {
+	Stats(ctx context.Context) (models.OutboxStats, error)
+}
+
+// PairedChecker tells whether the WhatsApp device is linked
+type PairedChecker interface {
+	IsPaired() bool
+}
+
+// HealthReporter answers liveness and readiness probes
+type HealthReporter interface {
+	// Liveness fails when restarting the service could bring it back
+	Liveness() (models.HealthReport, bool)
+	// Readiness fails when the service can't deliver messages right now
+	Readiness(ctx context.Context) (models.HealthReport, bool)
+}
+
+// HealthMonitor reports the state of the WhatsApp session, the database and the outbox
+type HealthMonitor struct {
+	db         DatabasePinger
+	connection ConnectionStatusProvider
+	session    PairedChecker
+	outbox     OutboxStatsProvider
+	config     *config.Config
+}
+
+var _ HealthReporter = (*HealthMonitor)(nil)
+
+// NewHealthMonitor creates a health monitor
+func NewHealthMonitor(db DatabasePinger, connection ConnectionStatusProvider, session PairedChecker, outbox OutboxStatsProvider, cfg *config.Config) *HealthMonitor {
+	return &HealthMonitor{
+		db:         db,
+		connection: connection,
+		session:    session,
+		outbox:     outbox,
+		config:     cfg,
+	}
+}
+
+// Liveness only looks at the WhatsApp session. A session that stays down although
+// the supervisor keeps reconnecting is worth a restart. A device waiting to be paired
+// is not, the pairing would be lost.
+func (h *HealthMonitor) Liveness() (models.HealthReport, bool) {
+	report := h.report()
+
+	switch report.WhatsApp.State {
+	case models.ConnectionConnected, models.ConnectionPairing:
+	default:
+		if down := time.Since(report.WhatsApp.Since); down > h.config.HealthMaxDisconnected {
+			report.Problems = append(report.Problems, fmt.Sprintf("WhatsApp has been %s for %s", report.WhatsApp.State, down.Round(time.Second)))
+		}
+	}
+	return h.finish(report)
+}
+
+// Watch checks liveness until ctx is cancelled and returns an error once it fails,
+// so the process can exit and be restarted by its supervisor. A Docker HEALTHCHECK
+// alone only marks the container unhealthy.
+func (h *HealthMonitor) Watch(ctx context.Context) error {
+	return h.watch(ctx, livenessWatchInterval)
+}
+
+// watch checks liveness every interval
+func (h *HealthMonitor) watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if report, ok := h.Liveness(); !ok {
+				return fmt.Errorf("liveness failed: %s", strings.Join(report.Problems, ", "))
+			}
+		}
+	}
+}
+
+// Readiness checks everything needed to deliver messages
+func (h *HealthMonitor) Readiness(ctx context.Context) (models.HealthReport, bool) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	report := h.report()
+
+	switch {
+	case !report.WhatsApp.Paired:
+		report.Problems = append(report.Problems, "WhatsApp device is not paired")
+	case report.WhatsApp.State != models.ConnectionConnected:
+		report.Problems = append(report.Problems, fmt.Sprintf("WhatsApp is %s", report.WhatsApp.State))
+	}
+
+	started := time.Now()
+	err := h.db.Ping(ctx)
+	database := models.DatabaseHealth{
+		OK:        err == nil,
+		LatencyMS: float64(time.Since(started).Microseconds()) / 1000,
+	}
+	if err != nil {
+		database.Error = err.Error()
+		report.Problems = append(report.Problems, "database is unreachable")
+	}
+	report.Database = &database
+
+	// Queue stats are informational, the ping already covers the database
+	if err == nil {
+		if stats, err := h.outbox.Stats(ctx); err == nil {
+			report.Outbox = &stats
+		}
+	}
+	return h.finish(report)
+}
+
+// report starts a health report with the state of the WhatsApp session
+func (h *HealthMonitor) report() models.HealthReport {
+	status := h.connection.Status()
+	return models.HealthReport{
+		WhatsApp: models.WhatsAppHealth{
+			State:  status.State,
+			Since:  status.Since,
+			Paired: h.session.IsPaired(),
+		},
+		CheckedAt: time.Now(),
+	}
+}
+
+// finish sets the overall status of a report
+func (h *HealthMonitor) finish(report models.HealthReport) (models.HealthReport, bool) {
+	if len(report.Problems) > 0 {
+		report.Status = models.HealthUnavailable
+		return report, false
+	}
+	report.Status = models.HealthOK
+	return report, true
+}
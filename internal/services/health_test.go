@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+type fakeConnection models.ConnectionStatus
+
+func (f fakeConnection) Status() models.ConnectionStatus { return models.ConnectionStatus(f) }
+
+type fakePaired bool
+
+func (f fakePaired) IsPaired() bool { return bool(f) }
+
+type fakePinger struct{ err error }
+
+func (f fakePinger) Ping(ctx context.Context) error { return f.err }
+
+type fakeOutboxStats struct{ stats models.OutboxStats }
+
+func (f fakeOutboxStats) Stats(ctx context.Context) (models.OutboxStats, error) { return f.stats, nil }
+
+func TestHealthMonitorLiveness(t *testing.T) {
+	cfg := &config.Config{HealthMaxDisconnected: 10 * time.Minute}
+	tests := []struct {
+		name  string
+		state models.ConnectionState
+		since time.Duration
+		want  bool
+	}{
+		{"connected", models.ConnectionConnected, time.Hour, true},
+		{"briefly reconnecting", models.ConnectionReconnecting, time.Minute, true},
+		{"reconnecting for too long", models.ConnectionReconnecting, time.Hour, false},
+		{"disconnected for too long", models.ConnectionDisconnected, time.Hour, false},
+		{"waiting to be paired", models.ConnectionPairing, time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection := fakeConnection{State: tt.state, Since: time.Now().Add(-tt.since)}
+			health := NewHealthMonitor(fakePinger{}, connection, fakePaired(true), fakeOutboxStats{}, cfg)
+
+			report, ok := health.Liveness()
+			if ok != tt.want {
+				t.Errorf("Liveness() = %v (%v), want %v", ok, report.Problems, tt.want)
+			}
+			if ok && report.Status != models.HealthOK || !ok && report.Status != models.HealthUnavailable {
+				t.Errorf("status = %q for ok=%v", report.Status, ok)
+			}
+		})
+	}
+}
+
+func TestHealthMonitorWatch(t *testing.T) {
+	cfg := &config.Config{HealthMaxDisconnected: 10 * time.Minute}
+	down := fakeConnection{State: models.ConnectionReconnecting, Since: time.Now().Add(-time.Hour)}
+	health := NewHealthMonitor(fakePinger{}, down, fakePaired(true), fakeOutboxStats{}, cfg)
+	if err := health.watch(context.Background(), time.Millisecond); err == nil {
+		t.Error("Watch must return once liveness fails")
+	}
+
+	up := fakeConnection{State: models.ConnectionConnected, Since: time.Now()}
+	health = NewHealthMonitor(fakePinger{}, up, fakePaired(true), fakeOutboxStats{}, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := health.watch(ctx, time.Millisecond); err != nil {
+		t.Errorf("Watch must keep going while live, got %v", err)
+	}
+}
+
+func TestHealthMonitorReadiness(t *testing.T) {
+	cfg := &config.Config{HealthMaxDisconnected: 10 * time.Minute}
+	sent := time.Now().Add(-time.Minute)
+	stats := fakeOutboxStats{models.OutboxStats{Pending: 3, LastSentAt: &sent}}
+	connected := fakeConnection{State: models.ConnectionConnected, Since: time.Now()}
+
+	tests := []struct {
+		name       string
+		connection fakeConnection
+		paired     bool
+		dbErr      error
+		want       bool
+		outbox     bool
+	}{
+		{"ready", connected, true, nil, true, true},
+		{"not paired", fakeConnection{State: models.ConnectionPairing}, false, nil, false, true},
+		{"reconnecting", fakeConnection{State: models.ConnectionReconnecting}, true, nil, false, true},
+		{"database down", connected, true, errors.New("connection refused"), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := NewHealthMonitor(fakePinger{tt.dbErr}, tt.connection, fakePaired(tt.paired), stats, cfg)
+
+			report, ok := health.Readiness(context.Background())
+			if ok != tt.want {
+				t.Errorf("Readiness() = %v (%v), want %v", ok, report.Problems, tt.want)
+			}
+			if report.Database == nil || report.Database.OK != (tt.dbErr == nil) {
+				t.Errorf("database = %+v, want ok=%v", report.Database, tt.dbErr == nil)
+			}
+			if (report.Outbox != nil) != tt.outbox {
+				t.Errorf("outbox = %+v, want present=%v", report.Outbox, tt.outbox)
+			}
+			if report.Outbox != nil && (report.Outbox.Pending != 3 || report.Outbox.LastSentAt == nil) {
+				t.Errorf("outbox = %+v, want the queue stats", report.Outbox)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// inboundTimeout bounds how long the handlers of a single inbound message may run
+const inboundTimeout = 30 * time.Second
+
+// InboundMessage is a received WhatsApp message matched against our users
+type InboundMessage struct {
+	ID          string
+	ChatJID     types.JID
+	SenderPhone string
+	// User is the registered user with the sender's phone number, nil for unknown senders
+	User        *models.User
+	ChatType    models.ChatType
+	ContentType models.ContentType
+	// Text holds the message text, or the caption of a media message
+	Text      string
+	Timestamp time.Time
+	// Event is the raw whatsmeow event, handlers use it to read locations or download media
+	Event *events.Message
+}
+
+// InboundHandler handles an inbound message. It returns true when it consumed
+// the message, which stops the message from reaching handlers registered later.
+type InboundHandler func(ctx context.Context, msg *InboundMessage) (bool, error)
+
+type inboundRoute struct {
+	chatType    models.ChatType
+	contentType models.ContentType
+}
+
+// InboundRouter logs every inbound message to the conversation log and hands it
+// to the handlers registered for its chat type and content type
+type InboundRouter struct {
+	users         repository.UserRepository
+	conversations repository.ConversationRepository
+
+	mu       sync.RWMutex
+	handlers map[inboundRoute][]InboundHandler
+}
+
+// NewInboundRouter creates a router without any handlers
+func NewInboundRouter(users repository.UserRepository, conversations repository.ConversationRepository) *InboundRouter {
+	return &InboundRouter{
+		users:         users,
+		conversations: conversations,
+		handlers:      make(map[inboundRoute][]InboundHandler),
+	}
+}
+
+// Handle registers a handler for messages of the given chat and content type.
+// Handlers run in registration order until one of them consumes the message.
+func (r *InboundRouter) Handle(chatType models.ChatType, contentType models.ContentType, handler InboundHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	route := inboundRoute{chatType: chatType, contentType: contentType}
+	r.handlers[route] = append(r.handlers[route], handler)
+}
+
+// Attach subscribes the router to messages received by the WhatsApp service
+func (r *InboundRouter) Attach(whatsapp *WhatsAppService) {
+	whatsapp.AddEventHandler(func(evt interface{}) {
+		if msg, ok := evt.(*events.Message); ok {
+			r.handleEvent(msg)
+		}
+	})
+}
+
+// handleEvent turns a whatsmeow message event into an InboundMessage and routes it
+func (r *InboundRouter) handleEvent(evt *events.Message) {
+	// Skip our own messages and status updates
+	if evt.Info.IsFromMe || evt.Info.Chat.Server == types.BroadcastServer {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), inboundTimeout)
+	defer cancel()
+
+	msg := newInboundMessage(evt)
+	if err := r.Route(ctx, msg); err != nil {
+		log.Printf("Inbound Error: Failed to handle message %s from %s: %v", msg.ID, msg.SenderPhone, err)
+	}
+}
+
+// Route matches the sender, stores the message in the conversation log and
+// dispatches it to the registered handlers. Redelivered messages are dropped unless
+// their handlers failed the last time.
+func (r *InboundRouter) Route(ctx context.Context, msg *InboundMessage) error {
+	if msg.User == nil && msg.SenderPhone != "" {
+		user, err := r.users.GetByPhone(ctx, msg.SenderPhone)
+		switch {
+		case err == nil:
+			msg.User = &user
+		case !errors.Is(err, repository.ErrUserNotFound):
+			return fmt.Errorf("failed to match sender: %v", err)
+		}
+	}
+
+	var userID *string
+	if msg.User != nil {
+		userID = &msg.User.ID
+	}
+	isNew, err := r.conversations.Append(ctx, models.ConversationMessage{
+		MessageID:   msg.ID,
+		ChatJID:     msg.ChatJID.String(),
+		SenderPhone: msg.SenderPhone,
+		UserID:      userID,
+		ChatType:    msg.ChatType,
+		ContentType: msg.ContentType,
+		Body:        msg.Text,
+		ReceivedAt:  msg.Timestamp,
+	})
+	if err != nil {
+		return err
+	}
+	if !isNew {
+		log.Printf("Inbound: Message %s was already handled, skipping", msg.ID)
+		return nil
+	}
+
+	err = r.dispatch(ctx, msg)
+	r.finish(ctx, msg, err)
+	return err
+}
+
+// finish records the outcome of the handlers so a failed message is handled again
+// when WhatsApp redelivers it
+func (r *InboundRouter) finish(ctx context.Context, msg *InboundMessage, err error) {
+	status := models.ConversationHandled
+	if err != nil {
+		status = models.ConversationFailed
+	}
+	// Record the outcome even when the handlers ran out of time
+	if err := r.conversations.Finish(context.WithoutCancel(ctx), msg.ID, status, err); err != nil {
+		log.Printf("Inbound Error: Failed to record the outcome of message %s: %v", msg.ID, err)
+	}
+}
+
+// dispatch runs the handlers registered for the message until one consumes it
+func (r *InboundRouter) dispatch(ctx context.Context, msg *InboundMessage) error {
+	r.mu.RLock()
+	handlers := r.handlers[inboundRoute{chatType: msg.ChatType, contentType: msg.ContentType}]
+	r.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handled, err := handler(ctx, msg)
+		if err != nil {
+			return err
+		}
+		if handled {
+			return nil
+		}
+	}
+
+	log.Printf("Inbound: No handler consumed %s %s message %s from %s", msg.ChatType, msg.ContentType, msg.ID, msg.SenderPhone)
+	return nil
+}
+
+// newInboundMessage extracts the routing information from a whatsmeow message event
+func newInboundMessage(evt *events.Message) *InboundMessage {
+	chatType := models.ChatDirect
+	if evt.Info.IsGroup {
+		chatType = models.ChatGroup
+	}
+
+	contentType, text := classifyContent(evt.Message)
+
+	return &InboundMessage{
+		ID:          string(evt.Info.ID),
+		ChatJID:     evt.Info.Chat,
+		SenderPhone: senderPhone(evt.Info.MessageSource),
+		ChatType:    chatType,
+		ContentType: contentType,
+		Text:        text,
+		Timestamp:   evt.Info.Timestamp,
+		Event:       evt,
+	}
+}
+
+// senderPhone returns the phone number of the sender, falling back to the
+// alternative address when WhatsApp hides the number behind a LID
+func senderPhone(source types.MessageSource) string {
+	sender := source.Sender
+	if sender.Server == types.HiddenUserServer && source.SenderAlt.Server == types.DefaultUserServer {
+		sender = source.SenderAlt
+	}
+	if sender.Server != types.DefaultUserServer {
+		return ""
+	}
+	return sender.User
+}
+
+// classifyContent returns the content type of a message and its text or caption.
+// whatsmeow already unwraps ephemeral, view-once and captioned document wrappers.
+func classifyContent(msg *waE2E.Message) (models.ContentType, string) {
+	switch {
+	case msg.GetConversation() != "":
+		return models.ContentText, msg.GetConversation()
+	case msg.GetExtendedTextMessage() != nil:
+		return models.ContentText, msg.GetExtendedTextMessage().GetText()
+	case msg.GetImageMessage() != nil:
+		return models.ContentImage, msg.GetImageMessage().GetCaption()
+	case msg.GetLocationMessage() != nil:
+		location := msg.GetLocationMessage()
+		return models.ContentLocation, fmt.Sprintf("%f,%f", location.GetDegreesLatitude(), location.GetDegreesLongitude())
+	case msg.GetLiveLocationMessage() != nil:
+		location := msg.GetLiveLocationMessage()
+		return models.ContentLocation, fmt.Sprintf("%f,%f", location.GetDegreesLatitude(), location.GetDegreesLongitude())
+	case msg.GetDocumentMessage() != nil:
+		document := msg.GetDocumentMessage()
+		if document.GetCaption() != "" {
+			return models.ContentDocument, document.GetCaption()
+		}
+		return models.ContentDocument, document.GetFileName()
+	default:
+		return models.ContentOther, ""
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestInboundRouter() (*InboundRouter, *repository.MemoryConversationRepository) {
+	users := repository.NewMemoryUserRepository(models.User{ID: "u1", Name: "Asha", Phone: "+91 90000 00001"})
+	conversations := repository.NewMemoryConversationRepository()
+	return NewInboundRouter(users, conversations), conversations
+}
+
+func textEvent(id, sender, text string) *events.Message {
+	jid := types.NewJID(sender, types.DefaultUserServer)
+	return &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: jid, Sender: jid},
+			ID:            types.MessageID(id),
+			Timestamp:     time.Now(),
+		},
+		Message: &waE2E.Message{Conversation: proto.String(text)},
+	}
+}
+
+func TestInboundRouterMatchesUserAndDispatches(t *testing.T) {
+	router, conversations := newTestInboundRouter()
+
+	var got []*InboundMessage
+	router.Handle(models.ChatDirect, models.ContentImage, func(ctx context.Context, msg *InboundMessage) (bool, error) {
+		t.Error("image handler must not receive text")
+		return true, nil
+	})
+	router.Handle(models.ChatDirect, models.ContentText, func(ctx context.Context, msg *InboundMessage) (bool, error) {
+		got = append(got, msg)
+		return true, nil
+	})
+	router.Handle(models.ChatDirect, models.ContentText, func(ctx context.Context, msg *InboundMessage) (bool, error) {
+		t.Error("handler after a consuming handler must not run")
+		return false, nil
+	})
+
+	router.handleEvent(textEvent("M1", "919000000001", "hello"))
+	// WhatsApp may redeliver a message, it must only be handled once
+	router.handleEvent(textEvent("M1", "919000000001", "hello"))
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 dispatched message, got %d", len(got))
+	}
+	if got[0].User == nil || got[0].User.ID != "u1" || got[0].Text != "hello" {
+		t.Errorf("unexpected message: %+v", got[0])
+	}
+
+	logged, _ := conversations.ListBySender(context.Background(), "919000000001", 10)
+	if len(logged) != 1 || logged[0].UserID == nil || *logged[0].UserID != "u1" {
+		t.Errorf("unexpected conversation log: %+v", logged)
+	}
+}
+
+func TestInboundRouterUnknownSender(t *testing.T) {
+	router, conversations := newTestInboundRouter()
+
+	var user *models.User
+	dispatched := false
+	router.Handle(models.ChatDirect, models.ContentText, func(ctx context.Context, msg *InboundMessage) (bool, error) {
+		dispatched, user = true, msg.User
+		return true, nil
+	})
+
+	router.handleEvent(textEvent("M2", "919999999999", "who is this"))
+
+	if !dispatched || user != nil {
+		t.Errorf("expected dispatch without a user, got dispatched=%v user=%v", dispatched, user)
+	}
+	logged, _ := conversations.ListBySender(context.Background(), "919999999999", 10)
+	if len(logged) != 1 || logged[0].UserID != nil {
+		t.Errorf("unexpected conversation log: %+v", logged)
+	}
+}
+
+func TestInboundRouterReturnsHandlerErrors(t *testing.T) {
+	router, _ := newTestInboundRouter()
+	router.Handle(models.ChatDirect, models.ContentText, func(ctx context.Context, msg *InboundMessage) (bool, error) {
+		return false, errors.New("boom")
+	})
+
+	msg := newInboundMessage(textEvent("M3", "919000000001", "hi"))
+	if err := router.Route(context.Background(), msg); err == nil {
+		t.Fatal("expected handler error")
+	}
+}
+
+func TestInboundRouterRetriesFailedMessages(t *testing.T) {
+	router, conversations := newTestInboundRouter()
+	calls := 0
+	router.Handle(models.ChatDirect, models.ContentText, func(ctx context.Context, msg *InboundMessage) (bool, error) {
+		calls++
+		if calls == 1 {
+			return false, errors.New("database unavailable")
+		}
+		return true, nil
+	})
+
+	// The redelivery of a message whose handlers failed is handled again, once
+	for i := 0; i < 3; i++ {
+		router.handleEvent(textEvent("M4", "919000000001", "hi"))
+	}
+
+	if calls != 2 {
+		t.Errorf("expected the failed message to be handled twice, got %d", calls)
+	}
+	logged, _ := conversations.ListBySender(context.Background(), "919000000001", 10)
+	if len(logged) != 1 || logged[0].Status != models.ConversationHandled || logged[0].LastError != nil {
+		t.Errorf("unexpected conversation log: %+v", logged)
+	}
+}
+
+func TestClassifyContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      *waE2E.Message
+		wantType models.ContentType
+		wantText string
+	}{
+		{"conversation", &waE2E.Message{Conversation: proto.String("hi")}, models.ContentText, "hi"},
+		{"extended text", &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{Text: proto.String("link")}}, models.ContentText, "link"},
+		{"image", &waE2E.Message{ImageMessage: &waE2E.ImageMessage{Caption: proto.String("front")}}, models.ContentImage, "front"},
+		{"location", &waE2E.Message{LocationMessage: &waE2E.LocationMessage{DegreesLatitude: proto.Float64(15.5), DegreesLongitude: proto.Float64(75.25)}}, models.ContentLocation, "15.500000,75.250000"},
+		{"document", &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{FileName: proto.String("utaara.pdf")}}, models.ContentDocument, "utaara.pdf"},
+		{"sticker", &waE2E.Message{StickerMessage: &waE2E.StickerMessage{}}, models.ContentOther, ""},
+	}
+
+	for _, tt := range tests {
+		gotType, gotText := classifyContent(tt.msg)
+		if gotType != tt.wantType || gotText != tt.wantText {
+			t.Errorf("%s: got %s %q, want %s %q", tt.name, gotType, gotText, tt.wantType, tt.wantText)
+		}
+	}
+}
+
+func TestSenderPhonePrefersPhoneNumberOverLID(t *testing.T) {
+	source := types.MessageSource{
+		Sender:    types.NewJID("123456789", types.HiddenUserServer),
+		SenderAlt: types.NewJID("919000000001", types.DefaultUserServer),
+	}
+	if got := senderPhone(source); got != "919000000001" {
+		t.Errorf("senderPhone = %q", got)
+	}
+}
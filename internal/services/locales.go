@@ -0,0 +1,48 @@
+package services
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultLocale is the locale messages fall back to when none is configured
+const DefaultLocale = "en"
+
+// localeAliases maps the language names stored in users.pref_lang to locale tags
+var localeAliases = map[string]string{
+	"english": "en",
+	"kannada": "kn",
+	"ಕನ್ನಡ":   "kn",
+	"hindi":   "hi",
+	"हिन्दी":  "hi",
+	"हिंदी":   "hi",
+}
+
+// normalizeLocale turns a preference like "kn_IN", "Kannada" or " HI " into a
+// lowercase locale tag
+func normalizeLocale(value string) string {
+	locale := strings.ToLower(strings.TrimSpace(value))
+	if alias, ok := localeAliases[locale]; ok {
+		return alias
+	}
+	return strings.ReplaceAll(locale, "_", "-")
+}
+
+// localeChain returns the locales tried for a preference, most specific first:
+// "kn-IN" tries kn-in, then kn, then the fallback locale
+func localeChain(preference, fallback string) []string {
+	var chain []string
+	add := func(locale string) {
+		if locale != "" && !slices.Contains(chain, locale) {
+			chain = append(chain, locale)
+		}
+	}
+
+	locale := normalizeLocale(preference)
+	add(locale)
+	if language, _, found := strings.Cut(locale, "-"); found {
+		add(language)
+	}
+	add(fallback)
+	return chain
+}
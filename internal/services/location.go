@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMapsLink is returned when a text doesn't contain a usable Google Maps link
+var ErrInvalidMapsLink = errors.New("not a Google Maps link")
+
+var (
+	urlPattern         = regexp.MustCompile(`https?://\S+`)
+	atCoordinates      = regexp.MustCompile(`@(-?\d{1,3}\.\d+),(-?\d{1,3}\.\d+)`)
+	coordinatesPattern = regexp.MustCompile(`^\s*\(?\s*(-?\d{1,3}(?:\.\d+)?)\s*[, ]\s*(-?\d{1,3}(?:\.\d+)?)\s*\)?\s*$`)
+)
+
+// MapsLocation is a location shared as a Google Maps link.
+// Short links don't carry coordinates, so Latitude and Longitude may be nil.
+type MapsLocation struct {
+	URL       string
+	Latitude  *float64
+	Longitude *float64
+}
+
+// ParseMapsLink extracts a Google Maps link, and its coordinates when present, from a message text
+func ParseMapsLink(text string) (MapsLocation, error) {
+	raw := urlPattern.FindString(text)
+	if raw == "" {
+		return MapsLocation{}, ErrInvalidMapsLink
+	}
+
+	link, err := url.Parse(strings.TrimRight(raw, ".,)"))
+	if err != nil || !isMapsURL(link) {
+		return MapsLocation{}, ErrInvalidMapsLink
+	}
+
+	location := MapsLocation{URL: link.String()}
+	if lat, lng, ok := coordinatesFromURL(link); ok {
+		location.Latitude, location.Longitude = &lat, &lng
+	}
+	return location, nil
+}
+
+// ParseCoordinates parses a "lat,lng" pair and checks both values are in range
+func ParseCoordinates(text string) (float64, float64, bool) {
+	match := coordinatesPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, 0, false
+	}
+	lat, latErr := strconv.ParseFloat(match[1], 64)
+	lng, lngErr := strconv.ParseFloat(match[2], 64)
+	if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+// isMapsURL reports whether a URL points at Google Maps
+func isMapsURL(link *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(link.Hostname()), "www.")
+	switch {
+	case host == "maps.app.goo.gl", host == "maps.google.com", strings.HasPrefix(host, "maps.google."):
+		return true
+	case host == "goo.gl":
+		return strings.HasPrefix(link.Path, "/maps")
+	case host == "google.com", strings.HasPrefix(host, "google."):
+		return strings.HasPrefix(link.Path, "/maps")
+	default:
+		return false
+	}
+}
+
+// coordinatesFromURL reads coordinates from the path ("@lat,lng") or the query of a maps URL
+func coordinatesFromURL(link *url.URL) (float64, float64, bool) {
+	if match := atCoordinates.FindStringSubmatch(link.Path); match != nil {
+		if lat, lng, ok := ParseCoordinates(match[1] + "," + match[2]); ok {
+			return lat, lng, true
+		}
+	}
+	query := link.Query()
+	for _, key := range []string{"q", "query", "ll", "center", "destination"} {
+		if lat, lng, ok := ParseCoordinates(query.Get(key)); ok {
+			return lat, lng, true
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMapsLink(t *testing.T) {
+	tests := []struct {
+		text     string
+		url      string
+		lat, lng float64
+		coords   bool
+	}{
+		{"https://maps.app.goo.gl/AbCdEf123", "https://maps.app.goo.gl/AbCdEf123", 0, 0, false},
+		{"here it is: https://www.google.com/maps/place/Hubli/@15.3647,75.1240,14z.", "https://www.google.com/maps/place/Hubli/@15.3647,75.1240,14z", 15.3647, 75.1240, true},
+		{"https://maps.google.com/?q=12.9716,77.5946", "https://maps.google.com/?q=12.9716,77.5946", 12.9716, 77.5946, true},
+		{"https://www.google.co.in/maps/search/?api=1&query=15.1,75.2", "https://www.google.co.in/maps/search/?api=1&query=15.1,75.2", 15.1, 75.2, true},
+	}
+
+	for _, tt := range tests {
+		location, err := ParseMapsLink(tt.text)
+		if err != nil {
+			t.Errorf("ParseMapsLink(%q) error: %v", tt.text, err)
+			continue
+		}
+		if location.URL != tt.url {
+			t.Errorf("ParseMapsLink(%q) url = %q, want %q", tt.text, location.URL, tt.url)
+		}
+		if tt.coords {
+			if location.Latitude == nil || *location.Latitude != tt.lat || *location.Longitude != tt.lng {
+				t.Errorf("ParseMapsLink(%q) coordinates = %v,%v", tt.text, location.Latitude, location.Longitude)
+			}
+		} else if location.Latitude != nil {
+			t.Errorf("ParseMapsLink(%q) expected no coordinates", tt.text)
+		}
+	}
+}
+
+func TestParseMapsLinkRejectsOtherLinks(t *testing.T) {
+	for _, text := range []string{"near the bus stand", "https://example.com/maps/x", "https://www.google.com/search?q=hubli"} {
+		if _, err := ParseMapsLink(text); !errors.Is(err, ErrInvalidMapsLink) {
+			t.Errorf("ParseMapsLink(%q) = %v, want ErrInvalidMapsLink", text, err)
+		}
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	if lat, lng, ok := ParseCoordinates("15.36, 75.12"); !ok || lat != 15.36 || lng != 75.12 {
+		t.Errorf("got %v %v %v", lat, lng, ok)
+	}
+	for _, text := range []string{"", "95,10", "10,200", "abc"} {
+		if _, _, ok := ParseCoordinates(text); ok {
+			t.Errorf("ParseCoordinates(%q) should fail", text)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+// mediaExtensions pins the extension of common media types, mime.ExtensionsByType
+// sorts alphabetically and would name JPEG files ".jfif"
+var mediaExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// mediaExtension returns the file extension for a MIME type
+func mediaExtension(mimeType string) string {
+	mediaType := strings.TrimSpace(strings.Split(mimeType, ";")[0])
+	if ext, ok := mediaExtensions[mediaType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// saveMedia writes downloaded media to dir, naming the file after the message ID
+// with an extension matching its MIME type, and returns the file path
+func saveMedia(dir, messageID, mimeType string, data []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create media directory: %v", err)
+	}
+
+	path := filepath.Join(dir, messageID+mediaExtension(mimeType))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to save media: %v", err)
+	}
+	return path, nil
+}
+
+// maxMediaSize caps the size of a media file fetched for sending, WhatsApp rejects
+// anything larger than its 100 MB document limit anyway
+const maxMediaSize = 100 << 20
+
+// thumbnailSize is the longest side of the JPEG preview embedded in image messages
+const thumbnailSize = 72
+
+// mediaHTTPClient fetches media attachments given by URL
+var mediaHTTPClient = &http.Client{Timeout: 60 * time.Second}
+
+// loadedMedia is the content of an attachment ready to be uploaded
+type loadedMedia struct {
+	data     []byte
+	fileName string
+	mimeType string
+}
+
+// loadMedia reads an attachment from disk or the web and fills in its file name and
+// MIME type. Missing files and client errors wrap ErrMediaUnavailable as retrying
+// won't help, network and server errors don't.
+func loadMedia(ctx context.Context, media models.MediaAttachment) (loadedMedia, error) {
+	var (
+		data     []byte
+		fileName = media.FileName
+		err      error
+	)
+
+	switch {
+	case media.Path != "":
+		data, err = os.ReadFile(media.Path)
+		if err != nil {
+			return loadedMedia{}, fmt.Errorf("%w: %v", ErrMediaUnavailable, err)
+		}
+		if fileName == "" {
+			fileName = filepath.Base(media.Path)
+		}
+	case media.URL != "":
+		data, err = fetchMedia(ctx, media.URL)
+		if err != nil {
+			return loadedMedia{}, err
+		}
+		if fileName == "" {
+			if parsed, err := url.Parse(media.URL); err == nil {
+				fileName = path.Base(parsed.Path)
+			}
+		}
+	default:
+		return loadedMedia{}, fmt.Errorf("%w: attachment has neither a path nor a URL", ErrMediaUnavailable)
+	}
+
+	if len(data) == 0 {
+		return loadedMedia{}, fmt.Errorf("%w: empty file", ErrMediaUnavailable)
+	}
+
+	return loadedMedia{
+		data:     data,
+		fileName: fileName,
+		mimeType: detectMimeType(media.MimeType, fileName, data),
+	}, nil
+}
+
+// fetchMedia downloads a media file over HTTP
+func fetchMedia(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMediaUnavailable, err)
+	}
+
+	resp, err := mediaHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch media: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return nil, fmt.Errorf("%w: %s returned %s", ErrMediaUnavailable, rawURL, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch media: %s returned %s", rawURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch media: %v", err)
+	}
+	if len(data) > maxMediaSize {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrMediaUnavailable, rawURL, maxMediaSize)
+	}
+	return data, nil
+}
+
+// detectMimeType returns the declared MIME type, or guesses it from the file
+// extension and then from the content
+func detectMimeType(declared, fileName string, data []byte) string {
+	if declared != "" {
+		return declared
+	}
+	if mimeType := mime.TypeByExtension(filepath.Ext(fileName)); mimeType != "" {
+		return mimeType
+	}
+	return http.DetectContentType(data)
+}
+
+// imageThumbnail decodes an image and renders the small JPEG preview WhatsApp shows
+// while the full image loads. It also returns the size of the original image.
+func imageThumbnail(data []byte) ([]byte, int, int, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, 0, 0, fmt.Errorf("failed to decode image: empty image")
+	}
+
+	thumbWidth, thumbHeight := thumbnailSize, thumbnailSize
+	if width > height {
+		thumbHeight = max(1, height*thumbnailSize/width)
+	} else {
+		thumbWidth = max(1, width*thumbnailSize/height)
+	}
+	if width < thumbWidth || height < thumbHeight {
+		thumbWidth, thumbHeight = width, height
+	}
+
+	// Average the source pixels covered by each thumbnail pixel
+	thumb := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for ty := 0; ty < thumbHeight; ty++ {
+		y0 := bounds.Min.Y + ty*height/thumbHeight
+		y1 := max(y0+1, bounds.Min.Y+(ty+1)*height/thumbHeight)
+		for tx := 0; tx < thumbWidth; tx++ {
+			x0 := bounds.Min.X + tx*width/thumbWidth
+			x1 := max(x0+1, bounds.Min.X+(tx+1)*width/thumbWidth)
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := src.At(x, y).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			thumb.Set(tx, ty, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 70}); err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to encode thumbnail: %v", err)
+	}
+	return buf.Bytes(), width, height, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+func TestMediaExtension(t *testing.T) {
+	tests := map[string]string{
+		"image/jpeg":                ".jpg",
+		"application/pdf":           ".pdf",
+		"audio/ogg; codecs=opus":    ".oga",
+		"application/x-unknown-foo": ".bin",
+	}
+	for mimeType, want := range tests {
+		if got := mediaExtension(mimeType); got != want {
+			t.Errorf("mediaExtension(%q) = %q, want %q", mimeType, got, want)
+		}
+	}
+}
+
+func TestDetectMimeType(t *testing.T) {
+	pdf := []byte("%PDF-1.4 brochure")
+	if got := detectMimeType("application/x-custom", "a.pdf", pdf); got != "application/x-custom" {
+		t.Errorf("declared type must win, got %q", got)
+	}
+	if got := detectMimeType("", "brochure.pdf", nil); got != "application/pdf" {
+		t.Errorf("expected type from extension, got %q", got)
+	}
+	if got := detectMimeType("", "upload", pdf); got != "application/pdf" {
+		t.Errorf("expected type from content, got %q", got)
+	}
+}
+
+func TestImageThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			src.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, width, height, err := imageThumbnail(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 400 || height != 200 {
+		t.Errorf("expected the original size 400x200, got %dx%d", width, height)
+	}
+
+	preview, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if size := preview.Bounds().Size(); size.X != thumbnailSize || size.Y != thumbnailSize/2 {
+		t.Errorf("expected a %dx%d thumbnail, got %v", thumbnailSize, thumbnailSize/2, size)
+	}
+
+	if _, _, _, err := imageThumbnail([]byte("not an image")); err == nil {
+		t.Error("expected an error for undecodable data")
+	}
+}
+
+func TestLoadMediaFromURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photos/plot.png":
+			w.Write([]byte("\x89PNG\r\n\x1a\n"))
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	media, err := loadMedia(context.Background(), models.MediaAttachment{Kind: models.MediaImage, URL: server.URL + "/photos/plot.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.fileName != "plot.png" || media.mimeType != "image/png" {
+		t.Errorf("unexpected file name %q or type %q", media.fileName, media.mimeType)
+	}
+
+	// A missing file won't appear on retry, a failing server might recover
+	if _, err := loadMedia(context.Background(), models.MediaAttachment{URL: server.URL + "/missing.png"}); !errors.Is(err, ErrMediaUnavailable) {
+		t.Errorf("expected ErrMediaUnavailable for a 404, got %v", err)
+	}
+	if _, err := loadMedia(context.Background(), models.MediaAttachment{URL: server.URL + "/broken"}); err == nil || errors.Is(err, ErrMediaUnavailable) {
+		t.Errorf("expected a retryable error for a 502, got %v", err)
+	}
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+// messageTemplateExt is the extension of template files, the file name without it
+// is the template name
+const messageTemplateExt = ".tmpl"
+
+// Message template names
+const (
+	TemplateRentalPost                    = "rental_post"
+	TemplateRentalPostAlert               = "rental_post_alert"
+	TemplateCustomSearch                  = "custom_search"
+	TemplateCustomSearchAlert             = "custom_search_alert"
+	TemplateConstructionEnquiry           = "construction_enquiry"
+	TemplateConstructionEnquiryAlert      = "construction_enquiry_alert"
+	TemplateConstructionBrochure          = "construction_brochure"
+	TemplateConstructionBrochureAlert     = "construction_brochure_alert"
+	TemplateAccountDeletion               = "account_deletion"
+	TemplateAccountDeletionAlert          = "account_deletion_alert"
+	TemplateSellRequest                   = "sell_request"
+	TemplateSellRequestAlert              = "sell_request_alert"
+	TemplateSellRequestAttended           = "sell_request_attended"
+	TemplateSellRequestAssigned           = "sell_request_assigned"
+	TemplateSellRequestAssignedAlert      = "sell_request_assigned_alert"
+	TemplateSellRequestDeletedAlert       = "sell_request_deleted_alert"
+	TemplateAccountDeletionCompleted      = "account_deletion_completed"
+	TemplateAccountDeletionCancelled      = "account_deletion_cancelled"
+	TemplateSellChecklistReminder         = "sell_checklist_reminder"
+	TemplateSellChecklistProgress         = "sell_checklist_progress"
+	TemplateSellChecklistResend           = "sell_checklist_resend"
+	TemplateSellChecklistComplete         = "sell_checklist_complete"
+	TemplateSellChecklistItem             = "sell_checklist_item"
+	TemplateRentalIntakePhotos            = "rental_intake_photos"
+	TemplateRentalIntakePhotoReceived     = "rental_intake_photo_received"
+	TemplateRentalIntakeMorePhotos        = "rental_intake_more_photos"
+	TemplateRentalIntakePhotoResend       = "rental_intake_photo_resend"
+	TemplateRentalIntakeLocation          = "rental_intake_location"
+	TemplateRentalIntakeDescription       = "rental_intake_description"
+	TemplateRentalIntakeRent              = "rental_intake_rent"
+	TemplateRentalIntakeContact           = "rental_intake_contact"
+	TemplateRentalIntakeComplete          = "rental_intake_complete"
+	TemplateRentalIntakeExpired           = "rental_intake_expired"
+	TemplateUndeliverableAlert            = "undeliverable_alert"
+	TemplatePropertyInterestAlert         = "property_interest_alert"
+	TemplatePropertyLookupFailedAlert     = "property_lookup_failed_alert"
+	TemplateAccountDeletionCompletedAlert = "account_deletion_completed_alert"
+	TemplateAccountDeletionCancelledAlert = "account_deletion_cancelled_alert"
+	TemplateAccountDeletionFailedAlert    = "account_deletion_failed_alert"
+	TemplateSellChecklistCompleteAlert    = "sell_checklist_complete_alert"
+	TemplateRentalIntakeCompleteAlert     = "rental_intake_complete_alert"
+)
+
+// messageTemplateSpec describes a message template the service renders
+type messageTemplateSpec struct {
+	// alert templates go to the team and are only rendered in the team locale
+	alert bool
+	// samples are rendered before the template is used, so a template referring to
+	// a field its data doesn't have is rejected
+	samples []any
+}
+
+// messageTemplateSpecs lists every template the service renders
+var messageTemplateSpecs = func() map[string]messageTemplateSpec {
+	named := models.User{ID: "sample-user", Name: "Asha", Phone: "919000000001"}
+	unnamed := models.User{ID: "sample-user", Phone: "919000000001"}
+	users := []any{models.UserMessageData{User: named}, models.UserMessageData{User: unnamed}}
+
+	deletion := &models.AccountDeletion{ID: 1, ScheduledFor: time.Now().Add(24 * time.Hour)}
+	deletions := []any{
+		models.AccountDeletionMessageData{User: named, Deletion: deletion},
+		models.AccountDeletionMessageData{User: unnamed},
+	}
+	// The user may already be gone when a deletion finishes
+	finishedDeletions := []any{models.AccountDeletionMessageData{Deletion: deletion}}
+	failedDeletions := []any{models.AccountDeletionMessageData{Deletion: deletion, Error: "user is referenced by a listing"}}
+	brochures := []any{
+		models.BrochureMessageData{User: named, LeadID: "#1", BrochureStatus: "Sent to the user"},
+		models.BrochureMessageData{User: unnamed, LeadID: "not recorded", BrochureStatus: "Failed to send"},
+	}
+	sellRequest := models.SellRequest{Id: 1, PropertyType: "Plot", Address: "Hubli", Price: "10L", AssignTo: "Ravi"}
+	sellRequests := []any{
+		models.SellRequestMessageData{User: named, SellRequest: sellRequest},
+		models.SellRequestMessageData{User: unnamed, SellRequest: sellRequest},
+	}
+	checklist := models.SellChecklist{ID: 1, SellRequestID: 1, Phone: "919000000001"}
+	// sell_checklist_item names one item, the checklist messages list the missing ones with it
+	var checklistItems []any
+	for _, item := range models.SellChecklistItems {
+		checklistItems = append(checklistItems, item)
+	}
+	size, propertyType, facing, mapLocation, utaara := "30x40", "NA", "East", "https://maps.google.com/?q=15.36,75.12", "utaara.pdf"
+	completeChecklist := models.SellChecklist{
+		ID: 1, SellRequestID: 1, Phone: "919000000001", Size: &size, PropertyType: &propertyType,
+		Facing: &facing, MapLocation: &mapLocation, Images: []string{"front.jpg"}, UtaaraCopy: &utaara,
+	}
+	checklists := []any{
+		models.SellChecklistMessageData{Checklist: checklist, Missing: models.SellChecklistItems},
+		models.SellChecklistMessageData{Checklist: checklist, Missing: models.SellChecklistItems[:1], Document: true},
+	}
+	intake := models.RentalIntake{ID: 1, Phone: "919000000001", Photos: []string{"photo.jpg"}}
+	intakes := []any{
+		models.RentalIntakeMessageData{Intake: intake, MinPhotos: 2, MissingPhotos: 1},
+		models.RentalIntakeMessageData{Intake: intake, Retry: true, MinPhotos: 2},
+	}
+	description, rent, lat, lng := "2BHK ground floor with parking", int64(15000), 15.36, 75.12
+	completeIntake := models.RentalIntake{
+		ID: 1, Phone: "919000000001", Photos: []string{"1.jpg", "2.jpg"}, MapsLink: &mapLocation,
+		Description: &description, Rent: &rent, ContactPhone: &intake.Phone,
+	}
+	pinnedIntake := completeIntake
+	pinnedIntake.MapsLink, pinnedIntake.Latitude, pinnedIntake.Longitude = nil, &lat, &lng
+	completedIntakes := []any{
+		models.RentalIntakeMessageData{Intake: completeIntake, DraftID: 1},
+		models.RentalIntakeMessageData{Intake: pinnedIntake, DraftID: 2},
+	}
+	property := models.Property{ID: 42, Title: "Corner plot", Size: "30x40"}
+	interests := []any{
+		models.PropertyInterestMessageData{User: named, Property: property, URL: "https://easyplots.in/property/42", Pinned: true},
+		models.PropertyInterestMessageData{User: unnamed, Property: property, URL: "https://easyplots.in/property/42", Area: "Vidyanagar"},
+		models.PropertyInterestMessageData{User: named, Property: property, URL: "https://easyplots.in/property/42"},
+	}
+	failedLookups := []any{
+		models.PropertyInterestMessageData{User: named, Property: models.Property{ID: 42}, Error: "property not found"},
+	}
+	undeliverable := []any{
+		models.UndeliverableMessageData{Phone: "919000000001", OutboxID: 1, Text: "Hello"},
+		models.UndeliverableMessageData{Phone: "919000000001", OutboxID: 2},
+	}
+
+	return map[string]messageTemplateSpec{
+		TemplateRentalPost:                    {samples: users},
+		TemplateRentalPostAlert:               {alert: true, samples: users},
+		TemplateCustomSearch:                  {samples: users},
+		TemplateCustomSearchAlert:             {alert: true, samples: users},
+		TemplateConstructionEnquiry:           {samples: users},
+		TemplateConstructionEnquiryAlert:      {alert: true, samples: users},
+		TemplateConstructionBrochure:          {samples: brochures},
+		TemplateConstructionBrochureAlert:     {alert: true, samples: brochures},
+		TemplateAccountDeletion:               {samples: deletions},
+		TemplateAccountDeletionAlert:          {alert: true, samples: deletions},
+		TemplateSellRequest:                   {samples: sellRequests},
+		TemplateSellRequestAlert:              {alert: true, samples: sellRequests},
+		TemplateSellRequestAttended:           {samples: sellRequests},
+		TemplateSellRequestAssigned:           {samples: sellRequests},
+		TemplateSellRequestAssignedAlert:      {alert: true, samples: sellRequests},
+		TemplateSellRequestDeletedAlert:       {alert: true, samples: sellRequests},
+		TemplateAccountDeletionCompleted:      {samples: finishedDeletions},
+		TemplateAccountDeletionCancelled:      {samples: finishedDeletions},
+		TemplateSellChecklistReminder:         {samples: checklists},
+		TemplateSellChecklistProgress:         {samples: checklists},
+		TemplateSellChecklistResend:           {samples: checklists},
+		TemplateSellChecklistComplete:         {samples: checklists},
+		TemplateSellChecklistItem:             {samples: checklistItems},
+		TemplateRentalIntakePhotos:            {samples: intakes},
+		TemplateRentalIntakePhotoReceived:     {samples: intakes},
+		TemplateRentalIntakeMorePhotos:        {samples: intakes},
+		TemplateRentalIntakePhotoResend:       {samples: intakes},
+		TemplateRentalIntakeLocation:          {samples: intakes},
+		TemplateRentalIntakeDescription:       {samples: intakes},
+		TemplateRentalIntakeRent:              {samples: intakes},
+		TemplateRentalIntakeContact:           {samples: intakes},
+		TemplateRentalIntakeComplete:          {samples: intakes},
+		TemplateRentalIntakeExpired:           {samples: intakes},
+		TemplateUndeliverableAlert:            {alert: true, samples: undeliverable},
+		TemplatePropertyInterestAlert:         {alert: true, samples: interests},
+		TemplatePropertyLookupFailedAlert:     {alert: true, samples: failedLookups},
+		TemplateAccountDeletionCompletedAlert: {alert: true, samples: finishedDeletions},
+		TemplateAccountDeletionCancelledAlert: {alert: true, samples: finishedDeletions},
+		TemplateAccountDeletionFailedAlert:    {alert: true, samples: failedDeletions},
+		TemplateSellChecklistCompleteAlert:    {alert: true, samples: []any{models.SellChecklistMessageData{Checklist: completeChecklist}}},
+		TemplateRentalIntakeCompleteAlert:     {alert: true, samples: completedIntakes},
+	}
+}()
+
+// MessageRenderer renders the texts of messages by template name
+type MessageRenderer interface {
+	// Render renders a message to a user in the locale closest to their preference
+	Render(locale, name string, data any) (string, error)
+	// RenderAlert renders an internal alert in the team locale
+	RenderAlert(name string, data any) (string, error)
+}
+
+// sendTemplate renders a message template in the locale closest to the preference
+// and queues it to a phone number
+func sendTemplate(ctx context.Context, messenger Messenger, templates MessageRenderer, locale, phoneNumber, name string, data any) error {
+	message, err := templates.Render(locale, name, data)
+	if err != nil {
+		return err
+	}
+	return messenger.SendMessage(ctx, phoneNumber, message)
+}
+
+// sendAlert renders an alert template in the team locale and queues it to every group
+// routed for the category
+func sendAlert(ctx context.Context, messenger Messenger, templates MessageRenderer, routes GroupRouter, category, name string, data any) error {
+	alert, err := templates.RenderAlert(name, data)
+	if err != nil {
+		return err
+	}
+	return SendToGroups(ctx, messenger, routes, category, alert)
+}
+
+// MessageTemplates renders messages from text/template files, one directory per
+// locale (e.g. templates/en, templates/kn), so their wording can change without a
+// release. The default locale must have every template, other locales fall back to
+// it for templates they don't translate. The files are reloaded when one changes, a
+// set that fails validation keeps the current templates.
+type MessageTemplates struct {
+	dir           string
+	defaultLocale string
+	teamLocale    string
+	interval      time.Duration
+
+	mu        sync.RWMutex
+	locales   map[string]*template.Template
+	signature string
+}
+
+var _ MessageRenderer = (*MessageTemplates)(nil)
+
+// NewMessageTemplates loads the message templates, a missing or broken template fails
+// startup and missing translations are logged
+func NewMessageTemplates(cfg *config.Config) (*MessageTemplates, error) {
+	templates := &MessageTemplates{
+		dir:           cfg.TemplatesDir,
+		defaultLocale: normalizeLocale(cfg.DefaultLocale),
+		teamLocale:    normalizeLocale(cfg.TeamLocale),
+		interval:      cfg.TemplatesReloadInterval,
+	}
+	if templates.defaultLocale == "" {
+		templates.defaultLocale = DefaultLocale
+	}
+	if templates.teamLocale == "" {
+		templates.teamLocale = templates.defaultLocale
+	}
+	if _, err := templates.reload(); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Render executes a template in the first locale of the preference's fallback chain
+// that has it, and trims the surrounding whitespace of the message
+func (t *MessageTemplates) Render(locale, name string, data any) (string, error) {
+	t.mu.RLock()
+	locales := t.locales
+	t.mu.RUnlock()
+
+	for _, candidate := range localeChain(locale, t.defaultLocale) {
+		if templates := locales[candidate]; templates != nil && templates.Lookup(name) != nil {
+			return renderMessageTemplate(templates, name, data)
+		}
+	}
+	return "", fmt.Errorf("message template %q not found", name)
+}
+
+// RenderAlert renders an internal alert in the team locale, whatever the locale of
+// the user it is about
+func (t *MessageTemplates) RenderAlert(name string, data any) (string, error) {
+	return t.Render(t.teamLocale, name, data)
+}
+
+// Run reloads the templates whenever a file changes until the context is cancelled
+func (t *MessageTemplates) Run(ctx context.Context) {
+	if t.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := t.reload()
+			if err != nil {
+				log.Printf("Template Error: Keeping the current message templates: %v", err)
+			} else if reloaded {
+				log.Printf("Templates: Reloaded message templates from %s", t.dir)
+			}
+		}
+	}
+}
+
+// reload parses and validates the templates if any file changed since the last load
+func (t *MessageTemplates) reload() (bool, error) {
+	files, signature, err := messageTemplateFiles(t.dir)
+	if err != nil {
+		return false, err
+	}
+	t.mu.RLock()
+	unchanged := signature == t.signature
+	t.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	locales := make(map[string]*template.Template, len(files))
+	for locale, localeFiles := range files {
+		templates, err := parseMessageTemplates(localeFiles)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s message templates in %s: %v", locale, t.dir, err)
+		}
+		locales[locale] = templates
+	}
+	missing, err := validateMessageTemplates(locales, t.defaultLocale, t.teamLocale)
+	if err != nil {
+		return false, fmt.Errorf("invalid message templates in %s: %v", t.dir, err)
+	}
+	for _, locale := range slices.Sorted(maps.Keys(missing)) {
+		log.Printf("Templates: Missing %s translations, falling back to %s: %s", locale, t.defaultLocale, strings.Join(missing[locale], ", "))
+	}
+
+	t.mu.Lock()
+	t.locales = locales
+	t.signature = signature
+	t.mu.Unlock()
+	return true, nil
+}
+
+// messageTemplateFiles lists the template files of every locale directory along with
+// a signature that changes whenever one of them is added, removed or modified
+func messageTemplateFiles(dir string) (map[string][]string, string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read message templates: %v", err)
+	}
+
+	files := make(map[string][]string)
+	var signature strings.Builder
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := normalizeLocale(entry.Name())
+		if _, exists := files[locale]; exists {
+			return nil, "", fmt.Errorf("message templates of locale %s are in more than one directory", locale)
+		}
+		localeEntries, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read message templates: %v", err)
+		}
+		files[locale] = nil
+		for _, file := range localeEntries {
+			if file.IsDir() || filepath.Ext(file.Name()) != messageTemplateExt {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read message templates: %v", err)
+			}
+			files[locale] = append(files[locale], filepath.Join(dir, entry.Name(), file.Name()))
+			fmt.Fprintf(&signature, "%s/%s:%d:%d;", entry.Name(), file.Name(), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return files, signature.String(), nil
+}
+
+// parseMessageTemplates parses every file into one set, named after the file
+func parseMessageTemplates(files []string) (*template.Template, error) {
+	templates := template.New("").Option("missingkey=error")
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", file, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), messageTemplateExt)
+		if _, err := templates.New(name).Parse(string(text)); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+// validateMessageTemplates renders every template of every locale with its sample
+// data. The default locale needs every template and the team locale every alert,
+// other templates missing from a locale are returned as missing translations.
+func validateMessageTemplates(locales map[string]*template.Template, defaultLocale, teamLocale string) (map[string][]string, error) {
+	if locales[defaultLocale] == nil {
+		return nil, fmt.Errorf("no templates for the default locale %s", defaultLocale)
+	}
+
+	var errs []error
+	missing := make(map[string][]string)
+	for _, locale := range slices.Sorted(maps.Keys(locales)) {
+		templates := locales[locale]
+		for _, name := range slices.Sorted(maps.Keys(messageTemplateSpecs)) {
+			spec := messageTemplateSpecs[name]
+			if templates.Lookup(name) == nil {
+				switch {
+				case locale == defaultLocale:
+					errs = append(errs, fmt.Errorf("%s: message template %q not found", locale, name))
+				case spec.alert && locale == teamLocale:
+					missing[locale] = append(missing[locale], name)
+				case !spec.alert:
+					missing[locale] = append(missing[locale], name)
+				}
+				continue
+			}
+			for _, data := range spec.samples {
+				if _, err := renderMessageTemplate(templates, name, data); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %v", locale, err))
+					break
+				}
+			}
+		}
+	}
+	return missing, errors.Join(errs...)
+}
+
+// renderMessageTemplate executes a template of the set, an empty message is an error
+func renderMessageTemplate(templates *template.Template, name string, data any) (string, error) {
+	tmpl := templates.Lookup(name)
+	if tmpl == nil {
+		return "", fmt.Errorf("message template %q not found", name)
+	}
+	var message bytes.Buffer
+	if err := tmpl.Execute(&message, data); err != nil {
+		return "", fmt.Errorf("failed to render message template %q: %v", name, err)
+	}
+	text := strings.TrimSpace(message.String())
+	if text == "" {
+		return "", fmt.Errorf("message template %q rendered an empty message", name)
+	}
+	return text, nil
+}
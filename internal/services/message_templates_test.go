@@ -0,0 +1,248 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+// shippedTemplatesDir is the template directory shipped with the service
+var shippedTemplatesDir = filepath.Join("..", "..", "templates")
+
+// testTemplates renders the shipped templates for the workflow tests
+var testTemplates = func() *MessageTemplates {
+	templates, err := NewMessageTemplates(&config.Config{TemplatesDir: shippedTemplatesDir, DefaultLocale: DefaultLocale})
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}()
+
+// copyMessageTemplates copies the shipped templates into a directory the test can edit
+func copyMessageTemplates(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files, err := filepath.Glob(filepath.Join(shippedTemplatesDir, "*", "*"+messageTemplateExt))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no shipped templates found: %v", err)
+	}
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		locale := filepath.Base(filepath.Dir(file))
+		writeMessageTemplate(t, dir, locale, strings.TrimSuffix(filepath.Base(file), messageTemplateExt), string(text))
+	}
+	return dir
+}
+
+// writeMessageTemplate writes a template file with a modification time that differs
+// from the previous write, so reloads notice it on coarse clocks
+func writeMessageTemplate(t *testing.T, dir, locale, name, text string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, locale), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, locale, name+messageTemplateExt)
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Duration(len(text)) * time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShippedMessageTemplatesRender(t *testing.T) {
+	templates, err := NewMessageTemplates(&config.Config{TemplatesDir: shippedTemplatesDir})
+	if err != nil {
+		t.Fatalf("shipped templates are invalid: %v", err)
+	}
+
+	message, err := templates.Render("", TemplateRentalPost, models.UserMessageData{User: models.User{Name: "Asha"}})
+	if err != nil || !strings.HasPrefix(message, "Hello Asha,") || strings.HasSuffix(message, "\n") {
+		t.Errorf("unexpected message %q: %v", message, err)
+	}
+	if _, err := templates.Render("", "unknown", nil); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+}
+
+func TestShippedTranslationsAreComplete(t *testing.T) {
+	locales := make(map[string]*template.Template)
+	files, _, err := messageTemplateFiles(shippedTemplatesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for locale, localeFiles := range files {
+		if locales[locale], err = parseMessageTemplates(localeFiles); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, locale := range []string{"en", "kn", "hi"} {
+		if locales[locale] == nil {
+			t.Errorf("no %s templates shipped", locale)
+		}
+	}
+
+	missing, err := validateMessageTemplates(locales, DefaultLocale, DefaultLocale)
+	if err != nil || len(missing) != 0 {
+		t.Errorf("expected complete translations, missing %v: %v", missing, err)
+	}
+}
+
+func TestMessageTemplatesPickUserLocale(t *testing.T) {
+	templates, err := NewMessageTemplates(&config.Config{TemplatesDir: shippedTemplatesDir, DefaultLocale: "en", TeamLocale: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := models.UserMessageData{User: models.User{Name: "Asha", Phone: "919000000001"}}
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"", "Hello Asha,"},
+		{"kn", "ನಮಸ್ಕಾರ Asha,"},
+		{"kn_IN", "ನಮಸ್ಕಾರ Asha,"},
+		{"Hindi", "नमस्ते Asha,"},
+		{"fr", "Hello Asha,"},
+	}
+	for _, tt := range tests {
+		message, err := templates.Render(tt.locale, TemplateCustomSearch, data)
+		if err != nil || !strings.HasPrefix(message, tt.want) {
+			t.Errorf("Render(%q) = %q, %v, want it to start with %q", tt.locale, message, err, tt.want)
+		}
+	}
+
+	alert, err := templates.RenderAlert(TemplateCustomSearchAlert, data)
+	if err != nil || !strings.Contains(alert, "Custom Property Request") {
+		t.Errorf("expected the alert in the team locale, got %q: %v", alert, err)
+	}
+}
+
+func TestLocaleChain(t *testing.T) {
+	tests := []struct {
+		preference string
+		want       []string
+	}{
+		{"", []string{"en"}},
+		{"en", []string{"en"}},
+		{"kn-IN", []string{"kn-in", "kn", "en"}},
+		{" Kannada ", []string{"kn", "en"}},
+		{"हिंदी", []string{"hi", "en"}},
+	}
+	for _, tt := range tests {
+		if got := localeChain(tt.preference, "en"); !slices.Equal(got, tt.want) {
+			t.Errorf("localeChain(%q) = %v, want %v", tt.preference, got, tt.want)
+		}
+	}
+}
+
+func TestMessageTemplatesReportMissingTranslations(t *testing.T) {
+	dir := copyMessageTemplates(t)
+	os.Remove(filepath.Join(dir, "kn", TemplateCustomSearch+messageTemplateExt))
+	writeMessageTemplate(t, dir, "ta", TemplateRentalPost, "வணக்கம் {{.User.Name}}")
+
+	templates, err := NewMessageTemplates(&config.Config{TemplatesDir: dir, DefaultLocale: "en", TeamLocale: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, _ := messageTemplateFiles(dir)
+	locales := make(map[string]*template.Template)
+	for locale, localeFiles := range files {
+		locales[locale], _ = parseMessageTemplates(localeFiles)
+	}
+	missing, err := validateMessageTemplates(locales, "en", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(missing["kn"], []string{TemplateCustomSearch}) {
+		t.Errorf("expected kn to miss custom_search, got %v", missing["kn"])
+	}
+	if slices.Contains(missing["ta"], TemplateRentalPostAlert) || !slices.Contains(missing["ta"], TemplateCustomSearch) {
+		t.Errorf("expected ta to miss customer messages but not alerts, got %v", missing["ta"])
+	}
+
+	// Untranslated messages fall back to the default locale
+	message, err := templates.Render("kn", TemplateCustomSearch, models.UserMessageData{User: models.User{Name: "Asha"}})
+	if err != nil || !strings.HasPrefix(message, "Hello Asha,") {
+		t.Errorf("expected the English fallback, got %q: %v", message, err)
+	}
+}
+
+func TestMessageTemplatesRejectInvalidSets(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(t *testing.T, dir string)
+		wantErr string
+	}{
+		{"missing template", func(t *testing.T, dir string) {
+			os.Remove(filepath.Join(dir, "en", TemplateCustomSearch+messageTemplateExt))
+		}, `"custom_search" not found`},
+		{"missing default locale", func(t *testing.T, dir string) {
+			os.RemoveAll(filepath.Join(dir, "en"))
+		}, "no templates for the default locale en"},
+		{"syntax error", func(t *testing.T, dir string) {
+			writeMessageTemplate(t, dir, "en", TemplateCustomSearch, "Hello {{.User.Name")
+		}, "custom_search"},
+		{"unknown field", func(t *testing.T, dir string) {
+			writeMessageTemplate(t, dir, "en", TemplateRentalPost, "Hello {{.SellRequest.Price}}")
+		}, "rental_post"},
+		{"broken translation", func(t *testing.T, dir string) {
+			writeMessageTemplate(t, dir, "kn", TemplateRentalPost, "ನಮಸ್ಕಾರ {{.User.Nickname}}")
+		}, "kn: "},
+		{"empty message", func(t *testing.T, dir string) {
+			writeMessageTemplate(t, dir, "en", TemplateSellRequestAlert, "{{if false}}never{{end}}\n")
+		}, "empty message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := copyMessageTemplates(t)
+			tt.change(t, dir)
+
+			_, err := NewMessageTemplates(&config.Config{TemplatesDir: dir})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMessageTemplatesReload(t *testing.T) {
+	dir := copyMessageTemplates(t)
+	templates, err := NewMessageTemplates(&config.Config{TemplatesDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := models.UserMessageData{User: models.User{Name: "Asha"}}
+
+	if reloaded, err := templates.reload(); reloaded || err != nil {
+		t.Fatalf("expected no reload without changes, got %v, %v", reloaded, err)
+	}
+
+	writeMessageTemplate(t, dir, "en", TemplateCustomSearch, "Namaste {{.User.Name}}")
+	if reloaded, err := templates.reload(); !reloaded || err != nil {
+		t.Fatalf("expected a reload, got %v, %v", reloaded, err)
+	}
+	if message, _ := templates.Render("", TemplateCustomSearch, data); message != "Namaste Asha" {
+		t.Errorf("expected the edited template, got %q", message)
+	}
+
+	// A broken edit keeps the templates that were working
+	writeMessageTemplate(t, dir, "en", TemplateCustomSearch, "Namaste {{.User.Nickname}}")
+	if _, err := templates.reload(); err == nil {
+		t.Fatal("expected the broken template to be rejected")
+	}
+	if message, _ := templates.Render("", TemplateCustomSearch, data); message != "Namaste Asha" {
+		t.Errorf("expected the previous template to stay, got %q", message)
+	}
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+// Messenger sends WhatsApp messages on behalf of the HTTP handlers.
+// The Outbox is the production implementation, FakeMessenger records sends for tests.
+type Messenger interface {
+	// SendMessage sends a direct message to a phone number
+	SendMessage(ctx context.Context, phoneNumber, message string) error
+	// SendGroupMessage sends a message to a group JID
+	SendGroupMessage(ctx context.Context, groupJID, message string) error
+	// SendMedia sends a file to a phone number, with the caption as its text
+	SendMedia(ctx context.Context, phoneNumber string, media models.MediaAttachment, caption string) error
+	// SendGroupMedia sends a file to a group JID, with the caption as its text
+	SendGroupMedia(ctx context.Context, groupJID string, media models.MediaAttachment, caption string) error
+	// SendLinkPreview sends a text whose link renders as a card to a phone number
+	SendLinkPreview(ctx context.Context, phoneNumber, message string, preview models.LinkPreview) error
+	// SendGroupLinkPreview sends a text whose link renders as a card to a group JID
+	SendGroupLinkPreview(ctx context.Context, groupJID, message string, preview models.LinkPreview) error
+	// SendLocation sends a location pin to a phone number
+	SendLocation(ctx context.Context, phoneNumber string, location models.Location) error
+	// SendGroupLocation sends a location pin to a group JID
+	SendGroupLocation(ctx context.Context, groupJID string, location models.Location) error
+}
+
+var _ Messenger = (*Outbox)(nil)
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// GroupRouter picks the internal groups notified about a category
+type GroupRouter interface {
+	Groups(category string) []string
+}
+
+// NotificationRoutesProvider routes alerts and shows operators where they go
+type NotificationRoutesProvider interface {
+	GroupRouter
+	Routes() models.NotificationRoutes
+}
+
+// NotificationRouter routes internal alerts to groups by category. Routes come from
+// a JSON file that is reloaded when it changes, categories without a route and
+// deployments without a file use WHATSAPP_GROUP_JID.
+type NotificationRouter struct {
+	path     string
+	fallback string
+	interval time.Duration
+
+	mu      sync.RWMutex
+	routes  models.NotificationRoutes
+	modTime time.Time
+}
+
+var _ NotificationRoutesProvider = (*NotificationRouter)(nil)
+
+// NewNotificationRouter loads the routing table, an invalid file fails startup
+func NewNotificationRouter(cfg *config.Config) (*NotificationRouter, error) {
+	if err := validateGroupJID(cfg.WhatsAppGroupJID); err != nil {
+		return nil, fmt.Errorf("invalid WHATSAPP_GROUP_JID: %v", err)
+	}
+	router := &NotificationRouter{
+		path:     cfg.NotificationRoutesFile,
+		fallback: cfg.WhatsAppGroupJID,
+		interval: cfg.NotificationRoutesReloadInterval,
+	}
+	if router.path == "" {
+		return router, nil
+	}
+	if _, err := router.reload(); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// NewStaticNotificationRouter creates a router with fixed routes
+func NewStaticNotificationRouter(routes models.NotificationRoutes, fallback string) *NotificationRouter {
+	return &NotificationRouter{routes: routes, fallback: fallback}
+}
+
+// Groups returns the groups notified about a category: its routes, the default groups
+// of the routing table, or WHATSAPP_GROUP_JID, whichever is configured first
+func (r *NotificationRouter) Groups(category string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if groups := r.routes.Routes[category]; len(groups) > 0 {
+		return slices.Clone(groups)
+	}
+	if len(r.routes.Default) > 0 {
+		return slices.Clone(r.routes.Default)
+	}
+	return []string{r.fallback}
+}
+
+// Routes returns the groups of every category as they are resolved right now
+func (r *NotificationRouter) Routes() models.NotificationRoutes {
+	routes := models.NotificationRoutes{Routes: make(map[string][]string)}
+	for _, category := range models.NotificationCategories {
+		routes.Routes[category] = r.Groups(category)
+	}
+	routes.Default = r.Groups("")
+	return routes
+}
+
+// Run reloads the routing file whenever it changes until the context is cancelled.
+// A file that can't be read or is invalid keeps the current routes.
+func (r *NotificationRouter) Run(ctx context.Context) {
+	if r.path == "" || r.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.reload()
+			if err != nil {
+				log.Printf("Routing Error: Keeping the current notification routes: %v", err)
+			} else if reloaded {
+				log.Printf("Routing: Reloaded notification routes from %s", r.path)
+			}
+		}
+	}
+}
+
+// reload reads the routing file if it changed since the last load
+func (r *NotificationRouter) reload() (bool, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read notification routes: %v", err)
+	}
+	r.mu.RLock()
+	unchanged := info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read notification routes: %v", err)
+	}
+	routes, err := parseNotificationRoutes(data)
+	if err != nil {
+		return false, fmt.Errorf("invalid notification routes in %s: %v", r.path, err)
+	}
+
+	r.mu.Lock()
+	r.routes = routes
+	r.modTime = info.ModTime()
+	r.mu.Unlock()
+	return true, nil
+}
+
+// parseNotificationRoutes decodes and validates a routing table
+func parseNotificationRoutes(data []byte) (models.NotificationRoutes, error) {
+	var routes models.NotificationRoutes
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&routes); err != nil {
+		return models.NotificationRoutes{}, err
+	}
+
+	var errs []error
+	for _, group := range routes.Default {
+		if err := validateGroupJID(group); err != nil {
+			errs = append(errs, fmt.Errorf("default: %v", err))
+		}
+	}
+	for category, groups := range routes.Routes {
+		if !slices.Contains(models.NotificationCategories, category) {
+			errs = append(errs, fmt.Errorf("unknown category %q, expected one of %v", category, models.NotificationCategories))
+		}
+		for _, group := range groups {
+			if err := validateGroupJID(group); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", category, err))
+			}
+		}
+	}
+	return routes, errors.Join(errs...)
+}
+
+// validateGroupJID checks that a JID addresses a WhatsApp group
+func validateGroupJID(value string) error {
+	jid, err := types.ParseJID(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a JID: %v", value, err)
+	}
+	if jid.Server != types.GroupServer || jid.User == "" {
+		return fmt.Errorf("%q is not a group JID (…@%s)", value, types.GroupServer)
+	}
+	return nil
+}
+
+// SendToGroups sends a message to every group routed for the category
+func SendToGroups(ctx context.Context, messenger Messenger, routes GroupRouter, category, message string) error {
+	var errs []error
+	for _, group := range routes.Groups(category) {
+		if err := messenger.SendGroupMessage(ctx, group, message); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", group, err))
+		}
+	}
+	return errors.Join(errs...)
+}
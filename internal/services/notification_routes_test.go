@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+const (
+	testFallbackGroup     = "120363000000000001@g.us"
+	testConstructionGroup = "120363000000000002@g.us"
+	testRentalGroup       = "120363000000000003@g.us"
+)
+
+// testGroupRouter sends every alert to testFallbackGroup
+var testGroupRouter = NewStaticNotificationRouter(models.NotificationRoutes{}, testFallbackGroup)
+
+func writeRoutes(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Make the change visible even on file systems with coarse timestamps
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNotificationRouterGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.json")
+	writeRoutes(t, path, `{
+		"routes": {
+			"construction": ["`+testConstructionGroup+`"],
+			"rental": ["`+testRentalGroup+`", "`+testFallbackGroup+`"]
+		}
+	}`, time.Now())
+
+	router, err := NewNotificationRouter(&config.Config{WhatsAppGroupJID: testFallbackGroup, NotificationRoutesFile: path})
+	if err != nil {
+		t.Fatalf("NewNotificationRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		category string
+		want     []string
+	}{
+		{models.CategoryConstruction, []string{testConstructionGroup}},
+		{models.CategoryRental, []string{testRentalGroup, testFallbackGroup}},
+		{models.CategorySellRequest, []string{testFallbackGroup}},
+		{"unknown", []string{testFallbackGroup}},
+	}
+	for _, tt := range tests {
+		if got := router.Groups(tt.category); !slices.Equal(got, tt.want) {
+			t.Errorf("Groups(%q) = %v, want %v", tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestNotificationRouterReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.json")
+	start := time.Now().Add(-time.Hour)
+	writeRoutes(t, path, `{"default": ["`+testConstructionGroup+`"]}`, start)
+
+	router, err := NewNotificationRouter(&config.Config{WhatsAppGroupJID: testFallbackGroup, NotificationRoutesFile: path})
+	if err != nil {
+		t.Fatalf("NewNotificationRouter() error = %v", err)
+	}
+	if got := router.Groups(models.CategorySearch); !slices.Equal(got, []string{testConstructionGroup}) {
+		t.Fatalf("Groups() = %v, want the default of the file", got)
+	}
+
+	// An invalid file keeps the current routes
+	writeRoutes(t, path, `{"routes": {"rental": ["not-a-group"]}}`, start.Add(time.Minute))
+	if _, err := router.reload(); err == nil {
+		t.Error("reload() accepted an invalid group JID")
+	}
+	if got := router.Groups(models.CategoryRental); !slices.Equal(got, []string{testConstructionGroup}) {
+		t.Errorf("Groups() after an invalid file = %v, want the previous routes", got)
+	}
+
+	writeRoutes(t, path, `{"routes": {"rental": ["`+testRentalGroup+`"]}}`, start.Add(2*time.Minute))
+	if reloaded, err := router.reload(); err != nil || !reloaded {
+		t.Fatalf("reload() = %v, %v", reloaded, err)
+	}
+	if got := router.Groups(models.CategoryRental); !slices.Equal(got, []string{testRentalGroup}) {
+		t.Errorf("Groups(rental) after reload = %v", got)
+	}
+	if got := router.Groups(models.CategorySearch); !slices.Equal(got, []string{testFallbackGroup}) {
+		t.Errorf("Groups(search) without a default = %v, want WHATSAPP_GROUP_JID", got)
+	}
+	if reloaded, _ := router.reload(); reloaded {
+		t.Error("reload() read an unchanged file again")
+	}
+}
+
+func TestParseNotificationRoutesRejectsMistakes(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"unknown category", `{"routes": {"constructon": ["` + testConstructionGroup + `"]}}`, "unknown category"},
+		{"user instead of group", `{"default": ["919000000001@s.whatsapp.net"]}`, "not a group JID"},
+		{"unknown field", `{"defaults": []}`, "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseNotificationRoutes([]byte(tt.json))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseNotificationRoutes() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendToGroups(t *testing.T) {
+	messenger := NewFakeMessenger()
+	routes := NewStaticNotificationRouter(models.NotificationRoutes{
+		Routes: map[string][]string{models.CategoryRental: {testRentalGroup, testFallbackGroup}},
+	}, testFallbackGroup)
+
+	if err := SendToGroups(context.Background(), messenger, routes, models.CategoryRental, "New listing"); err != nil {
+		t.Fatalf("SendToGroups() error = %v", err)
+	}
+	groups := messenger.GroupMessages()
+	if len(groups) != 2 || groups[0].Recipient != testRentalGroup || groups[1].Recipient != testFallbackGroup {
+		t.Errorf("group messages = %+v, want one per routed group", groups)
+	}
+}
@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// outboxLease is how long a claimed message stays invisible to other workers
+// while it is being delivered. If the process dies mid-send the message becomes
+// due again once the lease runs out.
+const outboxLease = 2 * time.Minute
+
+// enqueueRegistrationTimeout bounds how long queueing a direct message waits for
+// WhatsApp to tell whether the number is registered
+const enqueueRegistrationTimeout = 3 * time.Second
+
+const outboxColumns = `id, recipient_type, recipient, payload, status, attempts,
+	next_attempt_at, last_error, wa_message_id, created_at, sent_at, scheduled_for, immediate`
+
+// Outbox persists outbound WhatsApp messages and delivers them in the background.
+// Handlers only ever write to the outbox, so a message survives a disconnected
+// client or a restart and goes out once the session is back.
+type Outbox struct {
+	db            *pgxpool.Pool
+	whatsapp      *WhatsAppService
+	tracker       *MessageTracker
+	registrations RegistrationChecker
+	policy        *DeliveryPolicy
+	templates     MessageRenderer
+	routes        GroupRouter
+	config        *config.Config
+	wake          chan struct{}
+}
+
+var _ ScheduledMessages = (*Outbox)(nil)
+
+// NewOutbox creates a new outbox backed by the whatsapp_outbox table. Direct messages to
+// numbers already known not to be on WhatsApp are refused, other numbers are checked
+// before delivery and the team is alerted about those that turn out unregistered. Direct
+// messages are never delivered during quiet hours unless queued for immediate delivery.
+func NewOutbox(db *pgxpool.Pool, whatsapp *WhatsAppService, tracker *MessageTracker, registrations RegistrationChecker, policy *DeliveryPolicy, templates MessageRenderer, routes GroupRouter, cfg *config.Config) *Outbox {
+	outbox := &Outbox{
+		db:            db,
+		whatsapp:      whatsapp,
+		tracker:       tracker,
+		registrations: registrations,
+		policy:        policy,
+		templates:     templates,
+		routes:        routes,
+		config:        cfg,
+		wake:          make(chan struct{}, 1),
+	}
+
+	// Flush the queue as soon as the session comes back
+	whatsapp.AddEventHandler(func(evt interface{}) {
+		if _, ok := evt.(*events.Connected); ok {
+			outbox.notify()
+		}
+	})
+
+	return outbox
+}
+
+// SendMessage queues a WhatsApp message to the specified phone number
+func (o *Outbox) SendMessage(ctx context.Context, phoneNumber, message string) error {
+	_, err := o.Enqueue(ctx, models.RecipientUser, phoneNumber, models.OutboundMessage{Text: message})
+	return err
+}
+
+// SendGroupMessage queues a WhatsApp message to a group
+func (o *Outbox) SendGroupMessage(ctx context.Context, groupJID, message string) error {
+	_, err := o.Enqueue(ctx, models.RecipientGroup, groupJID, models.OutboundMessage{Text: message})
+	return err
+}
+
+// SendMedia queues a media message with an optional caption to the specified phone number
+func (o *Outbox) SendMedia(ctx context.Context, phoneNumber string, media models.MediaAttachment, caption string) error {
+	_, err := o.Enqueue(ctx, models.RecipientUser, phoneNumber, models.OutboundMessage{Text: caption, Media: &media})
+	return err
+}
+
+// SendGroupMedia queues a media message with an optional caption to a group
+func (o *Outbox) SendGroupMedia(ctx context.Context, groupJID string, media models.MediaAttachment, caption string) error {
+	_, err := o.Enqueue(ctx, models.RecipientGroup, groupJID, models.OutboundMessage{Text: caption, Media: &media})
+	return err
+}
+
+// SendLinkPreview queues a message whose link renders as a card to the specified phone number
+func (o *Outbox) SendLinkPreview(ctx context.Context, phoneNumber, message string, preview models.LinkPreview) error {
+	_, err := o.Enqueue(ctx, models.RecipientUser, phoneNumber, models.OutboundMessage{Text: message, Preview: &preview})
+	return err
+}
+
+// SendGroupLinkPreview queues a message whose link renders as a card to a group
+func (o *Outbox) SendGroupLinkPreview(ctx context.Context, groupJID, message string, preview models.LinkPreview) error {
+	_, err := o.Enqueue(ctx, models.RecipientGroup, groupJID, models.OutboundMessage{Text: message, Preview: &preview})
+	return err
+}
+
+// SendLocation queues a location pin to the specified phone number
+func (o *Outbox) SendLocation(ctx context.Context, phoneNumber string, location models.Location) error {
+	_, err := o.Enqueue(ctx, models.RecipientUser, phoneNumber, models.OutboundMessage{Location: &location})
+	return err
+}
+
+// SendGroupLocation queues a location pin to a group
+func (o *Outbox) SendGroupLocation(ctx context.Context, groupJID string, location models.Location) error {
+	_, err := o.Enqueue(ctx, models.RecipientGroup, groupJID, models.OutboundMessage{Location: &location})
+	return err
+}
+
+// Enqueue writes a message to the outbox and wakes the delivery worker. Direct
+// messages queued during quiet hours are scheduled for when they end.
+func (o *Outbox) Enqueue(ctx context.Context, recipientType models.RecipientType, recipient string, payload models.OutboundMessage) (int64, error) {
+	// Queue phone numbers in the form WhatsApp addresses them, an impossible number would never be delivered
+	if recipientType == models.RecipientUser {
+		normalized, err := normalizePhone(recipient, o.config.DefaultPhoneCountry)
+		if err != nil {
+			return 0, err
+		}
+		recipient = normalized
+
+		// Callers flag users that can't be reached, numbers WhatsApp doesn't answer for
+		// in time are checked again by the worker
+		if err := checkRegistrationWithin(ctx, o.registrations, recipient, enqueueRegistrationTimeout); err != nil {
+			return 0, err
+		}
+	}
+
+	immediate := recipientType == models.RecipientUser && immediateDelivery(ctx)
+	var scheduledFor *time.Time
+	if until, held := o.quietUntil(recipientType, immediate, time.Now()); held {
+		scheduledFor = &until
+	}
+
+	var id int64
+	err := o.db.QueryRow(ctx,
+		`INSERT INTO whatsapp_outbox (recipient_type, recipient, payload, scheduled_for, immediate, next_attempt_at)
+		 VALUES ($1, $2, $3, $4, $5, COALESCE($4, now())) RETURNING id`,
+		recipientType, recipient, payload, scheduledFor, immediate,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue message: %v", err)
+	}
+
+	if scheduledFor != nil {
+		log.Printf("Outbox: Queued message %d for %s %s, held for quiet hours until %s", id, recipientType, recipient, scheduledFor.Format(time.RFC3339))
+		return id, nil
+	}
+	log.Printf("Outbox: Queued message %d for %s %s", id, recipientType, recipient)
+	o.notify()
+	return id, nil
+}
+
+// ListScheduled returns the messages held for quiet hours, the next to go out first
+func (o *Outbox) ListScheduled(ctx context.Context) ([]models.OutboxMessage, error) {
+	rows, err := o.db.Query(ctx,
+		`SELECT `+outboxColumns+` FROM whatsapp_outbox
+		 WHERE status = 'pending' AND scheduled_for > now()
+		 ORDER BY scheduled_for, id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled messages: %v", err)
+	}
+	messages, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.OutboxMessage])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled messages: %v", err)
+	}
+	return messages, nil
+}
+
+// CancelScheduled cancels a message held for quiet hours. Once its time has come the
+// message may already be on its way and can't be cancelled anymore.
+func (o *Outbox) CancelScheduled(ctx context.Context, id int64) (models.OutboxMessage, error) {
+	rows, err := o.db.Query(ctx,
+		`UPDATE whatsapp_outbox SET status = 'cancelled'
+		 WHERE id = $1 AND status = 'pending' AND scheduled_for > now()
+		 RETURNING `+outboxColumns,
+		id,
+	)
+	if err != nil {
+		return models.OutboxMessage{}, fmt.Errorf("failed to cancel message %d: %v", id, err)
+	}
+	msg, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.OutboxMessage])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.OutboxMessage{}, fmt.Errorf("%w: %d", ErrNotScheduled, id)
+	}
+	if err != nil {
+		return models.OutboxMessage{}, fmt.Errorf("failed to cancel message %d: %v", id, err)
+	}
+	log.Printf("Outbox: Cancelled scheduled message %d for %s %s", msg.ID, msg.RecipientType, msg.Recipient)
+	return msg, nil
+}
+
+// Run delivers due messages until the context is cancelled
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.config.OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		o.processDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-o.wake:
+		}
+	}
+}
+
+// notify wakes the worker without blocking if a wake-up is already pending
+func (o *Outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// processDue delivers every message that is due, one at a time
+func (o *Outbox) processDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		// Leave messages untouched while offline so they don't burn attempts
+		if !o.whatsapp.IsConnected() {
+			return
+		}
+
+		msg, err := o.claimNext(ctx)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			log.Printf("Outbox Error: Failed to claim message: %v", err)
+			return
+		}
+
+		// A retry or a message that waited for the session may fall due during quiet hours
+		if until, held := o.quietUntil(msg.RecipientType, msg.Immediate, time.Now()); held {
+			log.Printf("Outbox: Message %d held for quiet hours until %s", msg.ID, until.Format(time.RFC3339))
+			o.hold(ctx, msg.ID, msg.Attempts-1, until)
+			continue
+		}
+
+		o.deliver(ctx, msg)
+	}
+}
+
+// claimNext takes the oldest due message and leases it to this worker
+func (o *Outbox) claimNext(ctx context.Context) (models.OutboxMessage, error) {
+	rows, err := o.db.Query(ctx,
+		`UPDATE whatsapp_outbox
+		 SET attempts = attempts + 1, next_attempt_at = now() + $1::interval
+		 WHERE id = (
+			SELECT id FROM whatsapp_outbox
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+outboxColumns,
+		outboxLease,
+	)
+	if err != nil {
+		return models.OutboxMessage{}, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[models.OutboxMessage])
+}
+
+// deliver sends a claimed message and records the outcome
+func (o *Outbox) deliver(ctx context.Context, msg models.OutboxMessage) {
+	messageID, err := o.send(ctx, msg)
+	if err == nil {
+		o.tracker.RecordSent(ctx, msg, messageID)
+		_, err = o.db.Exec(ctx,
+			`UPDATE whatsapp_outbox
+			 SET status = 'sent', sent_at = now(), wa_message_id = $2, last_error = NULL
+			 WHERE id = $1`,
+			msg.ID, string(messageID),
+		)
+		if err != nil {
+			log.Printf("Outbox Error: Message %d was sent but could not be marked: %v", msg.ID, err)
+		}
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrNotConnected):
+		// The session dropped between claiming and sending, don't count this attempt
+		log.Printf("Outbox: Message %d postponed, WhatsApp is not connected", msg.ID)
+		o.reschedule(ctx, msg.ID, msg.Attempts-1, 0, err)
+	case errors.Is(err, ErrInvalidRecipient), errors.Is(err, ErrMediaUnavailable), errors.Is(err, ErrNotOnWhatsApp), msg.Attempts >= o.config.OutboxMaxAttempts:
+		log.Printf("Outbox Error: Message %d moved to dead letter after %d attempt(s): %v", msg.ID, msg.Attempts, err)
+		o.markDead(ctx, msg.ID, err)
+		if errors.Is(err, ErrNotOnWhatsApp) {
+			o.alertUndeliverable(ctx, msg)
+		}
+	default:
+		delay := o.backoff(msg.Attempts)
+		log.Printf("Outbox: Message %d failed (attempt %d), retrying in %s: %v", msg.ID, msg.Attempts, delay, err)
+		o.reschedule(ctx, msg.ID, msg.Attempts, delay, err)
+	}
+}
+
+// alertUndeliverable tells the team a user has to be reached another way, the number
+// turned out not to be on WhatsApp after the message was queued
+func (o *Outbox) alertUndeliverable(ctx context.Context, msg models.OutboxMessage) {
+	data := models.UndeliverableMessageData{Phone: msg.Recipient, OutboxID: msg.ID, Text: msg.Payload.Text}
+	if err := sendAlert(ctx, o, o.templates, o.routes, models.CategoryDelivery, TemplateUndeliverableAlert, data); err != nil {
+		log.Printf("Outbox Error: Failed to alert about undeliverable message %d: %v", msg.ID, err)
+	}
+}
+
+// send delivers a message over WhatsApp. The number of a direct message is checked
+// first, asking WhatsApp unless the answer is cached.
+func (o *Outbox) send(ctx context.Context, msg models.OutboxMessage) (types.MessageID, error) {
+	if msg.RecipientType == models.RecipientUser {
+		// An invalid number is reported by Deliver
+		if phoneNumber, err := normalizePhone(msg.Recipient, o.config.DefaultPhoneCountry); err == nil {
+			if err := checkRegistration(ctx, o.registrations, phoneNumber); err != nil {
+				return "", err
+			}
+		}
+	}
+	return o.whatsapp.Deliver(ctx, msg)
+}
+
+// quietUntil returns when quiet hours end if a message may not go out at the given time
+func (o *Outbox) quietUntil(recipientType models.RecipientType, immediate bool, at time.Time) (time.Time, bool) {
+	if recipientType != models.RecipientUser || immediate {
+		return time.Time{}, false
+	}
+	next := o.policy.Next(at)
+	return next, next.After(at)
+}
+
+// hold schedules a claimed message for the end of quiet hours without counting the
+// attempt, operators can list and cancel it like any other scheduled message
+func (o *Outbox) hold(ctx context.Context, id int64, attempts int, until time.Time) {
+	_, err := o.db.Exec(ctx,
+		`UPDATE whatsapp_outbox
+		 SET attempts = $2, next_attempt_at = $3, scheduled_for = $3
+		 WHERE id = $1`,
+		id, attempts, until,
+	)
+	if err != nil {
+		log.Printf("Outbox Error: Failed to hold message %d: %v", id, err)
+	}
+}
+
+// reschedule puts a message back in the queue after the given delay
+func (o *Outbox) reschedule(ctx context.Context, id int64, attempts int, delay time.Duration, cause error) {
+	_, err := o.db.Exec(ctx,
+		`UPDATE whatsapp_outbox
+		 SET attempts = $2, next_attempt_at = now() + $3::interval, last_error = $4
+		 WHERE id = $1`,
+		id, attempts, delay, cause.Error(),
+	)
+	if err != nil {
+		log.Printf("Outbox Error: Failed to reschedule message %d: %v", id, err)
+	}
+}
+
+// markDead moves a message to the dead-letter state, it won't be retried again
+func (o *Outbox) markDead(ctx context.Context, id int64, cause error) {
+	_, err := o.db.Exec(ctx,
+		`UPDATE whatsapp_outbox SET status = 'dead', last_error = $2 WHERE id = $1`,
+		id, cause.Error(),
+	)
+	if err != nil {
+		log.Printf("Outbox Error: Failed to dead-letter message %d: %v", id, err)
+	}
+}
+
+// Stats summarizes the queue for health checks. Sent messages pile up over time, so
+// every part of the query is limited to rows covered by a partial index.
+func (o *Outbox) Stats(ctx context.Context) (models.OutboxStats, error) {
+	var stats models.OutboxStats
+	err := o.db.QueryRow(ctx,
+		`SELECT pending.total, pending.due, pending.scheduled,
+			(SELECT count(*) FROM whatsapp_outbox WHERE status = 'dead'),
+			pending.oldest,
+			(SELECT max(sent_at) FROM whatsapp_outbox WHERE status = 'sent')
+		 FROM (
+			SELECT
+				count(*) AS total,
+				count(*) FILTER (WHERE next_attempt_at <= now()) AS due,
+				count(*) FILTER (WHERE scheduled_for > now()) AS scheduled,
+				min(created_at) AS oldest
+			FROM whatsapp_outbox WHERE status = 'pending'
+		 ) AS pending`,
+	).Scan(&stats.Pending, &stats.Due, &stats.Scheduled, &stats.Dead, &stats.OldestPendingAt, &stats.LastSentAt)
+	if err != nil {
+		return models.OutboxStats{}, fmt.Errorf("failed to read outbox stats: %v", err)
+	}
+	return stats, nil
+}
+
+// backoff returns the retry delay after the given number of attempts
+func (o *Outbox) backoff(attempts int) time.Duration {
+	delay := o.config.OutboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= o.config.OutboxMaxBackoff {
+			return o.config.OutboxMaxBackoff
+		}
+	}
+	return delay
+}
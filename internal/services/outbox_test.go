@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	outbox := &Outbox{config: &config.Config{
+		OutboxBaseBackoff: 30 * time.Second,
+		OutboxMaxBackoff:  5 * time.Minute,
+	}}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{5, 5 * time.Minute},
+		{20, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := outbox.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestOutboxQuietUntil(t *testing.T) {
+	policy, err := NewDeliveryPolicy(&config.Config{QuietHoursStart: "21:00", QuietHoursEnd: "08:00", DeliveryTimezone: "Asia/Kolkata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox := &Outbox{policy: policy}
+	night := time.Date(2026, time.March, 10, 23, 0, 0, 0, policy.location)
+	morning := time.Date(2026, time.March, 11, 8, 0, 0, 0, policy.location)
+
+	// A message queued at 20:59 or a retry that falls due at night waits for the morning
+	if until, held := outbox.quietUntil(models.RecipientUser, false, night); !held || !until.Equal(morning) {
+		t.Errorf("quietUntil = %s, %v, want %s", until, held, morning)
+	}
+	if _, held := outbox.quietUntil(models.RecipientUser, true, night); held {
+		t.Error("replies to a user must not be held")
+	}
+	if _, held := outbox.quietUntil(models.RecipientGroup, false, night); held {
+		t.Error("group alerts must not be held")
+	}
+	if _, held := outbox.quietUntil(models.RecipientUser, false, morning); held {
+		t.Error("messages must go out once quiet hours end")
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+// ErrAlreadyPaired is returned when pairing is requested for a device that is already linked
+var ErrAlreadyPaired = errors.New("device is already paired")
+
+// ErrPairingInProgress is returned when pairing is requested while another one is running
+var ErrPairingInProgress = errors.New("pairing is already in progress")
+
+// ErrInvalidPairingMethod is returned for pairing methods other than qr and phone
+var ErrInvalidPairingMethod = errors.New("invalid pairing method")
+
+// PairingController lets operators link the WhatsApp device over HTTP
+type PairingController interface {
+	// StartPairing queues a pairing request, it runs as soon as the connection supervisor picks it up
+	StartPairing(request models.PairingRequest) (models.PairingStatus, error)
+	PairingStatus() models.PairingStatus
+	// SubscribePairing returns a channel receiving every status change until cancel is called
+	SubscribePairing() (updates <-chan models.PairingStatus, cancel func())
+}
+
+// pairing hands pairing requests from operators to the connection loop and tracks
+// their progress
+type pairing struct {
+	config *config.Config
+	paired func() bool
+	// requested is signalled when a request is queued
+	requested chan struct{}
+
+	mu          sync.Mutex
+	pending     *models.PairingRequest
+	status      models.PairingStatus
+	subscribers map[chan models.PairingStatus]struct{}
+}
+
+func newPairing(cfg *config.Config, paired func() bool) *pairing {
+	p := &pairing{
+		config:      cfg,
+		paired:      paired,
+		requested:   make(chan struct{}, 1),
+		status:      models.PairingStatus{State: models.PairingIdle, UpdatedAt: time.Now()},
+		subscribers: make(map[chan models.PairingStatus]struct{}),
+	}
+
+	// Pair right away on the first start if the configuration says how
+	switch models.PairingMethod(cfg.WhatsAppPairingMode) {
+	case models.PairingQR:
+		p.pending = &models.PairingRequest{Method: models.PairingQR}
+	case models.PairingPhone:
+		if cfg.WhatsAppPhoneNumber == "" {
+			break
+		}
+		phone, err := normalizePhone(cfg.WhatsAppPhoneNumber, cfg.DefaultPhoneCountry)
+		if err != nil {
+			log.Printf("WhatsApp Error: Invalid WHATSAPP_PHONE_NUMBER, pairing has to be started over HTTP: %v", err)
+			break
+		}
+		p.pending = &models.PairingRequest{Method: models.PairingPhone, Phone: phone}
+	}
+	return p
+}
+
+// start validates and queues a pairing request
+func (p *pairing) start(request models.PairingRequest) (models.PairingStatus, error) {
+	if request.Method == "" {
+		request.Method = models.PairingQR
+		if request.Phone != "" {
+			request.Method = models.PairingPhone
+		}
+	}
+	switch request.Method {
+	case models.PairingQR:
+		request.Phone = ""
+	case models.PairingPhone:
+		if request.Phone == "" {
+			return models.PairingStatus{}, fmt.Errorf("%w: phone pairing needs a phone number", ErrInvalidRecipient)
+		}
+		phone, err := normalizePhone(request.Phone, p.config.DefaultPhoneCountry)
+		if err != nil {
+			return models.PairingStatus{}, err
+		}
+		request.Phone = phone
+	default:
+		return models.PairingStatus{}, fmt.Errorf("%w: %q", ErrInvalidPairingMethod, request.Method)
+	}
+
+	if p.paired() {
+		return models.PairingStatus{}, ErrAlreadyPaired
+	}
+
+	p.mu.Lock()
+	switch p.status.State {
+	case models.PairingStarting, models.PairingQRReady, models.PairingCodeReady:
+		p.mu.Unlock()
+		return models.PairingStatus{}, ErrPairingInProgress
+	}
+	p.pending = &request
+	status := p.publish(models.PairingStatus{State: models.PairingStarting, Method: request.Method, Phone: request.Phone})
+	p.mu.Unlock()
+
+	select {
+	case p.requested <- struct{}{}:
+	default:
+	}
+	log.Printf("WhatsApp: %s pairing requested", request.Method)
+	return status, nil
+}
+
+// await blocks until a pairing request is queued and takes it
+func (p *pairing) await(ctx context.Context) (models.PairingRequest, error) {
+	for {
+		p.mu.Lock()
+		request := p.pending
+		p.pending = nil
+		p.mu.Unlock()
+
+		if request != nil {
+			p.update(models.PairingStatus{State: models.PairingStarting, Method: request.Method, Phone: request.Phone})
+			return *request, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return models.PairingRequest{}, ctx.Err()
+		case <-p.requested:
+		}
+	}
+}
+
+// showQR publishes a new QR code, valid for timeout
+func (p *pairing) showQR(code string, timeout time.Duration) {
+	expires := time.Now().Add(timeout)
+	p.modify(func(status *models.PairingStatus) {
+		status.State = models.PairingQRReady
+		status.QRCode = code
+		status.ExpiresAt = &expires
+	})
+}
+
+// showCode publishes the code to enter on the phone
+func (p *pairing) showCode(code string) {
+	p.modify(func(status *models.PairingStatus) {
+		status.State = models.PairingCodeReady
+		status.PairingCode = code
+	})
+}
+
+// succeed marks the pairing as done
+func (p *pairing) succeed() {
+	p.modify(func(status *models.PairingStatus) {
+		status.State = models.PairingSuccess
+	})
+}
+
+// fail marks the pairing as failed, a new one has to be requested
+func (p *pairing) fail(err error) {
+	p.modify(func(status *models.PairingStatus) {
+		status.State = models.PairingFailed
+		status.Error = err.Error()
+	})
+}
+
+// reset forgets the last pairing after the device was cleared
+func (p *pairing) reset() {
+	p.update(models.PairingStatus{State: models.PairingIdle})
+}
+
+// modify changes the state of the current pairing, dropping the QR code and
+// pairing code of the previous state
+func (p *pairing) modify(change func(status *models.PairingStatus)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := models.PairingStatus{Method: p.status.Method, Phone: p.status.Phone}
+	change(&status)
+	p.publish(status)
+}
+
+// update replaces the status
+func (p *pairing) update(status models.PairingStatus) models.PairingStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.publish(status)
+}
+
+// publish stores the status and sends it to every subscriber, p.mu must be held
+func (p *pairing) publish(status models.PairingStatus) models.PairingStatus {
+	status.UpdatedAt = time.Now()
+	p.status = status
+	for subscriber := range p.subscribers {
+		select {
+		case subscriber <- status:
+		default:
+			// A slow subscriber misses an update, the next one carries the full status
+		}
+	}
+	return status
+}
+
+// current returns the status of the last pairing
+func (p *pairing) current() models.PairingStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// subscribe registers a channel for status updates
+func (p *pairing) subscribe() (<-chan models.PairingStatus, func()) {
+	updates := make(chan models.PairingStatus, 8)
+
+	p.mu.Lock()
+	p.subscribers[updates] = struct{}{}
+	p.mu.Unlock()
+
+	var once sync.Once
+	return updates, func() {
+		once.Do(func() {
+			p.mu.Lock()
+			delete(p.subscribers, updates)
+			p.mu.Unlock()
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+func TestPairingStartValidatesRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		request models.PairingRequest
+		want    models.PairingRequest
+		err     error
+	}{
+		{"default to qr", models.PairingRequest{}, models.PairingRequest{Method: models.PairingQR}, nil},
+		{"phone implies phone pairing", models.PairingRequest{Phone: "090355 77330"}, models.PairingRequest{Method: models.PairingPhone, Phone: "919035577330"}, nil},
+		{"phone pairing without phone", models.PairingRequest{Method: models.PairingPhone}, models.PairingRequest{}, ErrInvalidRecipient},
+		{"invalid phone", models.PairingRequest{Method: models.PairingPhone, Phone: "12"}, models.PairingRequest{}, ErrInvalidRecipient},
+		{"unknown method", models.PairingRequest{Method: "carrier-pigeon"}, models.PairingRequest{}, ErrInvalidPairingMethod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPairing(&config.Config{}, func() bool { return false })
+			status, err := p.start(tt.request)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("start() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("start() error = %v", err)
+			}
+			if status.State != models.PairingStarting || status.Method != tt.want.Method || status.Phone != tt.want.Phone {
+				t.Errorf("start() status = %+v, want %s pairing of %q", status, tt.want.Method, tt.want.Phone)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			request, err := p.await(ctx)
+			if err != nil || request != tt.want {
+				t.Errorf("await() = %+v, %v, want %+v", request, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPairingStartRejectsPairedAndRunning(t *testing.T) {
+	paired := true
+	p := newPairing(&config.Config{}, func() bool { return paired })
+
+	if _, err := p.start(models.PairingRequest{}); !errors.Is(err, ErrAlreadyPaired) {
+		t.Errorf("start() on a paired device error = %v, want ErrAlreadyPaired", err)
+	}
+
+	paired = false
+	if _, err := p.start(models.PairingRequest{}); err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	if _, err := p.start(models.PairingRequest{}); !errors.Is(err, ErrPairingInProgress) {
+		t.Errorf("second start() error = %v, want ErrPairingInProgress", err)
+	}
+
+	p.fail(errors.New("pairing ended without success (timeout)"))
+	if _, err := p.start(models.PairingRequest{}); err != nil {
+		t.Errorf("start() after a failed pairing error = %v", err)
+	}
+}
+
+func TestPairingConfiguredMode(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want *models.PairingRequest
+	}{
+		{"qr", config.Config{WhatsAppPairingMode: "qr"}, &models.PairingRequest{Method: models.PairingQR}},
+		{"phone", config.Config{WhatsAppPairingMode: "phone", WhatsAppPhoneNumber: "+91 90355 77330"}, &models.PairingRequest{Method: models.PairingPhone, Phone: "919035577330"}},
+		{"phone without number waits for a request", config.Config{WhatsAppPairingMode: "phone"}, nil},
+		{"invalid number waits for a request", config.Config{WhatsAppPairingMode: "phone", WhatsAppPhoneNumber: "123"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPairing(&tt.cfg, func() bool { return false })
+			switch {
+			case tt.want == nil && p.pending != nil:
+				t.Errorf("pending = %+v, want none", *p.pending)
+			case tt.want != nil && (p.pending == nil || *p.pending != *tt.want):
+				t.Errorf("pending = %v, want %+v", p.pending, *tt.want)
+			}
+		})
+	}
+}
+
+func TestPairingPublishesUpdates(t *testing.T) {
+	p := newPairing(&config.Config{}, func() bool { return false })
+	updates, cancel := p.subscribe()
+	defer cancel()
+
+	if _, err := p.start(models.PairingRequest{Phone: "+919035577330"}); err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	p.showCode("ABCD-EFGH")
+	p.succeed()
+
+	var states []models.PairingState
+	for range 3 {
+		select {
+		case status := <-updates:
+			states = append(states, status.State)
+			if status.State == models.PairingCodeReady && status.PairingCode != "ABCD-EFGH" {
+				t.Errorf("pairing code = %q, want ABCD-EFGH", status.PairingCode)
+			}
+			if status.Phone != "919035577330" {
+				t.Errorf("phone = %q, want it kept across updates", status.Phone)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("got updates %v, want 3", states)
+		}
+	}
+	if states[0] != models.PairingStarting || states[1] != models.PairingCodeReady || states[2] != models.PairingSuccess {
+		t.Errorf("states = %v", states)
+	}
+	if code := p.current().PairingCode; code != "" {
+		t.Errorf("pairing code after success = %q, want it cleared", code)
+	}
+
+	cancel()
+	p.reset()
+	select {
+	case status := <-updates:
+		t.Errorf("got %+v after unsubscribing", status)
+	default:
+	}
+}
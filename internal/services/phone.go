@@ -0,0 +1,44 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/phone"
+)
+
+// normalizePhone converts a stored phone number into the international digits
+// WhatsApp JIDs use, logging any correction so bad data in users.phone can be fixed
+// at the source. Impossible numbers are reported as ErrInvalidRecipient.
+func normalizePhone(raw, defaultCountry string) (string, error) {
+	number, err := phone.Normalize(raw, defaultCountry)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRecipient, err)
+	}
+	if number.Changed() {
+		log.Printf("Phone: Normalized %q to %s (%s)", raw, number.E164, strings.Join(number.Changes, ", "))
+	}
+	return number.Digits(), nil
+}
+
+// userPhone returns a user's phone number the way WhatsApp reports senders, so
+// replies can be matched to them. Numbers that can't be normalized are kept as digits.
+func userPhone(raw, defaultCountry string) string {
+	digits, err := normalizePhone(raw, defaultCountry)
+	if err != nil {
+		log.Printf("Phone Error: %v", err)
+		return phoneDigits(raw)
+	}
+	return digits
+}
+
+// phoneDigits strips everything but digits from a phone number
+func phoneDigits(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
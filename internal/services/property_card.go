@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+// PropertyURL returns the public link of a listing
+func PropertyURL(propertyID int64) string {
+	return fmt.Sprintf("https://easyplots.in/property/%d", propertyID)
+}
+
+// PropertyLinkPreview builds the card shown for a listing link, with the title,
+// a summary of size, price and facing, and a thumbnail of imageURL when given.
+// The message it is sent with must contain PropertyURL of the same listing.
+func PropertyLinkPreview(property models.Property, imageURL string) models.LinkPreview {
+	preview := models.LinkPreview{
+		URL:         PropertyURL(property.ID),
+		Title:       property.Title,
+		Description: PropertySummary(property),
+	}
+	if preview.Title == "" {
+		preview.Title = fmt.Sprintf("Property #%d", property.ID)
+	}
+	if imageURL != "" {
+		preview.Image = &models.MediaAttachment{Kind: models.MediaImage, URL: imageURL}
+	}
+	return preview
+}
+
+// PropertySummary describes a listing in one line, e.g.
+// "30x40 · ₹45 Lakh (Negotiable) · East facing"
+func PropertySummary(property models.Property) string {
+	var parts []string
+	if size := strings.TrimSpace(property.Size); size != "" {
+		parts = append(parts, size)
+	}
+
+	switch {
+	case property.Rental && property.RentAmount != nil:
+		parts = append(parts, formatRupees(int64(*property.RentAmount))+"/month")
+	case property.EstimatedPrice != nil:
+		price := formatRupees(int64(*property.EstimatedPrice))
+		if property.Negotiable {
+			price += " (Negotiable)"
+		}
+		parts = append(parts, price)
+	}
+
+	if property.Facing != nil && strings.TrimSpace(*property.Facing) != "" {
+		parts = append(parts, strings.TrimSpace(*property.Facing)+" facing")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatRupees writes an amount the way Indian listings do: in Crore and Lakh
+// for large amounts and with Indian digit grouping below that
+func formatRupees(amount int64) string {
+	switch {
+	case amount >= 1_00_00_000:
+		return "₹" + trimDecimals(float64(amount)/1_00_00_000) + " Crore"
+	case amount >= 1_00_000:
+		return "₹" + trimDecimals(float64(amount)/1_00_000) + " Lakh"
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if len(digits) <= 3 {
+		return "₹" + digits
+	}
+	// The last three digits form a group, every two digits before that another
+	head, tail := digits[:len(digits)-3], digits[len(digits)-3:]
+	var groups []string
+	for len(head) > 2 {
+		groups = append([]string{head[len(head)-2:]}, groups...)
+		head = head[:len(head)-2]
+	}
+	groups = append([]string{head}, groups...)
+	return "₹" + strings.Join(append(groups, tail), ",")
+}
+
+// trimDecimals formats a value with up to two decimals, dropping trailing zeros
+func trimDecimals(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(strconv.FormatFloat(value, 'f', 2, 64), "0"), ".")
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+func TestFormatRupees(t *testing.T) {
+	tests := map[int64]string{
+		950:         "₹950",
+		75000:       "₹75,000",
+		4500000:     "₹45 Lakh",
+		1250000:     "₹12.5 Lakh",
+		12000000:    "₹1.2 Crore",
+		99999:       "₹99,999",
+		10000000000: "₹1000 Crore",
+	}
+	for amount, want := range tests {
+		if got := formatRupees(amount); got != want {
+			t.Errorf("formatRupees(%d) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestPropertyLinkPreview(t *testing.T) {
+	price, facing := 4500000, "East"
+	property := models.Property{ID: 42, Title: "Corner plot", Size: "30x40", EstimatedPrice: &price, Negotiable: true, Facing: &facing}
+
+	preview := PropertyLinkPreview(property, "https://cdn.example.com/banner.jpg")
+	if preview.URL != "https://easyplots.in/property/42" || preview.Title != "Corner plot" {
+		t.Errorf("unexpected preview %+v", preview)
+	}
+	if preview.Description != "30x40 · ₹45 Lakh (Negotiable) · East facing" {
+		t.Errorf("unexpected description %q", preview.Description)
+	}
+	if preview.Image == nil || preview.Image.URL != "https://cdn.example.com/banner.jpg" {
+		t.Errorf("unexpected thumbnail source %+v", preview.Image)
+	}
+
+	rent := 12000.0
+	rental := models.Property{ID: 7, Rental: true, RentAmount: &rent}
+	if preview := PropertyLinkPreview(rental, ""); preview.Title != "Property #7" || preview.Description != "₹12,000/month" || preview.Image != nil {
+		t.Errorf("unexpected rental preview %+v", preview)
+	}
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// receiptTimeout bounds how long storing a single receipt may take
+	receiptTimeout = 10 * time.Second
+	// receiptBuffer is how long receipts are kept for messages RecordSent hasn't stored yet
+	receiptBuffer = time.Minute
+)
+
+// bufferedReceipt is a receipt kept until the message it is about has been recorded
+type bufferedReceipt struct {
+	status models.MessageStatus
+	at     time.Time
+}
+
+// MessageTracker records every message we send and follows its delivery
+// and read receipts so the team can see whether a customer saw it
+type MessageTracker struct {
+	messages repository.MessageRepository
+
+	// A receipt can arrive before RecordSent has stored its message, so recent receipts
+	// are kept for RecordSent to apply. Every receiptBuffer Run moves recent to previous
+	// and drops the old previous, keeping each receipt for one to two periods.
+	mu       sync.Mutex
+	recent   map[string][]bufferedReceipt
+	previous map[string][]bufferedReceipt
+}
+
+// NewMessageTracker creates a tracker and subscribes it to WhatsApp receipts
+func NewMessageTracker(messages repository.MessageRepository, whatsapp *WhatsAppService) *MessageTracker {
+	tracker := &MessageTracker{messages: messages}
+	whatsapp.AddEventHandler(func(evt interface{}) {
+		if receipt, ok := evt.(*events.Receipt); ok {
+			tracker.handleReceipt(receipt)
+		}
+	})
+	return tracker
+}
+
+// Run drops buffered receipts nobody claimed until ctx is cancelled
+func (t *MessageTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(receiptBuffer)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.rotate()
+		}
+	}
+}
+
+// RecordSent stores the WhatsApp ID of a message delivered from the outbox
+func (t *MessageTracker) RecordSent(ctx context.Context, outboxMsg models.OutboxMessage, messageID types.MessageID) {
+	outboxID := outboxMsg.ID
+	err := t.messages.Record(ctx, models.TrackedMessage{
+		MessageID:     string(messageID),
+		OutboxID:      &outboxID,
+		RecipientType: outboxMsg.RecipientType,
+		Recipient:     outboxMsg.Recipient,
+		SentAt:        time.Now(),
+	})
+	if err != nil {
+		log.Printf("Receipts Error: Failed to record message %s: %v", messageID, err)
+		return
+	}
+
+	for _, receipt := range t.buffered(string(messageID)) {
+		if err := t.messages.UpdateStatus(ctx, []string{string(messageID)}, receipt.status, receipt.at); err != nil {
+			log.Printf("Receipts Error: Failed to apply early %s receipt for %s: %v", receipt.status, messageID, err)
+		}
+	}
+}
+
+// buffer keeps a receipt in case its messages haven't been recorded yet
+func (t *MessageTracker) buffer(ids []string, receipt bufferedReceipt) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.recent == nil {
+		t.recent = make(map[string][]bufferedReceipt)
+	}
+	for _, id := range ids {
+		t.recent[id] = append(t.recent[id], receipt)
+	}
+}
+
+// rotate drops the receipts buffered before the last rotation
+func (t *MessageTracker) rotate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.previous, t.recent = t.recent, make(map[string][]bufferedReceipt)
+}
+
+// buffered removes and returns the receipts kept for a message, oldest first
+func (t *MessageTracker) buffered(id string) []bufferedReceipt {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	receipts := append(t.previous[id], t.recent[id]...)
+	delete(t.previous, id)
+	delete(t.recent, id)
+	return receipts
+}
+
+// handleReceipt applies a delivery, read or failure receipt to the tracked messages.
+// A read receipt in a group only tells that one member read the message, so those
+// are ignored.
+func (t *MessageTracker) handleReceipt(receipt *events.Receipt) {
+	status, ok := receiptStatus(receipt.Type)
+	if !ok || len(receipt.MessageIDs) == 0 {
+		return
+	}
+	if receipt.IsGroup && status == models.MessageRead {
+		return
+	}
+
+	ids := make([]string, len(receipt.MessageIDs))
+	for i, id := range receipt.MessageIDs {
+		ids[i] = string(id)
+	}
+
+	// Buffered before the update, so either the update finds the message or RecordSent
+	// finds the receipt
+	t.buffer(ids, bufferedReceipt{status: status, at: receipt.Timestamp})
+
+	ctx, cancel := context.WithTimeout(context.Background(), receiptTimeout)
+	defer cancel()
+
+	if err := t.messages.UpdateStatus(ctx, ids, status, receipt.Timestamp); err != nil {
+		log.Printf("Receipts Error: Failed to apply %s receipt from %s: %v", status, receipt.Chat, err)
+	}
+}
+
+// receiptStatus maps a WhatsApp receipt type to the message status it proves.
+// Receipts from our own devices and protocol-level receipts are ignored.
+func receiptStatus(receiptType types.ReceiptType) (models.MessageStatus, bool) {
+	switch receiptType {
+	case types.ReceiptTypeDelivered:
+		return models.MessageDelivered, true
+	case types.ReceiptTypeRead, types.ReceiptTypePlayed:
+		return models.MessageRead, true
+	case types.ReceiptTypeServerError:
+		return models.MessageFailed, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestMessageTrackerFollowsReceipts(t *testing.T) {
+	ctx := context.Background()
+	messages := repository.NewMemoryMessageRepository()
+	tracker := &MessageTracker{messages: messages}
+
+	tracker.RecordSent(ctx, models.OutboxMessage{ID: 1, RecipientType: models.RecipientUser, Recipient: "919000000001"}, "MSG1")
+
+	sentAt := time.Now()
+	tracker.handleReceipt(&events.Receipt{MessageIDs: []types.MessageID{"MSG1"}, Type: types.ReceiptTypeRead, Timestamp: sentAt})
+	// A delivery receipt arriving late must not downgrade a read message
+	tracker.handleReceipt(&events.Receipt{MessageIDs: []types.MessageID{"MSG1"}, Type: types.ReceiptTypeDelivered, Timestamp: sentAt.Add(time.Second)})
+	// Receipts from our own devices are ignored
+	tracker.handleReceipt(&events.Receipt{MessageIDs: []types.MessageID{"MSG1"}, Type: types.ReceiptTypeSender, Timestamp: sentAt})
+
+	msg, err := messages.GetByID(ctx, "MSG1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Status != models.MessageRead {
+		t.Errorf("expected status read, got %s", msg.Status)
+	}
+	if msg.ReadAt == nil || !msg.ReadAt.Equal(sentAt) || msg.DeliveredAt == nil {
+		t.Errorf("unexpected timestamps: delivered %v, read %v", msg.DeliveredAt, msg.ReadAt)
+	}
+	if msg.OutboxID == nil || *msg.OutboxID != 1 {
+		t.Errorf("expected outbox id 1, got %v", msg.OutboxID)
+	}
+}
+
+func TestMessageTrackerAppliesEarlyReceipts(t *testing.T) {
+	ctx := context.Background()
+	messages := repository.NewMemoryMessageRepository()
+	tracker := &MessageTracker{messages: messages}
+
+	// The delivery receipt beats RecordSent, e.g. while the outbox is still saving the send
+	deliveredAt := time.Now()
+	tracker.handleReceipt(&events.Receipt{MessageIDs: []types.MessageID{"MSG1"}, Type: types.ReceiptTypeDelivered, Timestamp: deliveredAt})
+	tracker.RecordSent(ctx, models.OutboxMessage{ID: 1, RecipientType: models.RecipientUser, Recipient: "919000000001"}, "MSG1")
+
+	msg, err := messages.GetByID(ctx, "MSG1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Status != models.MessageDelivered || msg.DeliveredAt == nil || !msg.DeliveredAt.Equal(deliveredAt) {
+		t.Errorf("expected the early receipt to be applied, got %+v", msg)
+	}
+	if receipts := tracker.buffered("MSG1"); len(receipts) != 0 {
+		t.Errorf("applied receipts must be dropped, got %+v", receipts)
+	}
+}
+
+func TestMessageTrackerIgnoresGroupReadReceipts(t *testing.T) {
+	ctx := context.Background()
+	messages := repository.NewMemoryMessageRepository()
+	tracker := &MessageTracker{messages: messages}
+
+	tracker.RecordSent(ctx, models.OutboxMessage{ID: 1, RecipientType: models.RecipientGroup, Recipient: "120363000000000000@g.us"}, "MSG1")
+	group := types.MessageSource{IsGroup: true}
+	tracker.handleReceipt(&events.Receipt{MessageSource: group, MessageIDs: []types.MessageID{"MSG1"}, Type: types.ReceiptTypeDelivered, Timestamp: time.Now()})
+	tracker.handleReceipt(&events.Receipt{MessageSource: group, MessageIDs: []types.MessageID{"MSG1"}, Type: types.ReceiptTypeRead, Timestamp: time.Now()})
+
+	msg, err := messages.GetByID(ctx, "MSG1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Status != models.MessageDelivered || msg.ReadAt != nil {
+		t.Errorf("a member reading a group message must not mark it read, got %+v", msg)
+	}
+}
+
+func TestMessageTrackerExpiresBufferedReceipts(t *testing.T) {
+	tracker := &MessageTracker{messages: repository.NewMemoryMessageRepository()}
+	tracker.handleReceipt(&events.Receipt{MessageIDs: []types.MessageID{"MSG1"}, Type: types.ReceiptTypeDelivered, Timestamp: time.Now()})
+
+	tracker.rotate()
+	if receipts := tracker.buffered("MSG1"); len(receipts) != 1 {
+		t.Fatalf("a receipt must survive one rotation, got %+v", receipts)
+	}
+
+	tracker.handleReceipt(&events.Receipt{MessageIDs: []types.MessageID{"MSG2"}, Type: types.ReceiptTypeDelivered, Timestamp: time.Now()})
+	tracker.rotate()
+	tracker.rotate()
+	if receipts := tracker.buffered("MSG2"); len(receipts) != 0 {
+		t.Errorf("receipts must be dropped after two rotations, got %+v", receipts)
+	}
+}
+
+func TestReceiptStatus(t *testing.T) {
+	tests := []struct {
+		receipt types.ReceiptType
+		want    models.MessageStatus
+		ok      bool
+	}{
+		{types.ReceiptTypeDelivered, models.MessageDelivered, true},
+		{types.ReceiptTypeRead, models.MessageRead, true},
+		{types.ReceiptTypePlayed, models.MessageRead, true},
+		{types.ReceiptTypeServerError, models.MessageFailed, true},
+		{types.ReceiptTypeReadSelf, "", false},
+		{types.ReceiptTypeRetry, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := receiptStatus(tt.receipt)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("receiptStatus(%q) = %s, %v; want %s, %v", tt.receipt, got, ok, tt.want, tt.ok)
+		}
+	}
+}
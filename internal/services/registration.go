@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+)
+
+// ErrNotOnWhatsApp is returned when a message is sent to a number without a WhatsApp
+// account. The user has to be called instead.
+var ErrNotOnWhatsApp = errors.New("not on WhatsApp")
+
+// RegistrationChecker tells whether a phone number has a WhatsApp account
+type RegistrationChecker interface {
+	// IsOnWhatsApp checks a normalized phone number (international digits). An error
+	// means the answer is unknown, not that the number is unregistered.
+	IsOnWhatsApp(ctx context.Context, phone string) (bool, error)
+	// CachedIsOnWhatsApp answers from earlier checks only, known is false when there is
+	// no fresh answer for the number
+	CachedIsOnWhatsApp(ctx context.Context, phone string) (registered, known bool)
+}
+
+// RegistrationLookup asks WhatsApp whether a phone number is registered
+type RegistrationLookup interface {
+	LookupRegistration(ctx context.Context, phone string) (models.WhatsAppRegistration, error)
+}
+
+// RegistrationCache answers registration checks from Postgres and only asks WhatsApp
+// when the cached answer is missing or older than the configured TTL
+type RegistrationCache struct {
+	registrations repository.RegistrationRepository
+	lookup        RegistrationLookup
+	config        *config.Config
+}
+
+var _ RegistrationChecker = (*RegistrationCache)(nil)
+
+// NewRegistrationCache creates a registration checker backed by the whatsapp_registrations table
+func NewRegistrationCache(registrations repository.RegistrationRepository, lookup RegistrationLookup, cfg *config.Config) *RegistrationCache {
+	return &RegistrationCache{
+		registrations: registrations,
+		lookup:        lookup,
+		config:        cfg,
+	}
+}
+
+// IsOnWhatsApp returns the cached answer while it is fresh and checks with WhatsApp otherwise
+func (r *RegistrationCache) IsOnWhatsApp(ctx context.Context, phone string) (bool, error) {
+	if registered, known := r.CachedIsOnWhatsApp(ctx, phone); known {
+		return registered, nil
+	}
+
+	registration, err := r.lookup.LookupRegistration(ctx, phone)
+	if err != nil {
+		return false, err
+	}
+	registration.Phone = phone
+	registration.CheckedAt = time.Now()
+
+	if err := r.registrations.Save(ctx, registration); err != nil {
+		log.Printf("Registration Error: %v", err)
+	}
+	if !registration.Registered {
+		log.Printf("Registration: %s is not on WhatsApp", phone)
+	}
+	return registration.Registered, nil
+}
+
+// CachedIsOnWhatsApp returns the cached answer while it is fresh
+func (r *RegistrationCache) CachedIsOnWhatsApp(ctx context.Context, phone string) (bool, bool) {
+	cached, err := r.registrations.Get(ctx, phone)
+	switch {
+	case err == nil && time.Since(cached.CheckedAt) < r.config.WhatsAppRegistrationTTL:
+		return cached.Registered, true
+	case err != nil && !errors.Is(err, repository.ErrRegistrationNotFound):
+		log.Printf("Registration Error: %v", err)
+	}
+	return false, false
+}
+
+// checkRegistrationWithin is checkRegistration waiting at most timeout for WhatsApp.
+// A fresh cached answer is used as is, a number WhatsApp doesn't answer for in time is
+// let through and checked again before delivery.
+func checkRegistrationWithin(ctx context.Context, checker RegistrationChecker, phone string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return checkRegistration(ctx, checker, phone)
+}
+
+// checkRegistration returns ErrNotOnWhatsApp for a number known to be unregistered.
+// Numbers that can't be checked right now are let through.
+func checkRegistration(ctx context.Context, checker RegistrationChecker, phone string) error {
+	if checker == nil {
+		return nil
+	}
+	registered, err := checker.IsOnWhatsApp(ctx, phone)
+	if err != nil {
+		if !errors.Is(err, ErrNotConnected) {
+			log.Printf("Registration Error: Could not check %s: %v", phone, err)
+		}
+		return nil
+	}
+	if !registered {
+		return fmt.Errorf("%w: %s", ErrNotOnWhatsApp, phone)
+	}
+	return nil
+}
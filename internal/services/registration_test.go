@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+)
+
+// fakeLookup answers registration lookups from a fixed set of registered numbers
+type fakeLookup struct {
+	registered map[string]bool
+	err        error
+	calls      int
+	// delay holds every lookup back, like a slow session
+	delay time.Duration
+}
+
+func (f *fakeLookup) LookupRegistration(ctx context.Context, phone string) (models.WhatsAppRegistration, error) {
+	f.calls++
+	select {
+	case <-ctx.Done():
+		return models.WhatsAppRegistration{}, ctx.Err()
+	case <-time.After(f.delay):
+	}
+	if f.err != nil {
+		return models.WhatsAppRegistration{}, f.err
+	}
+	return models.WhatsAppRegistration{Phone: phone, Registered: f.registered[phone]}, nil
+}
+
+func TestRegistrationCacheUsesFreshResults(t *testing.T) {
+	ctx := context.Background()
+	registrations := repository.NewMemoryRegistrationRepository()
+	lookup := &fakeLookup{registered: map[string]bool{"919000000001": true}}
+	cache := NewRegistrationCache(registrations, lookup, &config.Config{WhatsAppRegistrationTTL: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		if registered, err := cache.IsOnWhatsApp(ctx, "919000000001"); err != nil || !registered {
+			t.Fatalf("expected registered, got %v, %v", registered, err)
+		}
+	}
+	if lookup.calls != 1 {
+		t.Errorf("expected one lookup, got %d", lookup.calls)
+	}
+
+	// A stale answer is checked again
+	registrations.Save(ctx, models.WhatsAppRegistration{Phone: "919000000002", Registered: true, CheckedAt: time.Now().Add(-2 * time.Hour)})
+	if registered, err := cache.IsOnWhatsApp(ctx, "919000000002"); err != nil || registered {
+		t.Errorf("expected the stale answer to be replaced, got %v, %v", registered, err)
+	}
+	if cached, _ := registrations.Get(ctx, "919000000002"); cached.Registered || time.Since(cached.CheckedAt) > time.Minute {
+		t.Errorf("expected a fresh negative answer to be cached, got %+v", cached)
+	}
+}
+
+func TestCheckRegistration(t *testing.T) {
+	ctx := context.Background()
+	lookup := &fakeLookup{registered: map[string]bool{"919000000001": true}}
+	cache := NewRegistrationCache(repository.NewMemoryRegistrationRepository(), lookup, &config.Config{WhatsAppRegistrationTTL: time.Hour})
+
+	if err := checkRegistration(ctx, cache, "919000000001"); err != nil {
+		t.Errorf("expected a registered number to pass, got %v", err)
+	}
+	if err := checkRegistration(ctx, cache, "919000000009"); !errors.Is(err, ErrNotOnWhatsApp) {
+		t.Errorf("expected ErrNotOnWhatsApp, got %v", err)
+	}
+
+	// When WhatsApp can't be asked the message goes out rather than being dropped
+	lookup.err = ErrNotConnected
+	if err := checkRegistration(ctx, cache, "919000000007"); err != nil {
+		t.Errorf("expected an unknown number to pass, got %v", err)
+	}
+	if err := checkRegistration(ctx, nil, "919000000009"); err != nil {
+		t.Errorf("expected no check without a checker, got %v", err)
+	}
+}
+
+func TestCheckRegistrationWithin(t *testing.T) {
+	ctx := context.Background()
+	lookup := &fakeLookup{}
+	cache := NewRegistrationCache(repository.NewMemoryRegistrationRepository(), lookup, &config.Config{WhatsAppRegistrationTTL: time.Hour})
+
+	// A number the cache doesn't know yet is checked with WhatsApp right away
+	if err := checkRegistrationWithin(ctx, cache, "919000000009", time.Second); !errors.Is(err, ErrNotOnWhatsApp) {
+		t.Errorf("expected ErrNotOnWhatsApp for an unchecked unregistered number, got %v", err)
+	}
+	if lookup.calls != 1 {
+		t.Fatalf("expected one lookup, got %d", lookup.calls)
+	}
+
+	// The answer is cached from then on
+	lookup.delay = time.Hour
+	if err := checkRegistrationWithin(ctx, cache, "919000000009", time.Millisecond); !errors.Is(err, ErrNotOnWhatsApp) {
+		t.Errorf("expected the cached answer, got %v", err)
+	}
+
+	// A lookup that doesn't answer in time lets the number through for the worker to check
+	start := time.Now()
+	if err := checkRegistrationWithin(ctx, cache, "919000000008", 10*time.Millisecond); err != nil {
+		t.Errorf("expected a slow lookup to let the number through, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("check took %s, want it bounded by the timeout", elapsed)
+	}
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/phone"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+const (
+	minIntakePhotos = 2
+	maxIntakePhotos = 3
+
+	minDescriptionLength = 10
+	minRent              = 500
+	maxRent              = 10000000
+
+	// intakeSweepInterval is how often inactive intakes are expired
+	intakeSweepInterval = time.Minute
+	// photoQueueSize is how many received photos may wait to be downloaded
+	photoQueueSize = 32
+)
+
+var (
+	// ErrInvalidRent is returned when a reply can't be read as a monthly rent
+	ErrInvalidRent = errors.New("invalid rent amount")
+	// ErrInvalidContact is returned when a reply isn't a usable phone number
+	ErrInvalidContact = errors.New("invalid contact number")
+
+	rentPattern  = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(k|thousand|lakhs?|lacs?|l)?\b`)
+	rentNoise    = strings.NewReplacer(",", "", "₹", "", "rs.", "", "rs", "", "inr", "", "/-", "")
+	doneReplies  = map[string]bool{"done": true, "next": true, "finished": true}
+	sameContacts = map[string]bool{"same": true, "same number": true, "this number": true, "this one": true}
+)
+
+// intakePrompts are the message templates asking the user for each step of the intake
+var intakePrompts = map[models.IntakeStep]string{
+	models.IntakePhotos:      TemplateRentalIntakePhotos,
+	models.IntakeLocation:    TemplateRentalIntakeLocation,
+	models.IntakeDescription: TemplateRentalIntakeDescription,
+	models.IntakeRent:        TemplateRentalIntakeRent,
+	models.IntakeContact:     TemplateRentalIntakeContact,
+}
+
+// MediaDownloader downloads the media attached to an inbound WhatsApp message
+type MediaDownloader interface {
+	Download(ctx context.Context, msg whatsmeow.DownloadableMessage) ([]byte, error)
+}
+
+// photoDownload is a photo received during the photo step, waiting to be downloaded
+type photoDownload struct {
+	intakeID  int64
+	phone     string
+	messageID string
+	image     *waE2E.ImageMessage
+}
+
+// RentalIntakeStarter starts a rental intake conversation with a user
+type RentalIntakeStarter interface {
+	Start(ctx context.Context, user models.User) error
+}
+
+// RentalIntakeFlow walks a user through listing a rental property over WhatsApp,
+// one item at a time, and turns the answers into a draft for the team to review.
+// Progress is stored in the database so a restart doesn't lose it.
+type RentalIntakeFlow struct {
+	intakes   repository.RentalIntakeRepository
+	messenger Messenger
+	templates MessageRenderer
+	media     MediaDownloader
+	routes    GroupRouter
+	config    *config.Config
+	photos    chan photoDownload
+}
+
+var _ RentalIntakeStarter = (*RentalIntakeFlow)(nil)
+
+// NewRentalIntakeFlow creates a new rental intake flow
+func NewRentalIntakeFlow(intakes repository.RentalIntakeRepository, messenger Messenger, templates MessageRenderer, media MediaDownloader, routes GroupRouter, cfg *config.Config) *RentalIntakeFlow {
+	return &RentalIntakeFlow{
+		intakes:   intakes,
+		messenger: messenger,
+		templates: templates,
+		media:     media,
+		routes:    routes,
+		config:    cfg,
+		photos:    make(chan photoDownload, photoQueueSize),
+	}
+}
+
+// Register hooks the flow into the inbound router for the replies it expects
+func (f *RentalIntakeFlow) Register(router *InboundRouter) {
+	router.Handle(models.ChatDirect, models.ContentText, f.handle)
+	router.Handle(models.ChatDirect, models.ContentImage, f.handle)
+	router.Handle(models.ChatDirect, models.ContentLocation, f.handle)
+}
+
+// Start begins a new intake for the user and asks for the first item
+func (f *RentalIntakeFlow) Start(ctx context.Context, user models.User) error {
+	intake, err := f.intakes.Start(ctx, models.RentalIntake{
+		UserID: user.ID,
+		Phone:  userPhone(user.Phone, f.config.DefaultPhoneCountry),
+		Locale: user.Locale(),
+		Step:   models.IntakePhotos,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Rental Intake: Started intake %d for user %s", intake.ID, user.ID)
+	return f.send(ctx, intake, intakePrompts[models.IntakePhotos], false)
+}
+
+// Run saves received photos and expires intakes that have been inactive for longer
+// than the configured timeout, until the context is cancelled
+func (f *RentalIntakeFlow) Run(ctx context.Context) {
+	ticker := time.NewTicker(intakeSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case download := <-f.photos:
+			f.savePhoto(ctx, download)
+		case <-ticker.C:
+			f.expireInactive(ctx)
+		}
+	}
+}
+
+// expireInactive closes stale intakes and lets their users know
+func (f *RentalIntakeFlow) expireInactive(ctx context.Context) {
+	expired, err := f.intakes.ExpireInactive(ctx, time.Now().Add(-f.config.RentalIntakeTimeout))
+	if err != nil {
+		log.Printf("Rental Intake Error: %v", err)
+		return
+	}
+
+	for _, intake := range expired {
+		log.Printf("Rental Intake: Intake %d expired at step %s", intake.ID, intake.Step)
+		if err := f.send(ctx, intake, TemplateRentalIntakeExpired, false); err != nil {
+			log.Printf("Rental Intake Error: Failed to tell the user intake %d expired: %v", intake.ID, err)
+		}
+	}
+}
+
+// handle processes a reply from a user with an active intake
+func (f *RentalIntakeFlow) handle(ctx context.Context, msg *InboundMessage) (bool, error) {
+	intake, err := f.intakes.GetActiveByPhone(ctx, msg.SenderPhone)
+	if errors.Is(err, repository.ErrIntakeNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The sweeper will expire it shortly, meanwhile don't treat the message as an answer
+	if time.Since(intake.UpdatedAt) > f.config.RentalIntakeTimeout {
+		return false, nil
+	}
+
+	switch intake.Step {
+	case models.IntakePhotos:
+		return true, f.handlePhoto(ctx, intake, msg)
+	case models.IntakeLocation:
+		return true, f.handleLocation(ctx, intake, msg)
+	case models.IntakeDescription:
+		return true, f.handleDescription(ctx, intake, msg)
+	case models.IntakeRent:
+		return true, f.handleRent(ctx, intake, msg)
+	case models.IntakeContact:
+		return true, f.handleContact(ctx, intake, msg)
+	default:
+		return false, fmt.Errorf("intake %d is at unknown step %q", intake.ID, intake.Step)
+	}
+}
+
+func (f *RentalIntakeFlow) handlePhoto(ctx context.Context, intake models.RentalIntake, msg *InboundMessage) error {
+	if msg.ContentType == models.ContentText && doneReplies[normalizeReply(msg.Text)] {
+		if len(intake.Photos) < minIntakePhotos {
+			return f.reply(ctx, intake, TemplateRentalIntakeMorePhotos, false)
+		}
+		return f.advance(ctx, intake, models.IntakeLocation)
+	}
+
+	image := msg.Event.Message.GetImageMessage()
+	if msg.ContentType != models.ContentImage || image == nil {
+		return f.reply(ctx, intake, intakePrompts[models.IntakePhotos], false)
+	}
+
+	// Downloading would hold up every other WhatsApp event, Run saves the photo instead
+	select {
+	case f.photos <- photoDownload{intakeID: intake.ID, phone: intake.Phone, messageID: msg.ID, image: image}:
+		return nil
+	default:
+		log.Printf("Rental Intake Error: Photo queue is full, dropping photo %s", msg.ID)
+		return f.reply(ctx, intake, TemplateRentalIntakePhotoResend, false)
+	}
+}
+
+// savePhoto downloads a queued photo and adds it to its intake. Photos are saved one
+// at a time so concurrent photos can't overwrite each other's progress.
+func (f *RentalIntakeFlow) savePhoto(ctx context.Context, download photoDownload) {
+	ctx, cancel := context.WithTimeout(ctx, inboundTimeout)
+	defer cancel()
+
+	if err := f.addPhoto(ctx, download); err != nil {
+		log.Printf("Rental Intake Error: Failed to save photo %s: %v", download.messageID, err)
+	}
+}
+
+func (f *RentalIntakeFlow) addPhoto(ctx context.Context, download photoDownload) error {
+	// The intake may have moved on while the photo was waiting, e.g. after the last photo
+	intake, err := f.intakes.GetActiveByPhone(ctx, download.phone)
+	if errors.Is(err, repository.ErrIntakeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if intake.ID != download.intakeID || intake.Step != models.IntakePhotos {
+		return nil
+	}
+
+	data, err := f.media.Download(ctx, download.image)
+	if err != nil {
+		log.Printf("Rental Intake Error: Failed to download photo %s: %v", download.messageID, err)
+		return f.reply(ctx, intake, TemplateRentalIntakePhotoResend, false)
+	}
+
+	dir := filepath.Join(f.config.MediaDir, "rental", strconv.FormatInt(intake.ID, 10))
+	path, err := saveMedia(dir, download.messageID, download.image.GetMimetype(), data)
+	if err != nil {
+		return err
+	}
+	intake.Photos = append(intake.Photos, path)
+
+	if len(intake.Photos) >= maxIntakePhotos {
+		return f.advance(ctx, intake, models.IntakeLocation)
+	}
+	if err := f.intakes.Save(ctx, intake); err != nil {
+		return err
+	}
+	return f.reply(ctx, intake, TemplateRentalIntakePhotoReceived, false)
+}
+
+func (f *RentalIntakeFlow) handleLocation(ctx context.Context, intake models.RentalIntake, msg *InboundMessage) error {
+	if pin := msg.Event.Message.GetLocationMessage(); msg.ContentType == models.ContentLocation && pin != nil {
+		lat, lng := pin.GetDegreesLatitude(), pin.GetDegreesLongitude()
+		intake.Latitude, intake.Longitude = &lat, &lng
+		return f.advance(ctx, intake, models.IntakeDescription)
+	}
+
+	location, err := ParseMapsLink(msg.Text)
+	if err != nil {
+		return f.reply(ctx, intake, intakePrompts[models.IntakeLocation], true)
+	}
+	intake.MapsLink = &location.URL
+	intake.Latitude, intake.Longitude = location.Latitude, location.Longitude
+	return f.advance(ctx, intake, models.IntakeDescription)
+}
+
+func (f *RentalIntakeFlow) handleDescription(ctx context.Context, intake models.RentalIntake, msg *InboundMessage) error {
+	description := strings.TrimSpace(msg.Text)
+	if msg.ContentType != models.ContentText || len(description) < minDescriptionLength {
+		return f.reply(ctx, intake, intakePrompts[models.IntakeDescription], true)
+	}
+	intake.Description = &description
+	return f.advance(ctx, intake, models.IntakeRent)
+}
+
+func (f *RentalIntakeFlow) handleRent(ctx context.Context, intake models.RentalIntake, msg *InboundMessage) error {
+	rent, err := ParseRent(msg.Text)
+	if err != nil {
+		return f.reply(ctx, intake, intakePrompts[models.IntakeRent], true)
+	}
+	intake.Rent = &rent
+	return f.advance(ctx, intake, models.IntakeContact)
+}
+
+func (f *RentalIntakeFlow) handleContact(ctx context.Context, intake models.RentalIntake, msg *InboundMessage) error {
+	contact, err := ParseContact(msg.Text, intake.Phone, f.config.DefaultPhoneCountry)
+	if err != nil {
+		return f.reply(ctx, intake, intakePrompts[models.IntakeContact], true)
+	}
+	intake.ContactPhone = &contact
+	return f.complete(ctx, intake)
+}
+
+// advance moves the intake to the next step and asks for it
+func (f *RentalIntakeFlow) advance(ctx context.Context, intake models.RentalIntake, next models.IntakeStep) error {
+	intake.Step = next
+	if err := f.intakes.Save(ctx, intake); err != nil {
+		return err
+	}
+	return f.reply(ctx, intake, intakePrompts[next], false)
+}
+
+// complete creates the draft listing and tells the user and the team
+func (f *RentalIntakeFlow) complete(ctx context.Context, intake models.RentalIntake) error {
+	draftID, err := f.intakes.Complete(ctx, intake)
+	if err != nil {
+		return err
+	}
+	log.Printf("Rental Intake: Intake %d completed as draft %d", intake.ID, draftID)
+
+	if err := f.reply(ctx, intake, TemplateRentalIntakeComplete, false); err != nil {
+		return err
+	}
+
+	data := models.RentalIntakeMessageData{Intake: intake, DraftID: draftID}
+	return sendAlert(ctx, f.messenger, f.templates, f.routes, models.CategoryRental, TemplateRentalIntakeCompleteAlert, data)
+}
+
+// reply answers a message from the user of an intake, it goes out even during quiet hours
+func (f *RentalIntakeFlow) reply(ctx context.Context, intake models.RentalIntake, name string, retry bool) error {
+	return f.send(WithImmediateDelivery(ctx), intake, name, retry)
+}
+
+// send sends a message of the intake conversation to its user in their language,
+// retry asks the question of the step again after an answer we couldn't use
+func (f *RentalIntakeFlow) send(ctx context.Context, intake models.RentalIntake, name string, retry bool) error {
+	data := models.RentalIntakeMessageData{
+		Intake:        intake,
+		Retry:         retry,
+		MinPhotos:     minIntakePhotos,
+		MissingPhotos: max(minIntakePhotos-len(intake.Photos), 0),
+	}
+	return sendTemplate(ctx, f.messenger, f.templates, intake.Locale, intake.Phone, name, data)
+}
+
+// ParseRent reads a monthly rent like "15000", "₹15,000/-", "15k" or "1.2 lakh"
+func ParseRent(text string) (int64, error) {
+	cleaned := rentNoise.Replace(strings.ToLower(text))
+	match := rentPattern.FindStringSubmatch(cleaned)
+	if match == nil {
+		return 0, ErrInvalidRent
+	}
+
+	amount, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, ErrInvalidRent
+	}
+	switch match[2] {
+	case "k", "thousand":
+		amount *= 1000
+	case "l", "lakh", "lakhs", "lac", "lacs":
+		amount *= 100000
+	}
+
+	rent := int64(amount)
+	if rent < minRent || rent > maxRent {
+		return 0, ErrInvalidRent
+	}
+	return rent, nil
+}
+
+// ParseContact reads a contact number and normalizes it like every other phone number,
+// "SAME" means the number the user is chatting from
+func ParseContact(text, senderPhone, defaultCountry string) (string, error) {
+	if sameContacts[normalizeReply(text)] {
+		return senderPhone, nil
+	}
+	number, err := phone.Normalize(text, defaultCountry)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidContact, err)
+	}
+	return number.Digits(), nil
+}
+
+// normalizeReply lowercases a reply and strips surrounding whitespace and punctuation
+func normalizeReply(text string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(text)), ".!")
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeDownloader returns the same bytes for every media download
+type fakeDownloader struct{}
+
+func (fakeDownloader) Download(ctx context.Context, msg whatsmeow.DownloadableMessage) ([]byte, error) {
+	return []byte("jpeg"), nil
+}
+
+func newTestIntakeFlow(t *testing.T) (*RentalIntakeFlow, *repository.MemoryRentalIntakeRepository, *FakeMessenger) {
+	intakes := repository.NewMemoryRentalIntakeRepository()
+	messenger := NewFakeMessenger()
+	cfg := &config.Config{MediaDir: t.TempDir(), RentalIntakeTimeout: time.Hour}
+	return NewRentalIntakeFlow(intakes, messenger, testTemplates, fakeDownloader{}, testGroupRouter, cfg), intakes, messenger
+}
+
+func TestRentalIntakeFlowAsksInUserLocale(t *testing.T) {
+	ctx := context.Background()
+	flow, _, messenger := newTestIntakeFlow(t)
+	hindi := "hindi"
+	if err := flow.Start(ctx, models.User{ID: "u1", Phone: "919000000001", PrefLang: &hindi}); err != nil {
+		t.Fatal(err)
+	}
+	flow.handle(ctx, intakeText("T1", "done"))
+
+	direct := messenger.DirectMessages()
+	if len(direct) != 2 || !strings.Contains(direct[0].Text, "तस्वीरें") || !strings.Contains(direct[1].Text, "कम से कम 2 तस्वीरें") {
+		t.Errorf("expected the questions in Hindi, got %+v", direct)
+	}
+}
+
+func intakeText(id, text string) *InboundMessage {
+	return &InboundMessage{
+		ID: id, SenderPhone: "919000000001", ChatType: models.ChatDirect, ContentType: models.ContentText, Text: text,
+		Event: &events.Message{Message: &waE2E.Message{Conversation: proto.String(text)}},
+	}
+}
+
+func intakePhoto(id string) *InboundMessage {
+	return &InboundMessage{
+		ID: id, SenderPhone: "919000000001", ChatType: models.ChatDirect, ContentType: models.ContentImage,
+		Event: &events.Message{Message: &waE2E.Message{ImageMessage: &waE2E.ImageMessage{Mimetype: proto.String("image/jpeg")}}},
+	}
+}
+
+// savePhotos saves the queued photos like Run does
+func savePhotos(ctx context.Context, flow *RentalIntakeFlow) {
+	for {
+		select {
+		case download := <-flow.photos:
+			flow.savePhoto(ctx, download)
+		default:
+			return
+		}
+	}
+}
+
+func TestRentalIntakeFlowCompletesDraft(t *testing.T) {
+	ctx := context.Background()
+	flow, intakes, messenger := newTestIntakeFlow(t)
+
+	if err := flow.Start(ctx, models.User{ID: "u1", Phone: "+91 90000 00001"}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []*InboundMessage{
+		intakePhoto("P1"),
+		intakeText("T1", "done"), // too early, only one photo so far
+		intakePhoto("P2"),
+		intakePhoto("P3"), // third photo moves on automatically
+		intakeText("T2", "near the temple"),
+		intakeText("T3", "https://maps.google.com/?q=15.36,75.12"),
+		intakeText("T4", "2BHK ground floor with parking"),
+		intakeText("T5", "about fifteen"),
+		intakeText("T6", "₹15,000/month"),
+		intakeText("T7", "same"),
+	}
+	for _, msg := range steps {
+		handled, err := flow.handle(ctx, msg)
+		if err != nil || !handled {
+			t.Fatalf("message %s: handled=%v err=%v", msg.ID, handled, err)
+		}
+		savePhotos(ctx, flow)
+	}
+
+	draft, ok := intakes.Draft(1)
+	if !ok {
+		t.Fatal("expected a draft to be created")
+	}
+	if len(draft.Photos) != 3 || *draft.Rent != 15000 || *draft.ContactPhone != "919000000001" || *draft.Latitude != 15.36 {
+		t.Errorf("unexpected draft: %+v", draft)
+	}
+	if _, err := intakes.GetActiveByPhone(ctx, "919000000001"); !errors.Is(err, repository.ErrIntakeNotFound) {
+		t.Error("intake must no longer be active")
+	}
+
+	groups := messenger.GroupMessages()
+	if len(groups) != 1 || !strings.Contains(groups[0].Text, "Draft #1") {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+
+	// Replies after completion are left for other handlers
+	if handled, _ := flow.handle(ctx, intakeText("T8", "thanks")); handled {
+		t.Error("completed intake must not consume messages")
+	}
+}
+
+func TestRentalIntakeFlowQueuesPhotos(t *testing.T) {
+	ctx := context.Background()
+	flow, intakes, _ := newTestIntakeFlow(t)
+	flow.Start(ctx, models.User{ID: "u1", Phone: "919000000001"})
+
+	// The event handler only queues the photo, the download happens in Run
+	for _, id := range []string{"P1", "P2", "P3", "P4"} {
+		if handled, err := flow.handle(ctx, intakePhoto(id)); err != nil || !handled {
+			t.Fatalf("photo %s: handled=%v err=%v", id, handled, err)
+		}
+	}
+	if intake, _ := intakes.GetActiveByPhone(ctx, "919000000001"); len(intake.Photos) != 0 {
+		t.Fatalf("photos must not be downloaded by the event handler, got %v", intake.Photos)
+	}
+
+	savePhotos(ctx, flow)
+
+	// The fourth photo arrived after the intake moved on and is dropped
+	intake, _ := intakes.GetActiveByPhone(ctx, "919000000001")
+	if len(intake.Photos) != maxIntakePhotos || intake.Step != models.IntakeLocation {
+		t.Errorf("unexpected intake after saving photos: %+v", intake)
+	}
+}
+
+func TestRentalIntakeFlowExpiresInactiveIntakes(t *testing.T) {
+	ctx := context.Background()
+	flow, intakes, messenger := newTestIntakeFlow(t)
+	flow.Start(ctx, models.User{ID: "u1", Phone: "919000000001"})
+	intakes.Touch(1, time.Now().Add(-2*time.Hour))
+
+	if handled, _ := flow.handle(ctx, intakePhoto("P1")); handled {
+		t.Error("stale intake must not consume messages")
+	}
+
+	messenger.Reset()
+	flow.expireInactive(ctx)
+
+	if _, err := intakes.GetActiveByPhone(ctx, "919000000001"); !errors.Is(err, repository.ErrIntakeNotFound) {
+		t.Error("intake must be expired")
+	}
+	if direct := messenger.DirectMessages(); len(direct) != 1 || !strings.Contains(direct[0].Text, "timed out") {
+		t.Errorf("expected a timeout notice, got %+v", direct)
+	}
+}
+
+func TestParseRent(t *testing.T) {
+	tests := []struct {
+		text string
+		want int64
+	}{
+		{"15000", 15000},
+		{"₹15,000/-", 15000},
+		{"Rs. 8500 per month", 8500},
+		{"15k", 15000},
+		{"1.2 lakh", 120000},
+	}
+	for _, tt := range tests {
+		got, err := ParseRent(tt.text)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRent(%q) = %d, %v; want %d", tt.text, got, err, tt.want)
+		}
+	}
+
+	for _, text := range []string{"negotiable", "50", "", "999999999"} {
+		if _, err := ParseRent(text); !errors.Is(err, ErrInvalidRent) {
+			t.Errorf("ParseRent(%q) should fail, got %v", text, err)
+		}
+	}
+}
+
+func TestParseContact(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Same.", "919000000001"},
+		{"+91 98450-12345", "919845012345"},
+		{"098450 12345", "919845012345"},
+		{"98450 12345", "919845012345"},
+	}
+	for _, tt := range tests {
+		if got, err := ParseContact(tt.text, "919000000001", "IN"); err != nil || got != tt.want {
+			t.Errorf("ParseContact(%q) = %q, %v; want %q", tt.text, got, err, tt.want)
+		}
+	}
+
+	for _, text := range []string{"call me", "12345", "+91 12345 67890 123"} {
+		if _, err := ParseContact(text, "919000000001", "IN"); !errors.Is(err, ErrInvalidContact) {
+			t.Errorf("ParseContact(%q): expected ErrInvalidContact, got %v", text, err)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"go.mau.fi/whatsmeow"
+)
+
+const (
+	// checklistSweepInterval is how often sellers with missing items are looked up for reminders
+	checklistSweepInterval = 10 * time.Minute
+	// checklistQueueSize is how many seller messages may wait to be applied to their checklist
+	checklistQueueSize = 32
+)
+
+var (
+	sizePattern       = regexp.MustCompile(`(?i)\b\d+(?:\.\d+)?\s*(?:sq\.?\s*ft|sqft|square\s*feet|sq\.?\s*yards?|sq\.?\s*yds?|sq\.?\s*m(?:eters?)?\b|acres?|guntas?|guntha|cents?)`)
+	dimensionsPattern = regexp.MustCompile(`\b\d{2,3}\s*[x×*]\s*\d{2,3}\b`)
+	facingPattern     = regexp.MustCompile(`(?i)\b((?:north|south)(?:[\s-]?(?:east|west))?|east|west)[\s-]*facing\b|\bfacing\s*[:\-]?\s*((?:north|south)(?:[\s-]?(?:east|west))?|east|west)\b`)
+	utaaraPattern     = regexp.MustCompile(`(?i)\b(utaara|utara|uttara|rtc|pahani)\b`)
+
+	// propertyTypes maps the way sellers describe a property to the type we record,
+	// more specific patterns come first
+	propertyTypes = []struct {
+		pattern *regexp.Regexp
+		label   string
+	}{
+		{regexp.MustCompile(`(?i)\b(non[\s-]?agri(?:cultural)?|na)\b`), "NA"},
+		{regexp.MustCompile(`(?i)\bagri(?:cultural|culture)?\b|\bfarm\s*land\b`), "Agricultural"},
+		{regexp.MustCompile(`(?i)\bguntas?\b|\bguntha\b`), "Gunta"},
+		{regexp.MustCompile(`(?i)\bcommercial\b`), "Commercial"},
+		{regexp.MustCompile(`(?i)\bresidential\b`), "Residential"},
+	}
+)
+
+// checklistUpdate is a message from a seller waiting to be applied to their checklist
+type checklistUpdate struct {
+	checklistID int64
+	msg         *InboundMessage
+}
+
+// SellChecklistStarter starts tracking the details a seller still has to send
+type SellChecklistStarter interface {
+	Start(ctx context.Context, sellRequest models.SellRequest, user models.User) error
+}
+
+// SellChecklistTracker fills in the checklist of a sell request from what the seller
+// sends us over WhatsApp, reminds them about missing items and tells the team once
+// everything is in
+type SellChecklistTracker struct {
+	checklists repository.SellChecklistRepository
+	messenger  Messenger
+	templates  MessageRenderer
+	media      MediaDownloader
+	routes     GroupRouter
+	config     *config.Config
+	updates    chan checklistUpdate
+}
+
+var _ SellChecklistStarter = (*SellChecklistTracker)(nil)
+
+// NewSellChecklistTracker creates a new sell checklist tracker
+func NewSellChecklistTracker(checklists repository.SellChecklistRepository, messenger Messenger, templates MessageRenderer, media MediaDownloader, routes GroupRouter, cfg *config.Config) *SellChecklistTracker {
+	return &SellChecklistTracker{
+		checklists: checklists,
+		messenger:  messenger,
+		templates:  templates,
+		media:      media,
+		routes:     routes,
+		config:     cfg,
+		updates:    make(chan checklistUpdate, checklistQueueSize),
+	}
+}
+
+// Register hooks the tracker into the inbound router for the content sellers send us
+func (t *SellChecklistTracker) Register(router *InboundRouter) {
+	router.Handle(models.ChatDirect, models.ContentText, t.handle)
+	router.Handle(models.ChatDirect, models.ContentImage, t.handle)
+	router.Handle(models.ChatDirect, models.ContentLocation, t.handle)
+	router.Handle(models.ChatDirect, models.ContentDocument, t.handle)
+}
+
+// Start creates the checklist of a sell request. The seller is already asked for
+// the details by SellRequestWhatAppMessage, so nothing is sent here.
+func (t *SellChecklistTracker) Start(ctx context.Context, sellRequest models.SellRequest, user models.User) error {
+	checklist, err := t.checklists.Create(ctx, models.SellChecklist{
+		SellRequestID: sellRequest.Id,
+		UserID:        user.ID,
+		Phone:         userPhone(user.Phone, t.config.DefaultPhoneCountry),
+		Locale:        user.Locale(),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Sell Checklist: Tracking checklist %d for sell request %d", checklist.ID, sellRequest.Id)
+	return nil
+}
+
+// Run applies the messages sellers send and reminds them about missing items until
+// the context is cancelled
+func (t *SellChecklistTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(checklistSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-t.updates:
+			t.applyUpdate(ctx, update)
+		case <-ticker.C:
+			t.sendReminders(ctx)
+		}
+	}
+}
+
+// sendReminders reminds sellers who went quiet about the items still missing
+func (t *SellChecklistTracker) sendReminders(ctx context.Context) {
+	now := time.Now()
+	due, err := t.checklists.ListDueReminders(ctx, now.Add(-t.config.SellChecklistReminderInterval), t.config.SellChecklistMaxReminders)
+	if err != nil {
+		log.Printf("Sell Checklist Error: %v", err)
+		return
+	}
+
+	for _, checklist := range due {
+		data := models.SellChecklistMessageData{Checklist: checklist, Missing: checklist.Missing()}
+		if err := t.send(ctx, checklist, TemplateSellChecklistReminder, data); err != nil {
+			log.Printf("Sell Checklist Error: Failed to remind checklist %d: %v", checklist.ID, err)
+			continue
+		}
+		if err := t.checklists.MarkReminded(ctx, checklist.ID, now); err != nil {
+			log.Printf("Sell Checklist Error: %v", err)
+		}
+	}
+}
+
+// handle takes the messages from a seller that contain checklist items. Downloading
+// their media would hold up every other WhatsApp event, so Run applies them instead,
+// texts included so a checklist is updated in the order the seller wrote.
+func (t *SellChecklistTracker) handle(ctx context.Context, msg *InboundMessage) (bool, error) {
+	checklist, err := t.checklists.GetOpenByPhone(ctx, msg.SenderPhone)
+	if errors.Is(err, repository.ErrChecklistNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Leave unrelated chatter for the team to read in the conversation log
+	if msg.ContentType == models.ContentText && !applyChecklistText(&checklist, msg.Text) {
+		return false, nil
+	}
+
+	select {
+	case t.updates <- checklistUpdate{checklistID: checklist.ID, msg: msg}:
+		return true, nil
+	default:
+		return true, fmt.Errorf("checklist queue is full, dropping message %s", msg.ID)
+	}
+}
+
+// applyUpdate records the checklist items of a queued message, one message at a time
+func (t *SellChecklistTracker) applyUpdate(ctx context.Context, update checklistUpdate) {
+	ctx, cancel := context.WithTimeout(ctx, inboundTimeout)
+	defer cancel()
+
+	if err := t.apply(ctx, update); err != nil {
+		log.Printf("Sell Checklist Error: Failed to apply message %s: %v", update.msg.ID, err)
+	}
+}
+
+func (t *SellChecklistTracker) apply(ctx context.Context, update checklistUpdate) error {
+	msg := update.msg
+	// The checklist may have been completed while the message was waiting
+	checklist, err := t.checklists.GetOpenByPhone(ctx, msg.SenderPhone)
+	if errors.Is(err, repository.ErrChecklistNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if checklist.ID != update.checklistID {
+		return nil
+	}
+
+	updated := applyChecklistText(&checklist, msg.Text)
+
+	switch msg.ContentType {
+	case models.ContentImage:
+		image := msg.Event.Message.GetImageMessage()
+		path, err := t.saveMedia(ctx, checklist, msg.ID, image, image.GetMimetype())
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			return t.reply(ctx, checklist, TemplateSellChecklistResend, models.SellChecklistMessageData{Checklist: checklist})
+		}
+		// The Utaara copy is often photographed rather than scanned
+		if utaaraPattern.MatchString(msg.Text) {
+			checklist.UtaaraCopy = &path
+		} else {
+			checklist.Images = append(checklist.Images, path)
+		}
+		updated = true
+
+	case models.ContentDocument:
+		document := msg.Event.Message.GetDocumentMessage()
+		path, err := t.saveMedia(ctx, checklist, msg.ID, document, document.GetMimetype())
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			return t.reply(ctx, checklist, TemplateSellChecklistResend, models.SellChecklistMessageData{Checklist: checklist, Document: true})
+		}
+		checklist.UtaaraCopy = &path
+		updated = true
+
+	case models.ContentLocation:
+		location := msg.Text
+		checklist.MapLocation = &location
+		updated = true
+	}
+
+	if !updated {
+		return nil
+	}
+
+	missing := checklist.Missing()
+	if len(missing) == 0 {
+		return t.complete(ctx, checklist)
+	}
+	if err := t.checklists.Save(ctx, checklist); err != nil {
+		return err
+	}
+	return t.reply(ctx, checklist, TemplateSellChecklistProgress, models.SellChecklistMessageData{Checklist: checklist, Missing: missing})
+}
+
+// send sends a message about the checklist to the seller in their language
+func (t *SellChecklistTracker) send(ctx context.Context, checklist models.SellChecklist, name string, data models.SellChecklistMessageData) error {
+	return sendTemplate(ctx, t.messenger, t.templates, checklist.Locale, checklist.Phone, name, data)
+}
+
+// reply answers a message from the seller, it goes out even during quiet hours
+func (t *SellChecklistTracker) reply(ctx context.Context, checklist models.SellChecklist, name string, data models.SellChecklistMessageData) error {
+	return t.send(WithImmediateDelivery(ctx), checklist, name, data)
+}
+
+// saveMedia downloads the media of a message into the checklist's media directory.
+// It returns an empty path when the download failed and the seller should resend it.
+func (t *SellChecklistTracker) saveMedia(ctx context.Context, checklist models.SellChecklist, messageID string, media whatsmeow.DownloadableMessage, mimeType string) (string, error) {
+	data, err := t.media.Download(ctx, media)
+	if err != nil {
+		log.Printf("Sell Checklist Error: Failed to download media %s: %v", messageID, err)
+		return "", nil
+	}
+
+	dir := filepath.Join(t.config.MediaDir, "sell", strconv.FormatInt(checklist.ID, 10))
+	return saveMedia(dir, messageID, mimeType, data)
+}
+
+// complete closes the checklist and tells the seller and the team
+func (t *SellChecklistTracker) complete(ctx context.Context, checklist models.SellChecklist) error {
+	now := time.Now()
+	checklist.CompletedAt = &now
+	if err := t.checklists.Save(ctx, checklist); err != nil {
+		return err
+	}
+	log.Printf("Sell Checklist: Checklist %d of sell request %d is complete", checklist.ID, checklist.SellRequestID)
+
+	if err := t.reply(ctx, checklist, TemplateSellChecklistComplete, models.SellChecklistMessageData{Checklist: checklist}); err != nil {
+		return err
+	}
+
+	data := models.SellChecklistMessageData{Checklist: checklist}
+	return sendAlert(ctx, t.messenger, t.templates, t.routes, models.CategorySellRequest, TemplateSellChecklistCompleteAlert, data)
+}
+
+// applyChecklistText fills in the items found in a message text or caption and
+// reports whether anything new was recorded. Items already received are kept.
+func applyChecklistText(checklist *models.SellChecklist, text string) bool {
+	updated := false
+	set := func(field **string, value string, ok bool) {
+		if ok && *field == nil {
+			*field = &value
+			updated = true
+		}
+	}
+
+	size, ok := ParseSize(text)
+	set(&checklist.Size, size, ok)
+	propertyType, ok := ParsePropertyType(text)
+	set(&checklist.PropertyType, propertyType, ok)
+	facing, ok := ParseFacing(text)
+	set(&checklist.Facing, facing, ok)
+	if location, err := ParseMapsLink(text); err == nil {
+		set(&checklist.MapLocation, location.URL, true)
+	}
+	return updated
+}
+
+// ParseSize finds a property size like "1200 sqft", "2 acres", "10 guntas" or "30x40"
+func ParseSize(text string) (string, bool) {
+	if size := sizePattern.FindString(text); size != "" {
+		return strings.TrimSpace(size), true
+	}
+	if size := dimensionsPattern.FindString(text); size != "" {
+		return size, true
+	}
+	return "", false
+}
+
+// ParsePropertyType finds the type of property like NA, Agricultural or Gunta
+func ParsePropertyType(text string) (string, bool) {
+	for _, propertyType := range propertyTypes {
+		if propertyType.pattern.MatchString(text) {
+			return propertyType.label, true
+		}
+	}
+	return "", false
+}
+
+// ParseFacing finds the facing of a property, "north east facing" becomes "North-East"
+func ParseFacing(text string) (string, bool) {
+	match := facingPattern.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+	direction := match[1]
+	if direction == "" {
+		direction = match[2]
+	}
+
+	direction = strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(direction))
+	for _, side := range []string{"east", "west"} {
+		if prefix, ok := strings.CutSuffix(direction, side); ok && prefix != "" {
+			direction = prefix + "-" + side
+		}
+	}
+
+	parts := strings.Split(direction, "-")
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, "-"), true
+}
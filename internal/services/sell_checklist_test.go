@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/repository"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestChecklistTracker(t *testing.T) (*SellChecklistTracker, *repository.MemorySellChecklistRepository, *FakeMessenger) {
+	checklists := repository.NewMemorySellChecklistRepository()
+	messenger := NewFakeMessenger()
+	cfg := &config.Config{MediaDir: t.TempDir(), SellChecklistReminderInterval: time.Hour, SellChecklistMaxReminders: 2}
+	return NewSellChecklistTracker(checklists, messenger, testTemplates, fakeDownloader{}, testGroupRouter, cfg), checklists, messenger
+}
+
+func captionedPhoto(id, caption string) *InboundMessage {
+	msg := intakePhoto(id)
+	msg.Text = caption
+	msg.Event.Message.ImageMessage.Caption = proto.String(caption)
+	return msg
+}
+
+// applyUpdates applies the queued seller messages like Run does
+func applyUpdates(ctx context.Context, tracker *SellChecklistTracker) {
+	for {
+		select {
+		case update := <-tracker.updates:
+			tracker.applyUpdate(ctx, update)
+		default:
+			return
+		}
+	}
+}
+
+func TestSellChecklistTrackerCompletesChecklist(t *testing.T) {
+	ctx := context.Background()
+	tracker, checklists, messenger := newTestChecklistTracker(t)
+
+	if err := tracker.Start(ctx, models.SellRequest{Id: 7}, models.User{ID: "u1", Phone: "+91 90000 00001"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if handled, _ := tracker.handle(ctx, intakeText("T1", "hello")); handled {
+		t.Error("messages without checklist items must be left for other handlers")
+	}
+
+	steps := []*InboundMessage{
+		intakeText("T2", "It is a 30x40 NA plot, east facing"),
+		captionedPhoto("P1", "front view"),
+		captionedPhoto("P2", ""),
+		captionedPhoto("P3", "utaara copy"),
+		{
+			ID: "L1", SenderPhone: "919000000001", ChatType: models.ChatDirect, ContentType: models.ContentLocation, Text: "15.360000,75.120000",
+			Event: &events.Message{Message: &waE2E.Message{LocationMessage: &waE2E.LocationMessage{}}},
+		},
+	}
+	for _, msg := range steps {
+		handled, err := tracker.handle(ctx, msg)
+		if err != nil || !handled {
+			t.Fatalf("message %s: handled=%v err=%v", msg.ID, handled, err)
+		}
+		applyUpdates(ctx, tracker)
+	}
+
+	checklist, _ := checklists.Get(1)
+	if checklist.CompletedAt == nil {
+		t.Fatalf("expected checklist to be complete, missing %v", checklist.Missing())
+	}
+	if *checklist.Size != "30x40" || *checklist.PropertyType != "NA" || *checklist.Facing != "East" || len(checklist.Images) != 2 || checklist.UtaaraCopy == nil {
+		t.Errorf("unexpected checklist: %+v", checklist)
+	}
+
+	direct := messenger.DirectMessages()
+	if !strings.Contains(direct[0].Text, "Google map location") || !strings.Contains(direct[len(direct)-1].Text, "Thank you") {
+		t.Errorf("unexpected replies: %+v", direct)
+	}
+	groups := messenger.GroupMessages()
+	if len(groups) != 1 || !strings.Contains(groups[0].Text, "Sell Request #7 Checklist Complete") {
+		t.Errorf("unexpected group messages: %+v", groups)
+	}
+}
+
+func TestSellChecklistTrackerQueuesMessages(t *testing.T) {
+	ctx := context.Background()
+	tracker, checklists, messenger := newTestChecklistTracker(t)
+	tracker.Start(ctx, models.SellRequest{Id: 7}, models.User{ID: "u1", Phone: "919000000001"})
+
+	// The event handler only queues the messages, they are applied in Run in order
+	for _, msg := range []*InboundMessage{captionedPhoto("P1", "front view"), intakeText("T1", "30x40 plot")} {
+		if handled, err := tracker.handle(ctx, msg); err != nil || !handled {
+			t.Fatalf("message %s: handled=%v err=%v", msg.ID, handled, err)
+		}
+	}
+	if checklist, _ := checklists.Get(1); len(checklist.Images) != 0 || checklist.Size != nil {
+		t.Fatalf("messages must not be applied by the event handler, got %+v", checklist)
+	}
+
+	applyUpdates(ctx, tracker)
+
+	checklist, _ := checklists.Get(1)
+	if len(checklist.Images) != 1 || checklist.Size == nil || *checklist.Size != "30x40" {
+		t.Errorf("unexpected checklist after applying messages: %+v", checklist)
+	}
+	if direct := messenger.DirectMessages(); len(direct) != 2 {
+		t.Errorf("expected a progress reply per message, got %+v", direct)
+	}
+}
+
+func TestSellChecklistTrackerSendsReminders(t *testing.T) {
+	ctx := context.Background()
+	tracker, checklists, messenger := newTestChecklistTracker(t)
+	tracker.Start(ctx, models.SellRequest{Id: 7}, models.User{ID: "u1", Phone: "919000000001"})
+
+	tracker.sendReminders(ctx)
+	if len(messenger.Messages()) != 0 {
+		t.Fatal("recently updated checklists must not be reminded")
+	}
+
+	for i := 0; i < 3; i++ {
+		checklists.Touch(1, time.Now().Add(-2*time.Hour))
+		tracker.sendReminders(ctx)
+	}
+
+	direct := messenger.DirectMessages()
+	if len(direct) != 2 || !strings.Contains(direct[0].Text, "Utaara copy") {
+		t.Errorf("expected 2 reminders listing missing items, got %+v", direct)
+	}
+}
+
+func TestSellChecklistTrackerRemindsInUserLocale(t *testing.T) {
+	ctx := context.Background()
+	tracker, checklists, messenger := newTestChecklistTracker(t)
+	kannada := "kn"
+	tracker.Start(ctx, models.SellRequest{Id: 7}, models.User{ID: "u1", Phone: "919000000001", PrefLang: &kannada})
+
+	checklists.Touch(1, time.Now().Add(-2*time.Hour))
+	tracker.sendReminders(ctx)
+
+	direct := messenger.DirectMessages()
+	if len(direct) != 1 || !strings.Contains(direct[0].Text, "ಉತಾರ ಪ್ರತಿ") || strings.Contains(direct[0].Text, "Utaara copy") {
+		t.Errorf("expected a Kannada reminder, got %+v", direct)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]string{
+		"Plot is 1200 sqft":     "1200 sqft",
+		"around 2.5 acres":      "2.5 acres",
+		"10 guntas near bypass": "10 guntas",
+		"site 30 x 40":          "30 x 40",
+	}
+	for text, want := range tests {
+		if got, ok := ParseSize(text); !ok || got != want {
+			t.Errorf("ParseSize(%q) = %q, %v, want %q", text, got, ok, want)
+		}
+	}
+	if _, ok := ParseSize("call me at 9000000001"); ok {
+		t.Error("phone numbers must not be read as a size")
+	}
+}
+
+func TestParsePropertyType(t *testing.T) {
+	tests := map[string]string{
+		"NA plot":                    "NA",
+		"non-agricultural land":      "NA",
+		"agricultural land":          "Agricultural",
+		"gunta site":                 "Gunta",
+		"commercial plot on MG road": "Commercial",
+	}
+	for text, want := range tests {
+		if got, ok := ParsePropertyType(text); !ok || got != want {
+			t.Errorf("ParsePropertyType(%q) = %q, %v, want %q", text, got, ok, want)
+		}
+	}
+	if _, ok := ParsePropertyType("national highway"); ok {
+		t.Error("words starting with na must not match")
+	}
+}
+
+func TestParseFacing(t *testing.T) {
+	tests := map[string]string{
+		"east facing":            "East",
+		"North-East facing":      "North-East",
+		"facing: south west":     "South-West",
+		"it is northwest facing": "North-West",
+	}
+	for text, want := range tests {
+		if got, ok := ParseFacing(text); !ok || got != want {
+			t.Errorf("ParseFacing(%q) = %q, %v, want %q", text, got, ok, want)
+		}
+	}
+	if _, ok := ParseFacing("the plot is near east gate"); ok {
+		t.Error("directions without facing must not match")
+	}
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// maxConnectionTransitions is how many state changes are kept for operators
+const maxConnectionTransitions = 20
+
+// SessionClient is the part of the WhatsApp client the supervisor drives
+type SessionClient interface {
+	Connect(ctx context.Context) error
+	Disconnect()
+	IsPaired() bool
+	ClearDevice(ctx context.Context) error
+	AddEventHandler(handler func(evt interface{}))
+}
+
+// ConnectionStatusProvider exposes the state of the WhatsApp session
+type ConnectionStatusProvider interface {
+	Status() models.ConnectionStatus
+}
+
+// ConnectionSupervisor keeps the WhatsApp session alive. It connects on start,
+// reconnects with exponential backoff and jitter whenever the session drops, and
+// after a logout clears the device and waits in the pairing state until it is
+// linked again. Every state change is recorded for operators.
+type ConnectionSupervisor struct {
+	client SessionClient
+	config *config.Config
+	wake   chan struct{}
+	// jitter returns a random fraction in [0, 1), replaced in tests
+	jitter func() float64
+
+	mu     sync.Mutex
+	status models.ConnectionStatus
+	// loggedIn is set once the current attempt reaches a logged in session, at connectedAt
+	loggedIn    bool
+	connectedAt time.Time
+}
+
+var _ ConnectionStatusProvider = (*ConnectionSupervisor)(nil)
+
+// NewConnectionSupervisor creates a supervisor for the WhatsApp session and takes
+// over reconnecting from whatsmeow
+func NewConnectionSupervisor(whatsapp *WhatsAppService, cfg *config.Config) *ConnectionSupervisor {
+	whatsapp.disableAutoReconnect()
+	return newConnectionSupervisor(whatsapp, cfg)
+}
+
+func newConnectionSupervisor(client SessionClient, cfg *config.Config) *ConnectionSupervisor {
+	supervisor := &ConnectionSupervisor{
+		client: client,
+		config: cfg,
+		wake:   make(chan struct{}, 1),
+		jitter: rand.Float64,
+		status: models.ConnectionStatus{State: models.ConnectionDisconnected, Since: time.Now()},
+	}
+	client.AddEventHandler(supervisor.handleEvent)
+	return supervisor
+}
+
+// Status returns a snapshot of the session state
+func (s *ConnectionSupervisor) Status() models.ConnectionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Transitions = append([]models.ConnectionTransition(nil), s.status.Transitions...)
+	return status
+}
+
+// Run keeps the session connected until the context is cancelled
+func (s *ConnectionSupervisor) Run(ctx context.Context) {
+	defer s.client.Disconnect()
+
+	for ctx.Err() == nil {
+		s.attempting()
+		if err := s.client.Connect(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// Start the next attempt from a clean socket, e.g. after a pairing timeout
+			s.client.Disconnect()
+			if s.client.IsPaired() {
+				s.retry(ctx, err)
+			} else {
+				// The next attempt waits for an operator to request pairing again
+				s.pairingFailed(err)
+			}
+			continue
+		}
+
+		// Wait until the session drops or is logged out
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		}
+
+		// A session that dropped before logging in, or soon after, counts as a failed
+		// attempt so a flapping connection keeps backing off
+		uptime, established := s.uptime()
+		switch {
+		case !established:
+			s.retry(ctx, errors.New("connection closed before logging in"))
+		case uptime < s.config.WhatsAppStableConnection && s.client.IsPaired():
+			s.retry(ctx, fmt.Errorf("connection dropped %s after logging in", uptime.Round(time.Second)))
+		default:
+			s.resetBackoff()
+		}
+	}
+}
+
+// handleEvent follows the session state reported by whatsmeow
+func (s *ConnectionSupervisor) handleEvent(evt interface{}) {
+	switch v := evt.(type) {
+	case *events.Connected:
+		s.connected()
+	case *events.Disconnected:
+		s.transition(models.ConnectionReconnecting, "connection lost")
+		s.notify()
+	case *events.LoggedOut:
+		s.loggedOut(v.Reason.String())
+	case *events.StreamReplaced:
+		// Another instance took over the session, fighting it would log both out
+		log.Printf("WhatsApp Alert: Session replaced by another client, not reconnecting")
+		s.transition(models.ConnectionDisconnected, "session replaced by another client")
+	case *events.TemporaryBan:
+		log.Printf("WhatsApp Alert: Temporarily banned (%s)", v.String())
+		s.transition(models.ConnectionDisconnected, fmt.Sprintf("temporarily banned: %s", v.String()))
+		time.AfterFunc(v.Expire, s.notify)
+	case *events.ClientOutdated:
+		log.Printf("WhatsApp Alert: Client version is outdated, update whatsmeow to reconnect")
+		s.transition(models.ConnectionDisconnected, "client outdated")
+	case *events.ConnectFailure:
+		// Logouts and bans have their own events
+		if v.Reason.IsLoggedOut() || v.Reason == events.ConnectFailureTempBanned || v.Reason == events.ConnectFailureClientOutdated {
+			return
+		}
+		s.transition(models.ConnectionReconnecting, fmt.Sprintf("connect failure: %s", v.Reason.String()))
+		s.notify()
+	}
+}
+
+// loggedOut clears the device and waits for it to be paired again
+func (s *ConnectionSupervisor) loggedOut(reason string) {
+	log.Printf("🚨 WhatsApp Alert: Session logged out (%s). The device must be paired again, no messages go out until then.", reason)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.client.ClearDevice(ctx); err != nil {
+		log.Printf("WhatsApp Error: %v", err)
+	}
+
+	s.transition(models.ConnectionPairing, "logged out: "+reason)
+	s.notify()
+}
+
+// attempting marks the start of a connection attempt
+func (s *ConnectionSupervisor) attempting() {
+	s.mu.Lock()
+	s.loggedIn = false
+	state := s.status.State
+	s.mu.Unlock()
+
+	switch {
+	case !s.client.IsPaired():
+		s.transition(models.ConnectionPairing, "device is not paired")
+	case state != models.ConnectionReconnecting:
+		s.transition(models.ConnectionConnecting, "")
+	}
+}
+
+// uptime reports whether the session logged in since the last attempt started and
+// for how long it has been up
+func (s *ConnectionSupervisor) uptime() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loggedIn {
+		return 0, false
+	}
+	return time.Since(s.connectedAt), true
+}
+
+// connected records a successful login. The backoff is kept until the session has
+// proven stable, see resetBackoff.
+func (s *ConnectionSupervisor) connected() {
+	s.mu.Lock()
+	s.loggedIn = true
+	s.connectedAt = time.Now()
+	s.status.LastError = ""
+	s.status.NextAttemptAt = nil
+	s.mu.Unlock()
+	s.transition(models.ConnectionConnected, "")
+}
+
+// resetBackoff forgets the failed attempts once a session stayed up long enough
+func (s *ConnectionSupervisor) resetBackoff() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Attempts = 0
+}
+
+// retry records a failed attempt and waits out the backoff
+func (s *ConnectionSupervisor) retry(ctx context.Context, err error) {
+	delay := s.failed(err)
+	select {
+	case <-ctx.Done():
+	case <-time.After(delay):
+	}
+}
+
+// pairingFailed records a failed pairing
+func (s *ConnectionSupervisor) pairingFailed(err error) {
+	s.mu.Lock()
+	s.status.LastError = err.Error()
+	s.mu.Unlock()
+	log.Printf("WhatsApp Error: Pairing failed, request a new one to try again: %v", err)
+}
+
+// failed records a failed attempt and returns the delay before the next one
+func (s *ConnectionSupervisor) failed(err error) time.Duration {
+	s.mu.Lock()
+	s.status.Attempts++
+	attempts := s.status.Attempts
+	delay := s.backoff(attempts)
+	next := time.Now().Add(delay)
+	s.status.LastError = err.Error()
+	s.status.NextAttemptAt = &next
+	state := models.ConnectionReconnecting
+	if s.status.State == models.ConnectionPairing {
+		state = models.ConnectionPairing
+	}
+	s.mu.Unlock()
+
+	log.Printf("WhatsApp Error: Connection attempt %d failed, retrying in %s: %v", attempts, delay.Round(time.Millisecond), err)
+	s.transition(state, err.Error())
+	return delay
+}
+
+// backoff doubles the base delay per failed attempt up to the maximum and keeps
+// a random half of it, so restarted instances don't reconnect in lockstep
+func (s *ConnectionSupervisor) backoff(attempts int) time.Duration {
+	delay := s.config.WhatsAppReconnectBaseDelay
+	for i := 1; i < attempts && delay < s.config.WhatsAppReconnectMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.WhatsAppReconnectMaxDelay {
+		delay = s.config.WhatsAppReconnectMaxDelay
+	}
+	return delay/2 + time.Duration(s.jitter()*float64(delay/2))
+}
+
+// transition moves to a new state and records the change
+func (s *ConnectionSupervisor) transition(state models.ConnectionState, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := s.status.State
+	if from == state {
+		return
+	}
+
+	now := time.Now()
+	s.status.State = state
+	s.status.Since = now
+	s.status.Transitions = append(s.status.Transitions, models.ConnectionTransition{From: from, To: state, Reason: reason, At: now})
+	if len(s.status.Transitions) > maxConnectionTransitions {
+		s.status.Transitions = s.status.Transitions[len(s.status.Transitions)-maxConnectionTransitions:]
+	}
+	log.Printf("WhatsApp: Connection %s → %s", from, state)
+}
+
+// notify wakes the supervisor loop without blocking if a wake-up is already pending
+func (s *ConnectionSupervisor) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
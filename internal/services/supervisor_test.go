@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// fakeSession fails the first connection attempts and logs in on the next one
+type fakeSession struct {
+	mu       sync.Mutex
+	failures int
+	paired   bool
+	connects int
+	cleared  bool
+	handler  func(evt interface{})
+}
+
+func (f *fakeSession) Connect(ctx context.Context) error {
+	f.mu.Lock()
+	f.connects++
+	if f.failures > 0 {
+		f.failures--
+		f.mu.Unlock()
+		return errors.New("dial failed")
+	}
+	f.paired = true
+	f.mu.Unlock()
+	go f.handler(&events.Connected{})
+	return nil
+}
+
+func (f *fakeSession) Disconnect() {}
+
+func (f *fakeSession) IsPaired() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.paired
+}
+
+func (f *fakeSession) ClearDevice(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paired = false
+	f.cleared = true
+	return nil
+}
+
+func (f *fakeSession) AddEventHandler(handler func(evt interface{})) {
+	f.handler = handler
+}
+
+func supervisorConfig() *config.Config {
+	return &config.Config{
+		WhatsAppReconnectBaseDelay: time.Millisecond,
+		WhatsAppReconnectMaxDelay:  4 * time.Millisecond,
+	}
+}
+
+func waitForState(t *testing.T, supervisor *ConnectionSupervisor, state models.ConnectionState) models.ConnectionStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := supervisor.Status(); status.State == state {
+			return status
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("state = %s, want %s", supervisor.Status().State, state)
+	return models.ConnectionStatus{}
+}
+
+func TestConnectionSupervisorBackoff(t *testing.T) {
+	cfg := &config.Config{WhatsAppReconnectBaseDelay: 2 * time.Second, WhatsAppReconnectMaxDelay: 30 * time.Second}
+	supervisor := newConnectionSupervisor(&fakeSession{}, cfg)
+
+	tests := []struct {
+		attempts int
+		jitter   float64
+		want     time.Duration
+	}{
+		{1, 0, time.Second},
+		{1, 0.999999, 2 * time.Second},
+		{2, 0, 2 * time.Second},
+		{3, 1, 8 * time.Second},
+		{5, 1, 30 * time.Second},
+		{50, 0, 15 * time.Second},
+	}
+	for _, tt := range tests {
+		jitter := tt.jitter
+		supervisor.jitter = func() float64 { return jitter }
+		got := supervisor.backoff(tt.attempts).Round(time.Second)
+		if got != tt.want {
+			t.Errorf("backoff(%d) with jitter %v = %s, want %s", tt.attempts, tt.jitter, got, tt.want)
+		}
+	}
+}
+
+func TestConnectionSupervisorRetriesUntilConnected(t *testing.T) {
+	session := &fakeSession{failures: 2, paired: true}
+	supervisor := newConnectionSupervisor(session, supervisorConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go supervisor.Run(ctx)
+
+	status := waitForState(t, supervisor, models.ConnectionConnected)
+	if status.LastError != "" || status.NextAttemptAt != nil {
+		t.Errorf("status after connecting = %+v, want the error cleared", status)
+	}
+	// The failures are only forgotten once the session has proven stable
+	if status.Attempts != 2 {
+		t.Errorf("attempts after connecting = %d, want 2", status.Attempts)
+	}
+	session.mu.Lock()
+	connects := session.connects
+	session.mu.Unlock()
+	if connects != 3 {
+		t.Errorf("connects = %d, want 3", connects)
+	}
+
+	var states []models.ConnectionState
+	for _, transition := range status.Transitions {
+		states = append(states, transition.To)
+	}
+	want := []models.ConnectionState{models.ConnectionConnecting, models.ConnectionReconnecting, models.ConnectionConnected}
+	if len(states) != len(want) {
+		t.Fatalf("transitions = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", states, want)
+		}
+	}
+}
+
+func TestConnectionSupervisorReconnectsAfterDrop(t *testing.T) {
+	session := &fakeSession{paired: true}
+	supervisor := newConnectionSupervisor(session, supervisorConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go supervisor.Run(ctx)
+	waitForState(t, supervisor, models.ConnectionConnected)
+
+	session.handler(&events.Disconnected{})
+	waitForState(t, supervisor, models.ConnectionConnected)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.connects != 2 {
+		t.Errorf("connects = %d, want 2", session.connects)
+	}
+}
+
+func TestConnectionSupervisorBacksOffWhenFlapping(t *testing.T) {
+	session := &fakeSession{paired: true}
+	cfg := supervisorConfig()
+	cfg.WhatsAppStableConnection = time.Hour
+	supervisor := newConnectionSupervisor(session, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go supervisor.Run(ctx)
+	waitForState(t, supervisor, models.ConnectionConnected)
+
+	// Every drop right after logging in counts as a failed attempt
+	for want := 1; want <= 2; want++ {
+		session.handler(&events.Disconnected{})
+		status := waitForState(t, supervisor, models.ConnectionConnected)
+		if status.Attempts != want {
+			t.Fatalf("attempts after drop %d = %d, want %d", want, status.Attempts, want)
+		}
+	}
+
+	// A drop after the session stayed up long enough starts over
+	supervisor.mu.Lock()
+	supervisor.connectedAt = time.Now().Add(-2 * time.Hour)
+	supervisor.mu.Unlock()
+	session.handler(&events.Disconnected{})
+	if status := waitForState(t, supervisor, models.ConnectionConnected); status.Attempts != 0 {
+		t.Errorf("attempts after a stable session = %d, want 0", status.Attempts)
+	}
+}
+
+func TestConnectionSupervisorLoggedOut(t *testing.T) {
+	session := &fakeSession{paired: true}
+	supervisor := newConnectionSupervisor(session, supervisorConfig())
+	supervisor.handleEvent(&events.Connected{})
+
+	session.handler(&events.LoggedOut{Reason: events.ConnectFailureLoggedOut})
+
+	status := supervisor.Status()
+	if status.State != models.ConnectionPairing {
+		t.Errorf("state = %s, want %s", status.State, models.ConnectionPairing)
+	}
+	if !session.cleared || session.IsPaired() {
+		t.Error("device was not cleared after logout")
+	}
+	select {
+	case <-supervisor.wake:
+	default:
+		t.Error("supervisor loop was not woken to start pairing")
+	}
+}
+
+func TestConnectionSupervisorStreamReplaced(t *testing.T) {
+	session := &fakeSession{paired: true}
+	supervisor := newConnectionSupervisor(session, supervisorConfig())
+	supervisor.handleEvent(&events.Connected{})
+
+	session.handler(&events.StreamReplaced{})
+
+	if state := supervisor.Status().State; state != models.ConnectionDisconnected {
+		t.Errorf("state = %s, want %s", state, models.ConnectionDisconnected)
+	}
+	select {
+	case <-supervisor.wake:
+		t.Error("supervisor reconnects a session taken over by another client")
+	default:
+	}
+}
@@ -2,17 +2,20 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
+	"google.golang.org/protobuf/proto"
 
 	// Import PostgreSQL driver for database/sql
 	_ "github.com/lib/pq"
@@ -23,6 +26,7 @@ type WhatsAppService struct {
 	container *sqlstore.Container
 	logger    waLog.Logger
 	config    *config.Config
+	pairing   *pairing
 }
 
 // NewWhatsAppService creates a new WhatsApp service
@@ -52,6 +56,7 @@ func NewWhatsAppService(ctx context.Context, cfg *config.Config) (*WhatsAppServi
 		logger:    logger,
 		config:    cfg,
 	}
+	service.pairing = newPairing(cfg, service.IsPaired)
 
 	// Add event handlers
 	client.AddEventHandler(service.eventHandler)
@@ -59,208 +64,389 @@ func NewWhatsAppService(ctx context.Context, cfg *config.Config) (*WhatsAppServi
 	return service, nil
 }
 
-// Connect connects to WhatsApp using configured pairing method
+// Connect connects to WhatsApp. An unpaired device waits for a pairing request,
+// either from the configured pairing mode on the first start or from the admin API.
 func (w *WhatsAppService) Connect(ctx context.Context) error {
-	// Check if already logged in
-	if w.client.Store.ID == nil {
-		// Not logged in, need to pair device
-		log.Printf("WhatsApp: Device not paired. Using %s pairing mode.", w.config.WhatsAppPairingMode)
-
-		// Connect to WhatsApp first
-		err := w.client.Connect()
-		if err != nil {
-			return fmt.Errorf("failed to connect: %v", err)
-		}
-
-		// Choose pairing method based on configuration
-		switch w.config.WhatsAppPairingMode {
-		case "phone":
-			return w.pairWithPhoneNumber(ctx)
-		case "qr":
-			return w.pairWithQR(ctx)
-		default:
-			log.Printf("Unknown pairing mode '%s', defaulting to phone pairing", w.config.WhatsAppPairingMode)
-			return w.pairWithPhoneNumber(ctx)
-		}
-	} else {
-		// Already logged in, just connect
+	if w.client.Store.ID != nil {
 		log.Println("WhatsApp: Device already paired, connecting...")
 		err := w.client.Connect()
 		if err != nil {
 			return fmt.Errorf("failed to connect: %v", err)
 		}
+		log.Println("WhatsApp: Connected successfully")
+		return nil
 	}
 
-	log.Println("WhatsApp: Connected successfully")
+	log.Println("WhatsApp: Device not paired, waiting for a pairing request (POST /admin/pairing)")
+	request, err := w.pairing.await(ctx)
+	if err != nil {
+		return err
+	}
+	if err := w.pair(ctx, request); err != nil {
+		w.pairing.fail(err)
+		return err
+	}
 	return nil
 }
 
-// pairWithPhoneNumber pairs the device using phone number and pairing code
-func (w *WhatsAppService) pairWithPhoneNumber(ctx context.Context) error {
-	// Use configured phone number or fallback
-	phoneNumber := w.config.WhatsAppPhoneNumber
-	if phoneNumber == "" {
-		phoneNumber = "919035577330" // Fallback to hardcoded number
-		log.Printf("No WHATSAPP_PHONE_NUMBER configured, using fallback: %s", phoneNumber)
+// pair links the device with a QR code or a phone pairing code
+func (w *WhatsAppService) pair(ctx context.Context, request models.PairingRequest) error {
+	// The QR channel has to be opened before connecting. Phone pairing uses it too,
+	// the code can only be requested once the first QR code arrives.
+	qrChan, err := w.client.GetQRChannel(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get QR channel: %v", err)
+	}
+	if err := w.client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect: %v", err)
 	}
 
-	log.Printf("Requesting pairing code for phone number: %s", phoneNumber)
+	codeRequested := false
+	for evt := range qrChan {
+		switch evt.Event {
+		case whatsmeow.QRChannelEventCode:
+			if request.Method == models.PairingQR {
+				log.Println("📱 New QR code, scan it from GET /admin/pairing/qr.png")
+				w.pairing.showQR(evt.Code, evt.Timeout)
+				continue
+			}
+			if codeRequested {
+				continue
+			}
+			codeRequested = true
 
-	// Request pairing code
-	code, err := w.client.PairPhone(ctx, phoneNumber, true, whatsmeow.PairClientChrome, "Chrome (Linux)")
-	if err != nil {
-		return fmt.Errorf("failed to request pairing code: %v", err)
+			log.Printf("Requesting pairing code for phone number: %s", request.Phone)
+			code, err := w.client.PairPhone(ctx, request.Phone, true, whatsmeow.PairClientChrome, "Chrome (Linux)")
+			if err != nil {
+				return fmt.Errorf("failed to request pairing code: %v", err)
+			}
+			log.Println("✅ Pairing code ready, enter it in WhatsApp → Settings → Linked Devices → Link with phone number (GET /admin/pairing)")
+			w.pairing.showCode(code)
+		case whatsmeow.QRChannelSuccess.Event:
+			log.Println("🎉 Successfully paired!")
+			w.pairing.succeed()
+			return nil
+		case whatsmeow.QRChannelEventError:
+			return fmt.Errorf("pairing failed: %v", evt.Error)
+		default:
+			return fmt.Errorf("pairing ended without success (%s)", evt.Event)
+		}
 	}
 
-	log.Printf("✅ Pairing code: %s", code)
-	log.Println("📱 Enter this code in your WhatsApp mobile app:")
-	log.Println("   WhatsApp → Settings → Linked Devices → Link a Device → Link with Phone Number")
-	log.Println("   Then enter the code above")
+	return fmt.Errorf("pairing ended without success")
+}
+
+var _ PairingController = (*WhatsAppService)(nil)
 
-	// Wait for pairing to complete
-	log.Println("⏳ Waiting for pairing to complete...")
+// StartPairing queues a request to link the device
+func (w *WhatsAppService) StartPairing(request models.PairingRequest) (models.PairingStatus, error) {
+	return w.pairing.start(request)
+}
 
-	// Wait for the client to be logged in
-	for i := 0; i < 60; i++ { // Wait up to 60 seconds
-		if w.client.Store.ID != nil {
-			log.Println("🎉 Successfully paired with phone number!")
-			return nil
+// PairingStatus returns the progress of the last pairing
+func (w *WhatsAppService) PairingStatus() models.PairingStatus {
+	return w.pairing.current()
+}
+
+// SubscribePairing streams pairing status changes until cancel is called
+func (w *WhatsAppService) SubscribePairing() (<-chan models.PairingStatus, func()) {
+	return w.pairing.subscribe()
+}
+
+// ErrNotConnected is returned when a send is attempted while the client is offline
+var ErrNotConnected = errors.New("WhatsApp client is not connected")
+
+// ErrInvalidRecipient is returned when a recipient can't be turned into a JID.
+// Retrying such a message will never succeed.
+var ErrInvalidRecipient = errors.New("invalid recipient")
+
+// ErrMediaUnavailable is returned when the file of a media message can't be read or fetched.
+// Retrying such a message will never succeed either.
+var ErrMediaUnavailable = errors.New("media file unavailable")
+
+// connectionGrace is how long a send waits for a dropped session to come back
+// before giving up with ErrNotConnected
+const connectionGrace = 5 * time.Second
+
+// IsConnected reports whether the WhatsApp client currently has a live connection
+func (w *WhatsAppService) IsConnected() bool {
+	return w.client.IsConnected()
+}
+
+// IsPaired reports whether a device is linked to a WhatsApp account
+func (w *WhatsAppService) IsPaired() bool {
+	return w.client.Store.ID != nil
+}
+
+// ClearDevice forgets the linked device so the next Connect starts pairing again.
+// whatsmeow already does this on most logouts, so an unpaired store is left alone.
+func (w *WhatsAppService) ClearDevice(ctx context.Context) error {
+	if w.client.Store.ID != nil {
+		if err := w.client.Store.Delete(ctx); err != nil {
+			return fmt.Errorf("failed to clear device: %v", err)
 		}
-		time.Sleep(1 * time.Second)
 	}
-
-	return fmt.Errorf("pairing timeout - please try again")
+	w.pairing.reset()
+	return nil
 }
 
-// pairWithQR pairs the device using QR code method
-func (w *WhatsAppService) pairWithQR(ctx context.Context) error {
-	log.Println("WhatsApp: Generating QR code for pairing...")
+// disableAutoReconnect leaves reconnecting to the ConnectionSupervisor
+func (w *WhatsAppService) disableAutoReconnect() {
+	w.client.EnableAutoReconnect = false
+}
 
-	// Generate QR code for pairing
-	qrChan, err := w.client.GetQRChannel(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get QR channel: %v", err)
+// awaitConnection waits up to timeout for the client to be connected
+func (w *WhatsAppService) awaitConnection(ctx context.Context, timeout time.Duration) bool {
+	if w.client.IsConnected() {
+		return true
 	}
 
-	// Wait for QR code and print it
-	for evt := range qrChan {
-		if evt.Event == "code" {
-			fmt.Println("📱 QR Code:")
-			fmt.Println(evt.Code)
-			fmt.Println("Scan this QR code with your WhatsApp mobile app")
-		} else {
-			fmt.Printf("QR Event: %s\n", evt.Event)
-			if evt.Event == "success" {
-				fmt.Println("🎉 Successfully paired!")
-				break
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline.C:
+			return false
+		case <-ticker.C:
+			if w.client.IsConnected() {
+				return true
 			}
 		}
 	}
-
-	return nil
 }
 
-// SendMessage sends a WhatsApp message to the specified phone number
-func (w *WhatsAppService) SendMessage(ctx context.Context, phoneNumber, message string) error {
-	log.Printf("WhatsApp Debug: SendMessage called with phoneNumber: %s", phoneNumber)
+// AddEventHandler registers an additional handler for raw whatsmeow events
+func (w *WhatsAppService) AddEventHandler(handler func(evt interface{})) {
+	w.client.AddEventHandler(handler)
+}
 
-	// Ensure client is connected
+// LookupRegistration asks WhatsApp whether a phone number (international digits) has an account
+func (w *WhatsAppService) LookupRegistration(ctx context.Context, phone string) (models.WhatsAppRegistration, error) {
+	// Don't hold up webhooks while offline, the outbox checks again before delivery
 	if !w.client.IsConnected() {
-		log.Printf("WhatsApp Error: Client is not connected")
-		return fmt.Errorf("WhatsApp client is not connected")
+		return models.WhatsAppRegistration{}, ErrNotConnected
 	}
 
-	log.Printf("WhatsApp Debug: Client is connected, proceeding with message send")
+	results, err := w.client.IsOnWhatsApp([]string{"+" + phone})
+	if err != nil {
+		return models.WhatsAppRegistration{}, fmt.Errorf("failed to check registration: %v", err)
+	}
 
-	// Parse phone number to JID
-	// Phone number should be in format: country_code + number (e.g., "919999999999")
-	jid, err := types.ParseJID(phoneNumber + "@s.whatsapp.net")
+	registration := models.WhatsAppRegistration{Phone: phone}
+	for _, result := range results {
+		if result.IsIn {
+			jid := result.JID.String()
+			registration.Registered = true
+			registration.JID = &jid
+		}
+	}
+	return registration, nil
+}
+
+// Download fetches and decrypts the media attached to a received message
+func (w *WhatsAppService) Download(ctx context.Context, msg whatsmeow.DownloadableMessage) ([]byte, error) {
+	data, err := w.client.Download(ctx, msg)
 	if err != nil {
-		log.Printf("WhatsApp Error: Invalid phone number format: %v", err)
-		return fmt.Errorf("invalid phone number format: %v", err)
+		return nil, fmt.Errorf("failed to download media: %v", err)
 	}
+	return data, nil
+}
 
-	log.Printf("WhatsApp Debug: JID parsed successfully: %s", jid)
+// Deliver sends an outbox message over the live WhatsApp connection and returns the WhatsApp message ID
+func (w *WhatsAppService) Deliver(ctx context.Context, outboxMsg models.OutboxMessage) (types.MessageID, error) {
+	log.Printf("WhatsApp Debug: Delivering outbox message %d to %s %s", outboxMsg.ID, outboxMsg.RecipientType, outboxMsg.Recipient)
 
-	// Create message
-	msg := &waE2E.Message{
-		Conversation: &message,
+	// Ensure client is connected, a reconnect in progress gets a moment to finish
+	if !w.awaitConnection(ctx, connectionGrace) {
+		return "", ErrNotConnected
+	}
+
+	jid, err := w.recipientJID(outboxMsg.RecipientType, outboxMsg.Recipient)
+	if err != nil {
+		return "", err
 	}
 
-	log.Printf("WhatsApp Debug: Message created, sending to %s", jid)
+	// Create message
+	msg, err := w.buildMessage(ctx, outboxMsg.Payload)
+	if err != nil {
+		return "", err
+	}
 
 	// Send message
 	response, err := w.client.SendMessage(ctx, jid, msg)
 	if err != nil {
-		log.Printf("WhatsApp Error: Failed to send message: %v", err)
-		return fmt.Errorf("failed to send message: %v", err)
+		return "", fmt.Errorf("failed to send message: %v", err)
 	}
 
 	log.Printf("WhatsApp: Message sent successfully. ID: %s, Timestamp: %s",
 		response.ID, response.Timestamp)
 
-	return nil
+	return response.ID, nil
 }
 
-// SendGroupMessage sends a WhatsApp message to a group
-func (w *WhatsAppService) SendGroupMessage(ctx context.Context, groupJID, message string) error {
-	log.Printf("WhatsApp Debug: SendGroupMessage called with groupJID: %s", groupJID)
-
-	// Ensure client is connected
-	if !w.client.IsConnected() {
-		log.Printf("WhatsApp Error: Client is not connected")
-		return fmt.Errorf("WhatsApp client is not connected")
+// buildMessage turns an outbox payload into a WhatsApp message, uploading its media first
+func (w *WhatsAppService) buildMessage(ctx context.Context, payload models.OutboundMessage) (*waE2E.Message, error) {
+	if location := payload.Location; location != nil {
+		msg := &waE2E.LocationMessage{
+			DegreesLatitude:  proto.Float64(location.Latitude),
+			DegreesLongitude: proto.Float64(location.Longitude),
+		}
+		if location.Name != "" {
+			msg.Name = proto.String(location.Name)
+		}
+		if location.Address != "" {
+			msg.Address = proto.String(location.Address)
+		}
+		return &waE2E.Message{LocationMessage: msg}, nil
 	}
 
-	log.Printf("WhatsApp Debug: Client is connected, proceeding with group message send")
-
-	// Parse group JID
-	// Group JID should be in format: groupId@g.us
-	jid, err := types.ParseJID(groupJID)
-	if err != nil {
-		log.Printf("WhatsApp Error: Invalid group JID format: %v", err)
-		return fmt.Errorf("invalid group JID format: %v", err)
+	if payload.Preview != nil {
+		return w.buildLinkPreview(ctx, payload.Text, *payload.Preview), nil
 	}
 
-	log.Printf("WhatsApp Debug: Group JID parsed successfully: %s", jid)
-
-	// Create message
-	msg := &waE2E.Message{
-		Conversation: &message,
+	if payload.Media == nil {
+		text := payload.Text
+		return &waE2E.Message{Conversation: &text}, nil
 	}
 
-	log.Printf("WhatsApp Debug: Group message created, sending to %s", jid)
+	mediaType, ok := uploadMediaTypes[payload.Media.Kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported media kind %q", ErrMediaUnavailable, payload.Media.Kind)
+	}
 
-	// Send message
-	response, err := w.client.SendMessage(ctx, jid, msg)
+	media, err := loadMedia(ctx, *payload.Media)
 	if err != nil {
-		log.Printf("WhatsApp Error: Failed to send group message: %v", err)
-		return fmt.Errorf("failed to send group message: %v", err)
+		return nil, err
 	}
 
-	log.Printf("WhatsApp: Group message sent successfully. ID: %s, Timestamp: %s",
-		response.ID, response.Timestamp)
+	uploaded, err := w.client.Upload(ctx, media.data, mediaType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %v", payload.Media.Kind, err)
+	}
 
-	return nil
+	switch payload.Media.Kind {
+	case models.MediaImage:
+		image := &waE2E.ImageMessage{
+			Caption:       proto.String(payload.Text),
+			Mimetype:      proto.String(media.mimeType),
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+		}
+		// A missing preview only makes the image load without a blur, so send it anyway
+		if thumbnail, width, height, err := imageThumbnail(media.data); err != nil {
+			log.Printf("WhatsApp Warning: No thumbnail for %s: %v", media.fileName, err)
+		} else {
+			image.JPEGThumbnail = thumbnail
+			image.Width = proto.Uint32(uint32(width))
+			image.Height = proto.Uint32(uint32(height))
+		}
+		return &waE2E.Message{ImageMessage: image}, nil
+	case models.MediaDocument:
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			Caption:       proto.String(payload.Text),
+			Title:         proto.String(media.fileName),
+			FileName:      proto.String(media.fileName),
+			Mimetype:      proto.String(media.mimeType),
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+		}}, nil
+	case models.MediaAudio:
+		// Audio messages can't carry a caption
+		return &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			Mimetype:      proto.String(media.mimeType),
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+		}}, nil
+	default:
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			Caption:       proto.String(payload.Text),
+			Mimetype:      proto.String(media.mimeType),
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+		}}, nil
+	}
 }
 
-const InternalGroupWhatsAppId = "120363420697230363@g.us"
+// buildLinkPreview turns a text with a link into an extended text message that
+// renders the link as a card. A thumbnail that can't be generated is left out
+// rather than holding back the message.
+func (w *WhatsAppService) buildLinkPreview(ctx context.Context, text string, preview models.LinkPreview) *waE2E.Message {
+	msg := &waE2E.ExtendedTextMessage{
+		Text:        proto.String(text),
+		MatchedText: proto.String(preview.URL),
+		Title:       proto.String(preview.Title),
+		PreviewType: waE2E.ExtendedTextMessage_NONE.Enum(),
+	}
+	if preview.Description != "" {
+		msg.Description = proto.String(preview.Description)
+	}
 
-// SendSellRequestToGroup sends a sell request message to a specific group
-func (w *WhatsAppService) SendSellRequestToGroup(ctx context.Context, groupJID, userName, propertyType, address, price, userPhone string) error {
-	log.Printf("WhatsApp Debug: SendSellRequestToGroup called - GroupJID: %s, User: %s", groupJID, userName)
+	if preview.Image != nil {
+		if media, err := loadMedia(ctx, *preview.Image); err != nil {
+			log.Printf("WhatsApp Warning: No link preview thumbnail for %s: %v", preview.URL, err)
+		} else if thumbnail, _, _, err := imageThumbnail(media.data); err != nil {
+			log.Printf("WhatsApp Warning: No link preview thumbnail for %s: %v", preview.URL, err)
+		} else {
+			msg.JPEGThumbnail = thumbnail
+		}
+	}
 
-	message := fmt.Sprintf(`🏠 *New Sell Request Received*
-👤 *Name:* %s
-🏘️ *Property Type:* %s  
-📍 *Address:* %s
-💰 *Price:* %s
-📞 *Phone:* %s
-`, userName, propertyType, address, price, userPhone)
+	return &waE2E.Message{ExtendedTextMessage: msg}
+}
 
-	log.Printf("WhatsApp Debug: Group message template created, length: %d characters", len(message))
+// uploadMediaTypes maps attachment kinds to the whatsmeow upload type
+var uploadMediaTypes = map[models.MediaKind]whatsmeow.MediaType{
+	models.MediaImage:    whatsmeow.MediaImage,
+	models.MediaDocument: whatsmeow.MediaDocument,
+	models.MediaAudio:    whatsmeow.MediaAudio,
+	models.MediaVideo:    whatsmeow.MediaVideo,
+}
 
-	return w.SendGroupMessage(ctx, groupJID, message)
+// recipientJID converts a stored recipient into a WhatsApp JID
+func (w *WhatsAppService) recipientJID(recipientType models.RecipientType, recipient string) (types.JID, error) {
+	switch recipientType {
+	case models.RecipientUser:
+		// Messages queued before numbers were normalized may still hold local formats
+		phoneNumber, err := normalizePhone(recipient, w.config.DefaultPhoneCountry)
+		if err != nil {
+			return types.JID{}, err
+		}
+		jid, err := types.ParseJID(phoneNumber + "@s.whatsapp.net")
+		if err != nil {
+			return types.JID{}, fmt.Errorf("%w: invalid phone number format: %v", ErrInvalidRecipient, err)
+		}
+		return jid, nil
+	case models.RecipientGroup:
+		// Group JID should be in format: groupId@g.us
+		jid, err := types.ParseJID(recipient)
+		if err != nil {
+			return types.JID{}, fmt.Errorf("%w: invalid group JID format: %v", ErrInvalidRecipient, err)
+		}
+		return jid, nil
+	default:
+		return types.JID{}, fmt.Errorf("%w: unknown recipient type %q", ErrInvalidRecipient, recipientType)
+	}
 }
 
 // Event handler for WhatsApp events
@@ -293,28 +479,3 @@ func (w *WhatsAppService) Close() error {
 	}
 	return nil
 }
-
-func SellRequestWhatAppMessage(userName string) string {
-	greeting := "Hello Sir/Madam,"
-	if userName != "" {
-		greeting = fmt.Sprintf("Hello %s,", userName)
-	}
-
-	return fmt.Sprintf(`%s
-We have received your property posting request 
-Please share the following details to allow us to list your property 
-- Property size
-- Type of property (NA, Gunta etc...)
-- Facing of the property
-- Google map location 
-- 2-3 images of your property
-- Size of the property 
-- Utraa Copy (for internal records keeping)
-
-If you have any quires please feel free to call us.
-
-Best regards,
-Easyplots Team`, greeting)
-}
-
-
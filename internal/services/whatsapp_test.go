@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/config"
+	"github.com/Mohammed-Yasin-Mulla/easyplots-whtasapp.git/internal/models"
+)
+
+func TestBuildMessageText(t *testing.T) {
+	msg, err := (&WhatsAppService{}).buildMessage(context.Background(), models.OutboundMessage{Text: "hello"})
+	if err != nil || msg.GetConversation() != "hello" {
+		t.Errorf("unexpected message %v, err %v", msg, err)
+	}
+}
+
+func TestBuildMessageMissingMedia(t *testing.T) {
+	payload := models.OutboundMessage{Media: &models.MediaAttachment{
+		Kind: models.MediaDocument,
+		Path: filepath.Join(t.TempDir(), "missing.pdf"),
+	}}
+
+	// The file is read before anything is uploaded, so no client is needed
+	_, err := (&WhatsAppService{}).buildMessage(context.Background(), payload)
+	if !errors.Is(err, ErrMediaUnavailable) {
+		t.Errorf("expected ErrMediaUnavailable, got %v", err)
+	}
+}
+
+func TestBuildMessageLocation(t *testing.T) {
+	payload := models.OutboundMessage{Location: &models.Location{Latitude: 15.36, Longitude: 75.12, Name: "Corner plot"}}
+	msg, err := (&WhatsAppService{}).buildMessage(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location := msg.GetLocationMessage()
+	if location.GetDegreesLatitude() != 15.36 || location.GetDegreesLongitude() != 75.12 || location.GetName() != "Corner plot" || location.Address != nil {
+		t.Errorf("unexpected location message %v", location)
+	}
+}
+
+func TestBuildMessageLinkPreview(t *testing.T) {
+	payload := models.OutboundMessage{
+		Text: "Link: https://easyplots.in/property/42",
+		Preview: &models.LinkPreview{
+			URL:         "https://easyplots.in/property/42",
+			Title:       "Corner plot",
+			Description: "30x40",
+			// An unreadable thumbnail must not hold back the message
+			Image: &models.MediaAttachment{Kind: models.MediaImage, Path: filepath.Join(t.TempDir(), "missing.jpg")},
+		},
+	}
+	msg, err := (&WhatsAppService{}).buildMessage(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := msg.GetExtendedTextMessage()
+	if card.GetText() != payload.Text || card.GetMatchedText() != "https://easyplots.in/property/42" || card.GetTitle() != "Corner plot" || card.GetDescription() != "30x40" {
+		t.Errorf("unexpected card %v", card)
+	}
+	if card.JPEGThumbnail != nil {
+		t.Error("expected no thumbnail")
+	}
+}
+
+func TestRecipientJIDNormalizesPhones(t *testing.T) {
+	service := &WhatsAppService{config: &config.Config{DefaultPhoneCountry: "IN"}}
+	for _, phone := range []string{"+91 90355 77330", "09035577330", "9035577330", "919035577330"} {
+		jid, err := service.recipientJID(models.RecipientUser, phone)
+		if err != nil || jid.String() != "919035577330@s.whatsapp.net" {
+			t.Errorf("recipientJID(%q) = %v, %v", phone, jid, err)
+		}
+	}
+
+	if _, err := service.recipientJID(models.RecipientUser, "12345"); !errors.Is(err, ErrInvalidRecipient) {
+		t.Errorf("expected ErrInvalidRecipient, got %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+package services
+
+// Workflows groups the conversation flows the HTTP handlers can start.
+// A nil field means the flow is disabled.
+type Workflows struct {
+	RentalIntake    RentalIntakeStarter
+	SellChecklist   SellChecklistStarter
+	AccountDeletion AccountDeletionStarter
+}